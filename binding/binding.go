@@ -6,14 +6,25 @@
 
 package binding
 
-import "net/http"
+import (
+	"bufio"
+	"errors"
+	"net/http"
+	"strings"
+)
 
 // Content-Type MIME of the most common data formats.
 const (
+	MIMEJSON              = "application/json"
 	MIMEPOSTForm          = "application/x-www-form-urlencoded"
 	MIMEMultipartPOSTForm = "multipart/form-data"
+	MIMEGOB               = "application/x-gob"
 )
 
+// ErrUnsupportedMediaType is returned by Default if there is no binding for
+// the content type, it's responded with HTTP 415 by c.MustBind.
+var ErrUnsupportedMediaType = errors.New("unsupported media type")
+
 // Binding describes the interface which needs to be implemented for binding the
 // data present in the request such as JSON request body, query parameters or
 // the form POST.
@@ -57,7 +68,9 @@ type StructValidator interface {
 var Validator StructValidator = &defaultValidator{}
 
 // These implement the Binding interface and can be used to bind the data
-// present in the request to struct instances.
+// present in the request to struct instances. GOB is never selected by
+// Default, since decoding gob from untrusted clients isn't safe, it's used
+// only explicitly, such as c.BindWith(obj, binding.GOB).
 var (
 	JSON          BindingBody = jsonBinding{}
 	Query         Binding     = queryBinding{}
@@ -66,8 +79,83 @@ var (
 	FormMultipart Binding     = formMultipartBinding{}
 	Uri           BindingUri  = uriBinding{}
 	Header        Binding     = headerBinding{}
+	GOB           BindingBody = gobBinding{}
 )
 
+// EnableContentTypeSniffing enables sniffing the content type of the body by
+// SniffContentType for the requests without Content-Type, so that c.Bind can
+// bind the JSON body sent without Content-Type.
+var EnableContentTypeSniffing = false
+
+// sniffLen is the maximum number of bytes peeked by SniffContentType.
+const sniffLen = 512
+
+// SniffContentType peeks the body in r, and returns MIMEJSON if the first
+// non-space byte is "{" or "[", otherwise "". At most the first 512 bytes are
+// peeked, which are still available to read from r.
+func SniffContentType(r *bufio.Reader) string {
+	for n := 1; n <= sniffLen; n++ {
+		b, _ := r.Peek(n)
+		if len(b) < n {
+			return ""
+		}
+		switch b[n-1] {
+		case ' ', '\t', '\r', '\n':
+			continue
+		case '{', '[':
+			return MIMEJSON
+		default:
+			return ""
+		}
+	}
+	return ""
+}
+
+// Default returns the appropriate Binding instance based on the HTTP method
+// and the content type. The Form binding is used for GET requests and the
+// requests without content type, ErrUnsupportedMediaType is returned for
+// the content type which no binding can handle. The content type is matched
+// case-insensitively.
+func Default(method, contentType string) (Binding, error) {
+	if method == http.MethodGet || contentType == "" {
+		return Form, nil
+	}
+
+	switch strings.ToLower(contentType) {
+	case MIMEJSON:
+		return JSON, nil
+	case MIMEPOSTForm:
+		return Form, nil
+	case MIMEMultipartPOSTForm:
+		return FormMultipart, nil
+	default:
+		return nil, ErrUnsupportedMediaType
+	}
+}
+
+// BindOptions are the options of BindWithOptions.
+type BindOptions struct {
+	// SkipValidation skips the validation after the data is bound, which is
+	// only supported by the built-in bindings.
+	SkipValidation bool
+}
+
+// decoder is implemented by the built-in bindings, it binds the data without
+// validation.
+type decoder interface {
+	decode(*http.Request, interface{}) error
+}
+
+// BindWithOptions binds the data present in the request to obj by b with the
+// given options. The validation is performed regardless of SkipValidation if
+// b is not a built-in binding.
+func BindWithOptions(req *http.Request, obj interface{}, b Binding, options BindOptions) error {
+	if d, ok := b.(decoder); ok && options.SkipValidation {
+		return d.decode(req, obj)
+	}
+	return b.Bind(req, obj)
+}
+
 func validate(obj interface{}) error {
 	if Validator == nil {
 		return nil
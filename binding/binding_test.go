@@ -7,6 +7,7 @@
 package binding
 
 import (
+	"bufio"
 	"bytes"
 	"encoding/json"
 	"io"
@@ -143,6 +144,53 @@ type FooStructForMapPtrType struct {
 	PtrBar *map[string]interface{} `form:"ptr_bar"`
 }
 
+func TestSniffContentType(t *testing.T) {
+	tests := map[string]string{
+		`{"foo":"bar"}`:                 MIMEJSON,
+		`[1, 2]`:                        MIMEJSON,
+		" \t\r\n{}":                     MIMEJSON,
+		"foo=bar":                       "",
+		"":                              "",
+		"   ":                           "",
+		strings.Repeat(" ", 600) + "{}": "",
+	}
+
+	for body, expected := range tests {
+		r := bufio.NewReader(strings.NewReader(body))
+		assert.Equal(t, expected, SniffContentType(r))
+		b, err := ioutil.ReadAll(r)
+		assert.NoError(t, err)
+		assert.Equal(t, body, string(b))
+	}
+}
+
+func TestDefault(t *testing.T) {
+	tests := []struct {
+		method      string
+		contentType string
+		expected    Binding
+		expectedErr error
+	}{
+		{"GET", "", Form, nil},
+		{"GET", MIMEJSON, Form, nil},
+		{"POST", "", Form, nil},
+		{"POST", MIMEJSON, JSON, nil},
+		{"POST", "Application/JSON", JSON, nil},
+		{"POST", "Multipart/Form-Data", FormMultipart, nil},
+		{"PUT", MIMEPOSTForm, Form, nil},
+		{"PATCH", MIMEMultipartPOSTForm, FormMultipart, nil},
+		{"POST", MIMEGOB, nil, ErrUnsupportedMediaType},
+		{"POST", "application/octet-stream", nil, ErrUnsupportedMediaType},
+		{"DELETE", "text/xml", nil, ErrUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		b, err := Default(tt.method, tt.contentType)
+		assert.Equal(t, tt.expected, b)
+		assert.Equal(t, tt.expectedErr, err)
+	}
+}
+
 func TestValidate(t *testing.T) {
 	v := Validator
 	Validator = nil
@@ -150,6 +198,54 @@ func TestValidate(t *testing.T) {
 	Validator = v
 }
 
+type customBinding struct{}
+
+func (customBinding) Bind(req *http.Request, obj interface{}) error {
+	return validate(obj)
+}
+
+func TestBindWithOptions(t *testing.T) {
+	type foo struct {
+		Foo string `json:"foo" form:"foo" header:"foo" validate:"required"`
+	}
+
+	tests := []struct {
+		name        string
+		binding     Binding
+		contentType string
+	}{
+		{"json", JSON, MIMEJSON},
+		{"query", Query, ""},
+		{"form", Form, MIMEPOSTForm},
+		{"form post", FormPost, MIMEPOSTForm},
+		{"header", Header, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newRequest := func() *http.Request {
+				req := requestWithBody("POST", "/?bar=1", "{}")
+				if tt.contentType != "" {
+					req.Header.Set("Content-Type", tt.contentType)
+				}
+				return req
+			}
+
+			var obj foo
+			assert.Error(t, BindWithOptions(newRequest(), &obj, tt.binding, BindOptions{}))
+			assert.NoError(t, BindWithOptions(newRequest(), &obj, tt.binding, BindOptions{SkipValidation: true}))
+		})
+	}
+
+	req := requestWithBody("POST", "/", "--boundary--")
+	req.Header.Set("Content-Type", MIMEMultipartPOSTForm+"; boundary=boundary")
+	var obj foo
+	assert.NoError(t, BindWithOptions(req, &obj, FormMultipart, BindOptions{SkipValidation: true}))
+
+	// the validation of custom bindings can't be skipped
+	assert.Error(t, BindWithOptions(requestWithBody("POST", "/", ""), &obj, customBinding{}, BindOptions{SkipValidation: true}))
+}
+
 func TestBindingJSONUseNumber(t *testing.T) {
 	testBodyBindingUseNumber(t,
 		JSON,
@@ -389,6 +485,22 @@ func TestBindingDefaultValueFormPost(t *testing.T) {
 	assert.Equal(t, "hello", obj.Bar)
 }
 
+func TestBindingFormPostForStructSlice(t *testing.T) {
+	req := requestWithBody("POST", "/", "items[1].name=b&items[0].name=a&items[0].price=1.5")
+	req.Header.Set("Content-Type", MIMEPOSTForm)
+	var obj struct {
+		Items []struct {
+			Name  string  `form:"name"`
+			Price float64 `form:"price"`
+		} `form:"items"`
+	}
+	assert.NoError(t, FormPost.Bind(req, &obj))
+	assert.Len(t, obj.Items, 2)
+	assert.Equal(t, "a", obj.Items[0].Name)
+	assert.Equal(t, 1.5, obj.Items[0].Price)
+	assert.Equal(t, "b", obj.Items[1].Name)
+}
+
 func TestBindingFormPostForMap(t *testing.T) {
 	req := createFormPostRequestForMap(t)
 	var obj FooStructForMapType
@@ -8,44 +8,88 @@ import (
 	"net/http"
 )
 
-const defaultMemory = 32 << 20
+// MaxMultipartMemory is the maximum bytes of memory used to parse multipart
+// forms, the remainder of file parts is stored on disk in temporary files.
+var MaxMultipartMemory int64 = 32 << 20 // 32 MB
+
+// WithMaxMultipartMemory returns a copy of b which parses multipart forms with
+// at most size bytes of memory instead of MaxMultipartMemory if b is Form or
+// FormMultipart, otherwise b itself is returned.
+func WithMaxMultipartMemory(b Binding, size int64) Binding {
+	switch fb := b.(type) {
+	case formBinding:
+		fb.maxMemory = size
+		return fb
+	case formMultipartBinding:
+		fb.maxMemory = size
+		return fb
+	}
+	return b
+}
+
+// maxMemory returns size, or MaxMultipartMemory if it's not positive.
+func maxMemory(size int64) int64 {
+	if size <= 0 {
+		return MaxMultipartMemory
+	}
+	return size
+}
+
+type formBinding struct {
+	// maxMemory falls back to MaxMultipartMemory if it's zero.
+	maxMemory int64
+}
 
-type formBinding struct{}
 type formPostBinding struct{}
-type formMultipartBinding struct{}
 
-func (formBinding) Bind(req *http.Request, obj interface{}) error {
+type formMultipartBinding struct {
+	// maxMemory falls back to MaxMultipartMemory if it's zero.
+	maxMemory int64
+}
+
+func (b formBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := b.decode(req, obj); err != nil {
+		return err
+	}
+	return validate(obj)
+}
+
+func (b formBinding) decode(req *http.Request, obj interface{}) error {
 	if err := req.ParseForm(); err != nil {
 		return err
 	}
-	if err := req.ParseMultipartForm(defaultMemory); err != nil {
+	if err := req.ParseMultipartForm(maxMemory(b.maxMemory)); err != nil {
 		if err != http.ErrNotMultipart {
 			return err
 		}
 	}
-	if err := mapForm(obj, req.Form); err != nil {
+	return mapForm(obj, req.Form)
+}
+
+func (b formPostBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := b.decode(req, obj); err != nil {
 		return err
 	}
 	return validate(obj)
 }
 
-func (formPostBinding) Bind(req *http.Request, obj interface{}) error {
+func (formPostBinding) decode(req *http.Request, obj interface{}) error {
 	if err := req.ParseForm(); err != nil {
 		return err
 	}
-	if err := mapForm(obj, req.PostForm); err != nil {
+	return mapForm(obj, req.PostForm)
+}
+
+func (b formMultipartBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := b.decode(req, obj); err != nil {
 		return err
 	}
 	return validate(obj)
 }
 
-func (formMultipartBinding) Bind(req *http.Request, obj interface{}) error {
-	if err := req.ParseMultipartForm(defaultMemory); err != nil {
-		return err
-	}
-	if err := mappingByPtr(obj, (*multipartRequest)(req), "form"); err != nil {
+func (b formMultipartBinding) decode(req *http.Request, obj interface{}) error {
+	if err := req.ParseMultipartForm(maxMemory(b.maxMemory)); err != nil {
 		return err
 	}
-
-	return validate(obj)
+	return mappingByPtr(obj, (*multipartRequest)(req), "form")
 }
@@ -7,19 +7,23 @@
 package binding
 
 import (
-	"encoding/json"
+	"encoding"
 	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/soongo/soon/internal/json"
 	"github.com/soongo/soon/util"
 )
 
 var errUnknownType = errors.New("unknown type")
 
+var timeType = reflect.TypeOf(time.Time{})
+
 func mapUri(ptr interface{}, m map[string][]string) error {
 	return mapFormByTag(ptr, m, "uri")
 }
@@ -110,11 +114,15 @@ func mapping(value reflect.Value, field reflect.StructField, setter setter, tag
 type setOptions struct {
 	isDefaultExists bool
 	defaultValue    string
+
+	// the tag name of struct fields, used to map the indexed keys into slice
+	// of structs, such as `items[0].name`
+	tag string
 }
 
 func tryToSetValue(value reflect.Value, field reflect.StructField, setter setter, tag string) (bool, error) {
 	var tagValue string
-	var setOpt setOptions
+	setOpt := setOptions{tag: tag}
 
 	tagValue = field.Tag.Get(tag)
 	tagValue, opts := head(tagValue, ",")
@@ -141,11 +149,21 @@ func tryToSetValue(value reflect.Value, field reflect.StructField, setter setter
 
 func setByForm(value reflect.Value, field reflect.StructField, form map[string][]string, tagValue string, opt setOptions) (isSetted bool, err error) {
 	vs, ok := form[tagValue]
+	if !ok && value.Kind() == reflect.Slice && isStructType(value.Type().Elem()) {
+		if isSetted, err = setByIndexedForm(value, form, tagValue, opt.tag); isSetted || err != nil {
+			return isSetted, err
+		}
+	}
 	if !ok && !opt.isDefaultExists {
 		return false, nil
 	}
 
-	switch value.Kind() {
+	kind := value.Kind()
+	if _, isUnmarshaler := textUnmarshaler(value); isUnmarshaler {
+		kind = reflect.String // bound from a single value, such as net.IP
+	}
+
+	switch kind {
 	case reflect.Slice:
 		if !ok {
 			vs = []string{opt.defaultValue}
@@ -172,7 +190,60 @@ func setByForm(value reflect.Value, field reflect.StructField, form map[string][
 	}
 }
 
+// isStructType reports whether t is a struct or pointer to struct, which is
+// mapped field by field, rather than from a single value.
+func isStructType(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct && t != timeType && !reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// setByIndexedForm sets the slice of structs by the indexed keys, such as
+// `items[0].name=a&items[1].name=b`. The elements are ordered by index, and
+// the missing indexes are skipped.
+func setByIndexedForm(value reflect.Value, form map[string][]string, key, tag string) (bool, error) {
+	prefix := key + "["
+	subForms := make(map[int]map[string][]string)
+	for k, vs := range form {
+		if !strings.HasPrefix(k, prefix) {
+			continue
+		}
+		index, rest := head(k[len(prefix):], "]")
+		i, err := strconv.Atoi(index)
+		if err != nil || i < 0 || !strings.HasPrefix(rest, ".") {
+			continue
+		}
+		if subForms[i] == nil {
+			subForms[i] = make(map[string][]string)
+		}
+		subForms[i][rest[1:]] = vs
+	}
+	if len(subForms) == 0 {
+		return false, nil
+	}
+
+	indexes := make([]int, 0, len(subForms))
+	for i := range subForms {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	slice := reflect.MakeSlice(value.Type(), len(indexes), len(indexes))
+	for i, index := range indexes {
+		if _, err := mapping(slice.Index(i), emptyField, formSource(subForms[index]), tag); err != nil {
+			return false, err
+		}
+	}
+	value.Set(slice)
+	return true, nil
+}
+
 func setWithProperType(val string, value reflect.Value, field reflect.StructField) error {
+	if u, ok := textUnmarshaler(value); ok {
+		return u.UnmarshalText(util.StringToBytes(val))
+	}
+
 	switch value.Kind() {
 	case reflect.Int:
 		return setIntField(val, 0, value)
@@ -220,6 +291,18 @@ func setWithProperType(val string, value reflect.Value, field reflect.StructFiel
 	return nil
 }
 
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// textUnmarshaler returns the addressable value as encoding.TextUnmarshaler
+// if it implements the interface, except time.Time which is set by the
+// time_format tag.
+func textUnmarshaler(value reflect.Value) (encoding.TextUnmarshaler, bool) {
+	if !value.CanAddr() || value.Type() == timeType || !reflect.PtrTo(value.Type()).Implements(textUnmarshalerType) {
+		return nil, false
+	}
+	return value.Addr().Interface().(encoding.TextUnmarshaler), true
+}
+
 func setIntField(val string, bitSize int, field reflect.Value) error {
 	if val == "" {
 		val = "0"
@@ -5,6 +5,8 @@
 package binding
 
 import (
+	"errors"
+	"net"
 	"reflect"
 	"testing"
 	"time"
@@ -190,6 +192,44 @@ func TestMappingTime(t *testing.T) {
 	assert.Error(t, err)
 }
 
+type testLevel int
+
+func (l *testLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low":
+		*l = 1
+	case "high":
+		*l = 2
+	default:
+		return errors.New("invalid level")
+	}
+	return nil
+}
+
+func TestMappingTextUnmarshaler(t *testing.T) {
+	var s struct {
+		Level  testLevel   `form:"level"`
+		Levels []testLevel `form:"levels"`
+		Ptr    *testLevel  `form:"ptr"`
+		IP     net.IP      `form:"ip"`
+	}
+
+	err := mapForm(&s, map[string][]string{
+		"level":  {"high"},
+		"levels": {"low", "high"},
+		"ptr":    {"low"},
+		"ip":     {"127.0.0.1"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, testLevel(2), s.Level)
+	assert.Equal(t, []testLevel{1, 2}, s.Levels)
+	assert.Equal(t, testLevel(1), *s.Ptr)
+	assert.Equal(t, "127.0.0.1", s.IP.String())
+
+	err = mapForm(&s, map[string][]string{"level": {"wrong"}})
+	assert.EqualError(t, err, "invalid level")
+}
+
 func TestMapiingTimeDuration(t *testing.T) {
 	var s struct {
 		D time.Duration
@@ -260,6 +300,48 @@ func TestMappingStructField(t *testing.T) {
 	assert.Equal(t, 9, s.J.I)
 }
 
+func TestMappingStructSliceField(t *testing.T) {
+	type tag struct {
+		Name string `form:"name"`
+	}
+	type item struct {
+		Name  string `form:"name"`
+		Count int    `form:"count"`
+		Tags  []tag  `form:"tags"`
+	}
+	var s struct {
+		Items    []item  `form:"items"`
+		Pointers []*item `form:"pointers"`
+		Empty    []item  `form:"empty"`
+	}
+
+	err := mapForm(&s, map[string][]string{
+		"items[10].name":         {"c"},
+		"items[0].name":          {"a"},
+		"items[0].count":         {"1"},
+		"items[0].tags[0].name":  {"x"},
+		"items[2].name":          {"b"},
+		"items[2].count":         {"2"},
+		"items[x].name":          {"ignored"},
+		"items[3]name":           {"ignored"},
+		"pointers[0].name":       {"p"},
+		"itemsfoo[0].name":       {"ignored"},
+		"items[0].tags[1].other": {"ignored"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []item{
+		{Name: "a", Count: 1, Tags: []tag{{"x"}, {}}},
+		{Name: "b", Count: 2},
+		{Name: "c"},
+	}, s.Items)
+	assert.Len(t, s.Pointers, 1)
+	assert.Equal(t, "p", s.Pointers[0].Name)
+	assert.Nil(t, s.Empty)
+
+	err = mapForm(&s, map[string][]string{"items[0].count": {"wrong"}})
+	assert.Error(t, err)
+}
+
 func TestMappingMapField(t *testing.T) {
 	var s struct {
 		M map[string]int
@@ -0,0 +1,40 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package binding
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"io"
+	"net/http"
+)
+
+type gobBinding struct{}
+
+func (b gobBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := b.decode(req, obj); err != nil {
+		return err
+	}
+	return validate(obj)
+}
+
+func (gobBinding) decode(req *http.Request, obj interface{}) error {
+	if req == nil || req.Body == nil {
+		return errors.New("invalid request")
+	}
+	return decodeGOB(req.Body, obj)
+}
+
+func (gobBinding) BindBody(body []byte, obj interface{}) error {
+	if err := decodeGOB(bytes.NewReader(body), obj); err != nil {
+		return err
+	}
+	return validate(obj)
+}
+
+func decodeGOB(r io.Reader, obj interface{}) error {
+	return gob.NewDecoder(r).Decode(obj)
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package binding
+
+import (
+	"bytes"
+	"encoding/gob"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type gobShape interface {
+	Area() float64
+}
+
+type gobSquare struct {
+	Side float64
+}
+
+func (s gobSquare) Area() float64 {
+	return s.Side * s.Side
+}
+
+type gobRoot struct {
+	Name  string `validate:"required"`
+	Shape gobShape
+}
+
+func init() {
+	gob.Register(gobSquare{})
+}
+
+func encodeGOB(t *testing.T, v interface{}) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(v))
+	return buf.Bytes()
+}
+
+func TestGobBinding_Bind(t *testing.T) {
+	body := encodeGOB(t, gobRoot{Name: "foo", Shape: gobSquare{Side: 2}})
+
+	var obj gobRoot
+	req := httptest.NewRequest("POST", "/", bytes.NewReader(body))
+	req.Header.Set("Content-Type", MIMEGOB)
+	require.NoError(t, GOB.Bind(req, &obj))
+	assert.Equal(t, "foo", obj.Name)
+	assert.Equal(t, gobSquare{Side: 2}, obj.Shape)
+	assert.Equal(t, float64(4), obj.Shape.Area())
+
+	obj = gobRoot{}
+	require.NoError(t, GOB.BindBody(body, &obj))
+	assert.Equal(t, "foo", obj.Name)
+
+	obj = gobRoot{}
+	body = encodeGOB(t, gobRoot{Shape: gobSquare{Side: 2}})
+	assert.Error(t, GOB.BindBody(body, &obj))
+
+	obj = gobRoot{}
+	body = encodeGOB(t, gobRoot{Shape: gobSquare{Side: 2}})
+	req = httptest.NewRequest("POST", "/", bytes.NewReader(body))
+	assert.NoError(t, BindWithOptions(req, &obj, GOB, BindOptions{SkipValidation: true}))
+
+	assert.Error(t, GOB.Bind(requestWithBody("POST", "/", "foo"), &obj))
+	assert.Error(t, GOB.Bind(nil, &obj))
+}
@@ -12,14 +12,17 @@ import (
 
 type headerBinding struct{}
 
-func (headerBinding) Bind(req *http.Request, obj interface{}) error {
-	if err := mapHeader(obj, req.Header); err != nil {
+func (b headerBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := b.decode(req, obj); err != nil {
 		return err
 	}
-
 	return validate(obj)
 }
 
+func (headerBinding) decode(req *http.Request, obj interface{}) error {
+	return mapHeader(obj, req.Header)
+}
+
 func mapHeader(ptr interface{}, h map[string][]string) error {
 	return mappingByPtr(ptr, headerSource(h), "header")
 }
@@ -8,10 +8,11 @@ package binding
 
 import (
 	"bytes"
-	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
+
+	"github.com/soongo/soon/internal/json"
 )
 
 // EnableDecoderUseNumber is used to call the UseNumber method on the JSON
@@ -25,29 +26,80 @@ var EnableDecoderUseNumber = false
 // keys which do not match any non-ignored, exported fields in the destination.
 var EnableDecoderDisallowUnknownFields = false
 
-type jsonBinding struct{}
+// JSONOptions are the options of the JSON decoder, see JSONWith.
+type JSONOptions struct {
+	// UseNumber is the same as EnableDecoderUseNumber, but for one binding.
+	UseNumber bool
+
+	// DisallowUnknownFields is the same as EnableDecoderDisallowUnknownFields,
+	// but for one binding.
+	DisallowUnknownFields bool
+}
 
-func (jsonBinding) Bind(req *http.Request, obj interface{}) error {
+// JSONWith returns a JSON binding which decodes with the given options
+// instead of the package-level EnableDecoderUseNumber and
+// EnableDecoderDisallowUnknownFields, e.g.
+//
+//	c.MustBindWith(&obj, binding.JSONWith(binding.JSONOptions{DisallowUnknownFields: true}))
+func JSONWith(options JSONOptions) BindingBody {
+	return jsonBinding{options: &options}
+}
+
+// WithJSONCodec returns a copy of b which decodes with the given JSON codec
+// if b is a JSON binding, such as JSON or the one returned by JSONWith,
+// otherwise b itself is returned.
+func WithJSONCodec(b Binding, codec json.Codec) Binding {
+	if jb, ok := b.(jsonBinding); ok {
+		jb.codec = codec
+		return jb
+	}
+	return b
+}
+
+type jsonBinding struct {
+	// options falls back to the package-level variables if it's nil.
+	options *JSONOptions
+
+	// codec falls back to the one set by soon.SetJSONCodec if it's nil.
+	codec json.Codec
+}
+
+func (b jsonBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := b.decode(req, obj); err != nil {
+		return err
+	}
+	return validate(obj)
+}
+
+func (b jsonBinding) decode(req *http.Request, obj interface{}) error {
 	if req == nil || req.Body == nil {
 		return errors.New("invalid request")
 	}
-	return decodeJSON(req.Body, obj)
+	return b.decodeJSON(req.Body, obj)
 }
 
-func (jsonBinding) BindBody(body []byte, obj interface{}) error {
-	return decodeJSON(bytes.NewReader(body), obj)
+func (b jsonBinding) BindBody(body []byte, obj interface{}) error {
+	if err := b.decodeJSON(bytes.NewReader(body), obj); err != nil {
+		return err
+	}
+	return validate(obj)
 }
 
-func decodeJSON(r io.Reader, obj interface{}) error {
-	decoder := json.NewDecoder(r)
-	if EnableDecoderUseNumber {
+func (b jsonBinding) decodeJSON(r io.Reader, obj interface{}) error {
+	options := JSONOptions{
+		UseNumber:             EnableDecoderUseNumber,
+		DisallowUnknownFields: EnableDecoderDisallowUnknownFields,
+	}
+	if b.options != nil {
+		options = *b.options
+	}
+
+	decoder := json.Or(b.codec).NewDecoder(r)
+	if options.UseNumber {
 		decoder.UseNumber()
 	}
-	if EnableDecoderDisallowUnknownFields {
+	if options.DisallowUnknownFields {
 		decoder.DisallowUnknownFields()
 	}
-	if err := decoder.Decode(obj); err != nil {
-		return err
-	}
-	return validate(obj)
+	return decoder.Decode(obj)
 }
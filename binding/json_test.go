@@ -5,14 +5,57 @@
 package binding
 
 import (
+	"bufio"
+	"bytes"
+	stdjson "encoding/json"
+	"io"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/soongo/soon/internal/json"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
+// bufferedCodec is a custom Codec which decodes through a bufio.Reader, so
+// that the JSON bindings are tested and benchmarked against a codec other
+// than Std.
+type bufferedCodec struct {
+	decoders int
+}
+
+func (c *bufferedCodec) Marshal(v interface{}) ([]byte, error) {
+	return stdjson.Marshal(v)
+}
+
+func (c *bufferedCodec) Unmarshal(data []byte, v interface{}) error {
+	return c.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+func (c *bufferedCodec) NewEncoder(w io.Writer) json.Encoder {
+	return stdjson.NewEncoder(w)
+}
+
+func (c *bufferedCodec) NewDecoder(r io.Reader) json.Decoder {
+	c.decoders++
+	return stdjson.NewDecoder(bufio.NewReader(r))
+}
+
+// jsonCodecs returns the codecs which the JSON bindings are run against.
+func jsonCodecs() []struct {
+	name  string
+	codec json.Codec
+} {
+	return []struct {
+		name  string
+		codec json.Codec
+	}{
+		{"std", json.Std},
+		{"buffered", &bufferedCodec{}},
+	}
+}
+
 type jsonChild struct {
 	Name   string `json:"name" validate:"required,min=3"`
 	Age    int    `json:"age" validate:"gte=0,max=150"`
@@ -97,3 +140,100 @@ func TestJsonBinding_BindBody(t *testing.T) {
 		}
 	}
 }
+
+func TestJsonBinding_Codec(t *testing.T) {
+	tests := []struct {
+		name string
+		test func(t *testing.T)
+	}{
+		{"Bind", TestJsonBinding_Bind},
+		{"BindBody", TestJsonBinding_BindBody},
+		{"UseNumber", TestBindingJSONUseNumber},
+		{"UseNumber2", TestBindingJSONUseNumber2},
+		{"DisallowUnknownFields", TestBindingJSONDisallowUnknownFields},
+		{"JSONWith", TestJSONWith},
+	}
+
+	for _, c := range jsonCodecs() {
+		for _, tt := range tests {
+			t.Run(c.name+"/"+tt.name, func(t *testing.T) {
+				json.SetCodec(c.codec)
+				defer json.SetCodec(nil)
+				tt.test(t)
+			})
+		}
+		if b, ok := c.codec.(*bufferedCodec); ok {
+			assert.Greater(t, b.decoders, 2*len(jsonBindTests))
+		}
+	}
+}
+
+func BenchmarkJsonBinding_Codec(b *testing.B) {
+	body := []byte(jsonBindTests[0].json)
+	for _, c := range jsonCodecs() {
+		b.Run(c.name, func(b *testing.B) {
+			binding := WithJSONCodec(JSON, c.codec).(BindingBody)
+			b.ReportAllocs()
+			b.SetBytes(int64(len(body)))
+			for i := 0; i < b.N; i++ {
+				var obj jsonRoot
+				if err := binding.BindBody(body, &obj); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONWith(t *testing.T) {
+	body := `{"foo": 123, "bar": "baz"}`
+
+	var obj FooStructUseNumber
+	req := requestWithBody("POST", "/", body)
+	require.NoError(t, JSONWith(JSONOptions{UseNumber: true}).Bind(req, &obj))
+	assert.Equal(t, stdjson.Number("123"), obj.Foo)
+
+	obj = FooStructUseNumber{}
+	req = requestWithBody("POST", "/", body)
+	require.NoError(t, JSONWith(JSONOptions{}).Bind(req, &obj))
+	assert.Equal(t, float64(123), obj.Foo)
+
+	obj = FooStructUseNumber{}
+	require.NoError(t, JSONWith(JSONOptions{UseNumber: true}).BindBody([]byte(body), &obj))
+	assert.Equal(t, stdjson.Number("123"), obj.Foo)
+
+	var strict FooStructDisallowUnknownFields
+	req = requestWithBody("POST", "/", body)
+	err := JSONWith(JSONOptions{DisallowUnknownFields: true}).Bind(req, &strict)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "bar")
+
+	// the options don't fall back to the package-level variables
+	EnableDecoderUseNumber = true
+	defer func() {
+		EnableDecoderUseNumber = false
+	}()
+	obj = FooStructUseNumber{}
+	req = requestWithBody("POST", "/", body)
+	require.NoError(t, JSONWith(JSONOptions{}).Bind(req, &obj))
+	assert.Equal(t, float64(123), obj.Foo)
+	assert.False(t, EnableDecoderDisallowUnknownFields)
+}
+
+func TestWithJSONCodec(t *testing.T) {
+	body := `{"foo": 123, "bar": "baz"}`
+	codec := &bufferedCodec{}
+
+	var obj FooStructUseNumber
+	b := WithJSONCodec(JSONWith(JSONOptions{UseNumber: true}), codec)
+	require.NoError(t, b.Bind(requestWithBody("POST", "/", body), &obj))
+	assert.Equal(t, stdjson.Number("123"), obj.Foo)
+	require.NoError(t, b.(BindingBody).BindBody([]byte(body), &obj))
+	assert.Equal(t, 2, codec.decoders)
+
+	// the global codec isn't changed
+	require.NoError(t, JSON.Bind(requestWithBody("POST", "/", body), &obj))
+	assert.Equal(t, 2, codec.decoders)
+
+	assert.Equal(t, Query, WithJSONCodec(Query, codec))
+}
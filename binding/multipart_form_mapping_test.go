@@ -9,6 +9,7 @@ import (
 	"io/ioutil"
 	"mime/multipart"
 	"net/http"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -39,6 +40,29 @@ func TestFormMultipartBindingBindOneFile(t *testing.T) {
 	assertMultipartFileHeader(t, s.ArrayPtrs[0], file)
 }
 
+func TestFormMultipartBindingMaxMemory(t *testing.T) {
+	defer func(size int64) {
+		MaxMultipartMemory = size
+	}(MaxMultipartMemory)
+	MaxMultipartMemory = 8
+
+	var s struct {
+		File *multipart.FileHeader `form:"file"`
+	}
+	file := testFile{"file", "file1", bytes.Repeat([]byte("hello"), 100)}
+
+	req := createRequestMultipartFiles(t, file)
+	err := FormMultipart.Bind(req, &s)
+	assert.NoError(t, err)
+	assertMultipartFileHeader(t, s.File, file)
+
+	// the file part is larger than the memory limit, so it's stored on disk
+	f, err := s.File.Open()
+	assert.NoError(t, err)
+	defer f.Close()
+	assert.IsType(t, &os.File{}, f)
+}
+
 func TestFormMultipartBindingBindTwoFiles(t *testing.T) {
 	var s struct {
 		SliceValues []multipart.FileHeader   `form:"file"`
@@ -136,3 +160,25 @@ func assertMultipartFileHeader(t *testing.T, fh *multipart.FileHeader, file test
 	err = fl.Close()
 	assert.NoError(t, err)
 }
+
+func TestWithMaxMultipartMemory(t *testing.T) {
+	var s struct {
+		File *multipart.FileHeader `form:"file"`
+	}
+	file := testFile{"file", "file1", bytes.Repeat([]byte("a"), 100)}
+
+	for _, b := range []Binding{Form, FormMultipart} {
+		req := createRequestMultipartFiles(t, file)
+		assert.NoError(t, WithMaxMultipartMemory(b, 10).Bind(req, &s))
+
+		fh := req.MultipartForm.File["file"][0]
+		assertMultipartFileHeader(t, fh, file)
+		f, err := fh.Open()
+		assert.NoError(t, err)
+		_, onDisk := f.(*os.File)
+		assert.True(t, onDisk)
+		f.Close()
+	}
+
+	assert.Equal(t, FormPost, WithMaxMultipartMemory(FormPost, 10))
+}
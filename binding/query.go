@@ -8,10 +8,13 @@ import "net/http"
 
 type queryBinding struct{}
 
-func (queryBinding) Bind(req *http.Request, obj interface{}) error {
-	values := req.URL.Query()
-	if err := mapForm(obj, values); err != nil {
+func (b queryBinding) Bind(req *http.Request, obj interface{}) error {
+	if err := b.decode(req, obj); err != nil {
 		return err
 	}
 	return validate(obj)
 }
+
+func (queryBinding) decode(req *http.Request, obj interface{}) error {
+	return mapForm(obj, req.URL.Query())
+}
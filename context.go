@@ -5,9 +5,19 @@
 package soon
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
 	"fmt"
+	"html/template"
+	"io"
 	"io/ioutil"
+	"mime/multipart"
 	"net/http"
+	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
@@ -16,7 +26,6 @@ import (
 	"time"
 
 	"github.com/soongo/soon/binding"
-	"github.com/soongo/soon/internal"
 	"github.com/soongo/soon/renderer"
 	"github.com/soongo/soon/util"
 )
@@ -24,6 +33,15 @@ import (
 // BodyBytesKey indicates a default body bytes key.
 const BodyBytesKey = "_soongo/soon/bodybyteskey"
 
+// ErrResponseFinished is returned by c.Write after the response is finished.
+var ErrResponseFinished = errors.New("response is finished")
+
+// ErrNotSupported is returned by c.Push if the server push isn't supported,
+// such as over HTTP/1.x connections.
+var ErrNotSupported = errors.New("not supported")
+
+var _ io.Writer = &Context{}
+
 // Context is the most important part of soon.
 // It allows us to pass variables between middleware, manage the flow,
 // validate the JSON of a request and render a JSON response for example.
@@ -34,6 +52,9 @@ type Context struct {
 
 	next func(v ...interface{})
 
+	// matchRoute looks up the route matching the request, see Route.
+	matchRoute func() *Route
+
 	// Locals contains local variables scoped to the request,
 	// and therefore available during that request / response cycle (if any).
 	//
@@ -46,6 +67,15 @@ type Context struct {
 
 	// The finished property will be true if `context.End()` has been called.
 	finished bool
+
+	// bodyCached is true if the body is cached by the CacheBody middleware.
+	bodyCached bool
+
+	// panicked is true while the error of a recovered panic is handled, and
+	// panicStack is the stack trace of it, which is only captured in debug
+	// mode.
+	panicked   bool
+	panicStack []byte
 }
 
 // NewContext returns an instance of Context object
@@ -61,6 +91,22 @@ func (c *Context) Next(v ...interface{}) {
 	c.next(v...)
 }
 
+// Route returns the route matching the request, which is the one being
+// handled, or the one to be handled after the current middleware. It's nil
+// if no route matches, or the context isn't served by a router. The metadata
+// of route can be used by the middlewares, such as checking the scope:
+//
+//	if scope, ok := c.Route().Meta("scope"); ok && !hasScope(c, scope) {
+//		c.SendStatus(http.StatusForbidden)
+//		return
+//	}
+func (c *Context) Route() *Route {
+	if c.matchRoute == nil {
+		return nil
+	}
+	return c.matchRoute()
+}
+
 // SetLocal is used to store a new key/value pair in locals for this context.
 // It also lazy initializes c.Locals if it was not used previously.
 func (c *Context) SetLocal(k string, v interface{}) {
@@ -112,6 +158,85 @@ func (c *Context) ResetLocals(m map[string]interface{}) {
 	}
 }
 
+// SetValue stores `val` under `key` in the request context, so that it's
+// also available to the wrapped http.Handler via r.Context().Value(key).
+// Unlike locals, the key can be of any comparable type, and an unexported
+// key type should be used to avoid collisions between packages.
+func (c *Context) SetValue(key, val interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	ctx := context.WithValue(c.Request.Context(), key, val)
+	c.Request.Request = c.Request.WithContext(ctx)
+}
+
+// Value returns the value stored under `key` in the request context, or nil
+// if there is no value associated with key.
+func (c *Context) Value(key interface{}) interface{} {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.Request.Context().Value(key)
+}
+
+// Param returns the value of the named route parameter.
+// It is a shortcut for c.Request.Params.Get(name), and returns an empty
+// string if the parameter does not exist.
+func (c *Context) Param(name string) string {
+	return c.Request.Params.Get(name)
+}
+
+// ParamAt returns the value of the unnamed route parameter at index `i`,
+// such as the wildcard parameter `(.*)`. It returns an empty string if the
+// parameter does not exist.
+func (c *Context) ParamAt(i int) string {
+	return c.Request.Params.Get(i)
+}
+
+// Query returns the first value of the url query associated with the given
+// name. It is a shortcut for c.Request.Query.Get(name), and returns an empty
+// string if there are no values associated with the name.
+func (c *Context) Query(name string) string {
+	return c.Request.Query.Get(name)
+}
+
+// DefaultQuery returns the first value of the url query associated with the
+// given name if it exists, otherwise it returns the specified `def` value.
+func (c *Context) DefaultQuery(name, def string) string {
+	if values, ok := c.Request.Query[name]; ok && len(values) > 0 {
+		return values[0]
+	}
+	return def
+}
+
+// QueryArray returns all values of the url query associated with the given
+// name. It returns an empty slice if there are no values.
+//
+//	GET /?tag=a&tag=b
+//	c.QueryArray("tag") == []string{"a", "b"}
+func (c *Context) QueryArray(name string) []string {
+	if values, ok := c.Request.Query[name]; ok {
+		return values
+	}
+	return []string{}
+}
+
+// QueryMap returns a map for the url queries in the form of `name[key]=value`
+// associated with the given name. It returns an empty map if there are no
+// such queries. Only the first value is used if a key has multiple values.
+//
+//	GET /?ids[a]=x&ids[b]=y
+//	c.QueryMap("ids") == map[string]string{"a": "x", "b": "y"}
+func (c *Context) QueryMap(name string) map[string]string {
+	m := make(map[string]string)
+	for k, values := range c.Request.Query {
+		if i := strings.IndexByte(k, '['); i >= 1 && k[:i] == name {
+			if j := strings.IndexByte(k[i+1:], ']'); j >= 1 && len(values) > 0 {
+				m[k[i+1:][:j]] = values[0]
+			}
+		}
+	}
+	return m
+}
+
 // HeadersSent indicates if the response header was already sent.
 func (c *Context) HeadersSent() bool {
 	return c.Writer.HeaderWritten()
@@ -122,8 +247,21 @@ func (c *Context) HeadersSent() bool {
 // specified value. The value parameter can be a string or a string slice.
 // Note: calling c.Set() after c.Append() will reset the previously-set
 // header value.
-func (c *Context) Append(key string, value interface{}) {
+func (c *Context) Append(key string, value interface{}) *Context {
+	if c.ignoredAfterFinished("Append") {
+		return c
+	}
 	util.AddHeader(c.Writer, key, value)
+	return c
+}
+
+// ignoredAfterFinished reports whether the response is finished, so that the
+// header-mutating method should be ignored, and warns it in debug mode.
+func (c *Context) ignoredAfterFinished(method string) bool {
+	if c.finished {
+		debugPrintWARNING("c.%s is ignored since the response is finished", method)
+	}
+	return c.finished
 }
 
 // Get the first value of response header associated with the given key.
@@ -138,8 +276,12 @@ func (c *Context) Get(field string) string {
 // associated with key. The key is case insensitive;
 //
 // To set multiple fields at once, pass a string map as the parameter.
-func (c *Context) Set(value ...interface{}) {
+func (c *Context) Set(value ...interface{}) *Context {
+	if c.ignoredAfterFinished("Set") {
+		return c
+	}
 	util.SetHeader(c.Writer, value...)
+	return c
 }
 
 func (c *Context) del(field string) {
@@ -148,13 +290,23 @@ func (c *Context) del(field string) {
 
 // Vary adds `field` to Vary. If already present in the Vary set, then
 // this call is simply ignored.
-func (c *Context) Vary(fields ...string) {
+func (c *Context) Vary(fields ...string) *Context {
+	if c.ignoredAfterFinished("Vary") {
+		return c
+	}
 	util.Vary(c.Writer, fields)
+	return c
 }
 
-// Status sets the HTTP status for the response.
-func (c *Context) Status(code int) {
+// Status sets the HTTP status for the response. The header is not written
+// until the response body is sent, and the context is returned for chaining,
+// such as c.Status(201).Json(v).
+func (c *Context) Status(code int) *Context {
+	if c.ignoredAfterFinished("Status") {
+		return c
+	}
 	c.Writer.WriteHeader(code)
+	return c
 }
 
 // SendStatus sets the response HTTP status code to statusCode and
@@ -164,15 +316,61 @@ func (c *Context) SendStatus(code int) {
 	c.Send(http.StatusText(code))
 }
 
+// JSONError sets the response HTTP status code to `code` and sends a JSON
+// response in the form of `{"error": message, "status": code}`. If `message`
+// is empty, the status text of `code` is used, like the default error handler.
+func (c *Context) JSONError(code int, message string) {
+	if message == "" {
+		message = http.StatusText(code)
+	}
+	c.AbortWithStatusJSON(code, jsonError{Error: message, Status: code})
+}
+
+// AbortWithStatusJSON sets the response HTTP status code to `code`, sends
+// `obj` as a JSON response and ends the response, so that the subsequent
+// sends are ignored.
+func (c *Context) AbortWithStatusJSON(code int, obj interface{}) {
+	c.Status(code).Json(obj)
+	c.finished = true
+}
+
+// jsonError is the response body of c.JSONError.
+type jsonError struct {
+	Error  string `json:"error"`
+	Status int    `json:"status"`
+}
+
 // Type sets the Content-Type HTTP header to the MIME type as determined
 // by LookupMimeType() for the specified type. If type contains the
 // “/” character, then it sets the Content-Type to type.
-func (c *Context) Type(s string) {
+func (c *Context) Type(s string) *Context {
+	if c.ignoredAfterFinished("Type") {
+		return c
+	}
 	util.SetContentType(c.Writer, s)
+	return c
+}
+
+// Types sets the Content-Type HTTP header to the MIME type of the best one of
+// `exts` based on the request’s Accept HTTP header field, and returns it. It
+// returns empty string without setting the header if none is acceptable.
+func (c *Context) Types(exts ...string) string {
+	if len(exts) == 0 {
+		return ""
+	}
+
+	c.Vary("Accept")
+	accepts := c.Request.Accepts(exts...)
+	if len(accepts) == 0 {
+		return ""
+	}
+
+	c.Type(accepts[0])
+	return accepts[0]
 }
 
 // Links sets Link header field with the given `links`.
-func (c *Context) Links(links map[string]string) {
+func (c *Context) Links(links map[string]string) *Context {
 	link := strings.TrimSpace(c.Get("Link"))
 	if link != "" {
 		link += ", "
@@ -188,12 +386,40 @@ func (c *Context) Links(links map[string]string) {
 	}
 
 	c.Set("Link", link)
+	return c
+}
+
+// Deprecate marks the response as deprecated by setting the Deprecation
+// header. If `date` is given, it is used as the Sunset header (RFC 8594),
+// which should be a HTTP-date. If `link` is given, it is appended to the
+// Link header with the "deprecation" relation.
+func (c *Context) Deprecate(date string, link string) *Context {
+	c.Set("Deprecation", "true")
+	if date = strings.TrimSpace(date); date != "" {
+		c.Set("Sunset", date)
+	}
+	if link = strings.TrimSpace(link); link != "" {
+		c.Links(map[string]string{"deprecation": link})
+	}
+	return c
+}
+
+// Warning appends a Warning header with the given `code`, `agent` and `text`,
+// in the form of `<code> <agent> "<text>"` (RFC 7234, section 5.5).
+// If `agent` is empty, "-" is used.
+func (c *Context) Warning(code int, agent, text string) *Context {
+	if agent = strings.TrimSpace(agent); agent == "" {
+		agent = "-"
+	}
+	text = strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(text)
+	c.Append("Warning", fmt.Sprintf("%03d %s \"%s\"", code, agent, text))
+	return c
 }
 
 // Location sets the location header to `url`.
 // The given `url` can also be "back", which redirects
 // to the _Referrer_ or _Referer_ headers or "/".
-func (c *Context) Location(url string) {
+func (c *Context) Location(url string) *Context {
 	url = strings.TrimSpace(url)
 	if url == "back" {
 		url = c.Get("Referrer")
@@ -202,42 +428,165 @@ func (c *Context) Location(url string) {
 		}
 	}
 	c.Set("Location", util.EncodeURI(url))
+	return c
 }
 
 // Attachment sets the HTTP response Content-Disposition header field to
 // “attachment”. If a filename is given, then it sets the Content-Type based
 // on the extension name via c.Type(), and sets the Content-Disposition
 // “filename=” parameter.
-func (c *Context) Attachment(filename ...string) {
+func (c *Context) Attachment(filename ...string) *Context {
 	contentDisposition := "attachment"
 	if len(filename) >= 1 {
 		name := filename[0]
-		contentDisposition = fmt.Sprintf("attachment; filename=\"%s\"", name)
+		contentDisposition = util.ContentDisposition(name)
 		c.Type(filepath.Ext(name))
 	}
 	c.Set("Content-Disposition", contentDisposition)
+	return c
+}
+
+// CookieDefaults contains the default attributes applied by c.Cookie to the
+// cookies which leave them zero-valued, see SetCookieDefaults and the
+// "cookie defaults" setting of App.
+type CookieDefaults struct {
+	// Default Path attribute.
+	Path string
+
+	// Default SameSite attribute.
+	SameSite http.SameSite
+
+	// Sets Secure attribute if true.
+	Secure bool
+
+	// Sets HttpOnly attribute if true.
+	HttpOnly bool
 }
 
-// Cookie sets cookie.
-func (c *Context) Cookie(cookie *http.Cookie) {
-	http.SetCookie(c.Writer, cookie)
+var cookieDefaults CookieDefaults
+
+// Cookie sets cookie, the zero-valued attributes of cookie are filled with
+// CookieDefaults. It returns an error without setting the cookie if the value
+// contains invalid characters, which should be encoded, e.g. with
+// url.QueryEscape.
+func (c *Context) Cookie(cookie *http.Cookie) error {
+	if c.ignoredAfterFinished("Cookie") {
+		return nil
+	}
+	if !isCookieValueValid(cookie.Value) {
+		return fmt.Errorf("invalid value for cookie %q", cookie.Name)
+	}
+
+	defaults := c.cookieDefaults()
+	ck := *cookie
+	if ck.Path == "" {
+		ck.Path = defaults.Path
+	}
+	if ck.SameSite == 0 {
+		ck.SameSite = defaults.SameSite
+	}
+	ck.Secure = ck.Secure || defaults.Secure
+	ck.HttpOnly = ck.HttpOnly || defaults.HttpOnly
+	http.SetCookie(c.Writer, &ck)
+	return nil
 }
 
-// ClearCookie clears the specified cookie.
+// SetCookie is a shortcut of Cookie with the common attributes, the Expires
+// attribute is computed from maxAge as well for the old clients, e.g.
+//
+//	c.SetCookie("name", "soon", 3600, "/", "localhost", false, true)
+//
+// maxAge=0 means no Max-Age attribute specified, and maxAge<0 means delete
+// cookie now. The SameSite attribute is filled with CookieDefaults.
+func (c *Context) SetCookie(name, value string, maxAge int, path, domain string, secure, httpOnly bool) error {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		MaxAge:   maxAge,
+		Path:     path,
+		Domain:   domain,
+		Secure:   secure,
+		HttpOnly: httpOnly,
+	}
+	if maxAge > 0 {
+		cookie.Expires = time.Now().Add(time.Duration(maxAge) * time.Second)
+	} else if maxAge < 0 {
+		cookie.Expires = time.Unix(0, 0)
+	}
+	return c.Cookie(cookie)
+}
+
+// SignedCookie is similar with Cookie, but the value is signed with secret,
+// so that it can't be tampered by the client, see util.SignCookie. It's read
+// by c.Request.SignedCookie or c.Request.SignedCookies with the same secret.
+func (c *Context) SignedCookie(cookie *http.Cookie, secret []byte) error {
+	ck := *cookie
+	ck.Value = util.SignCookie(ck.Value, secret)
+	return c.Cookie(&ck)
+}
+
+// isCookieValueValid reports whether the cookie value consists of the valid
+// characters only, as per RFC 6265. The value may be double quoted.
+func isCookieValueValid(v string) bool {
+	if len(v) > 1 && v[0] == '"' && v[len(v)-1] == '"' {
+		v = v[1 : len(v)-1]
+	}
+	for i := 0; i < len(v); i++ {
+		b := v[i]
+		if b < 0x20 || b >= 0x7f || b == '"' || b == ';' || b == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// ClearCookie clears the specified cookie. The Domain, Path, Secure and
+// SameSite attributes are kept, since browsers only clear the cookie if
+// they match the original one.
 func (c *Context) ClearCookie(cookie *http.Cookie) {
 	p := cookie.Path
+	if p == "" {
+		p = c.cookieDefaults().Path
+	}
 	if p == "" {
 		p = "/"
 	}
 
-	http.SetCookie(c.Writer, &http.Cookie{
-		Name:    cookie.Name,
-		Value:   "",
-		Path:    p,
-		Expires: time.Unix(0, 0),
+	c.Cookie(&http.Cookie{
+		Name:     cookie.Name,
+		Value:    "",
+		Path:     p,
+		Domain:   cookie.Domain,
+		Expires:  time.Unix(0, 0),
+		Secure:   cookie.Secure,
+		SameSite: cookie.SameSite,
 	})
 }
 
+// Push initiates an HTTP/2 server push of target, such as the assets used by
+// the HTML or file to be sent, see http.Pusher. It returns ErrNotSupported if
+// neither c.Writer nor the underlying http.ResponseWriter is an http.Pusher.
+func (c *Context) Push(target string, opts *http.PushOptions) error {
+	if pusher, ok := c.Writer.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	if pusher, ok := c.response.ResponseWriter.(http.Pusher); ok {
+		return pusher.Push(target, opts)
+	}
+	return ErrNotSupported
+}
+
+// Write writes the data into the response body, so that the context is an
+// io.Writer, which can be passed to fmt.Fprintf, io.Copy or template.Execute.
+// It doesn't finish the response, but returns ErrResponseFinished if the
+// response is already finished, such as after c.End() or c.Send().
+func (c *Context) Write(data []byte) (int, error) {
+	if c.finished {
+		return 0, ErrResponseFinished
+	}
+	return c.Writer.Write(data)
+}
+
 // End signals to the server that all of the response headers and body have been
 // sent; other operations after it will be ignored.
 //
@@ -259,8 +608,9 @@ func (c *Context) End() {
 // Content-Type is set for you, however you may alter this within the callback
 // using `c.Type()` or `c.Set("Content-Type", ...)`.
 //
-// By default Soon passes an `error` with a `.status` of 406 to `Next(err)`
-// if a match is not made. If you provide a `.default` callback it will be
+// By default Soon passes a *NotAcceptableError with a `.status` of 406 to
+// `Next(err)` if a match is not made, or calls the handler set by
+// SetNotAcceptableHandler. If you provide a `.default` callback it will be
 // invoked instead.
 func (c *Context) Format(m map[string]Handle) {
 	defaultHandler := m["default"]
@@ -296,10 +646,114 @@ func (c *Context) Format(m map[string]Handle) {
 	} else if defaultHandler != nil {
 		defaultHandler(c)
 	} else {
-		c.Next(internal.NewStatusCodeError(http.StatusNotAcceptable))
+		notAcceptable(c, keys)
 	}
 }
 
+// NegotiateConfig contains the offered content types and the data of each
+// type for c.Negotiate.
+type NegotiateConfig struct {
+	// The offered content types in order of preference, such as
+	// "application/json", "application/xml", "text/html" or "json", "xml", "html".
+	Offered []string
+
+	// The data for "text/html", it's rendered by HTMLTemplate if given,
+	// otherwise it's sent as html string.
+	HTMLData interface{}
+
+	// The template to render HTMLData.
+	HTMLTemplate *template.Template
+
+	// The name of template in HTMLTemplate to execute, HTMLTemplate itself is
+	// executed if it's empty.
+	HTMLName string
+
+	// The data for "application/json".
+	JSONData interface{}
+
+	// The data for "application/xml" and "text/xml".
+	XMLData interface{}
+
+	// The data for the types which have no specific data.
+	Data interface{}
+}
+
+// Negotiate picks the best content type of config.Offered based on the
+// request’s Accept HTTP header field, and renders the data of that type.
+// It responds 406 “Not Acceptable” if none of the offered types is acceptable,
+// the same as c.Format.
+func (c *Context) Negotiate(config NegotiateConfig) {
+	var accepts []string
+	if len(config.Offered) > 0 {
+		accepts = c.Request.Accepts(config.Offered...)
+	}
+
+	c.Vary("Accept")
+
+	if len(accepts) == 0 {
+		notAcceptable(c, config.Offered)
+		return
+	}
+
+	pick := func(data interface{}) interface{} {
+		if data == nil {
+			return config.Data
+		}
+		return data
+	}
+
+	switch t := util.NormalizeType(accepts[0]).Value; t {
+	case "application/json":
+		c.Json(pick(config.JSONData))
+	case "application/xml", "text/xml":
+		c.Set("Content-Type", t+"; charset=utf-8")
+		c.Render(&renderer.XML{Data: pick(config.XMLData)})
+	case "text/html":
+		data := pick(config.HTMLData)
+		if config.HTMLTemplate != nil {
+			c.Render(&renderer.HTML{Template: config.HTMLTemplate, Name: config.HTMLName, Data: data})
+		} else {
+			c.Html(fmt.Sprint(data))
+		}
+	default:
+		c.Next(fmt.Errorf("unsupported negotiate format: %s", t))
+	}
+}
+
+// Bind checks the Method and Content-Type to select a binding engine
+// automatically, see binding.Default. It returns
+// binding.ErrUnsupportedMediaType if no engine can handle the Content-Type.
+// The content type of body is sniffed for the requests without Content-Type
+// if binding.EnableContentTypeSniffing is true.
+func (c *Context) Bind(obj interface{}) error {
+	contentType := c.Request.ContentType()
+	if contentType == "" && binding.EnableContentTypeSniffing {
+		contentType = c.sniffContentType()
+	}
+	b, err := binding.Default(c.Request.Method, contentType)
+	if err != nil {
+		return err
+	}
+	return c.BindWith(obj, b)
+}
+
+// sniffContentType sniffs the content type of body by
+// binding.SniffContentType, the body is buffered so that it's still read from
+// the beginning by the binding.
+func (c *Context) sniffContentType() string {
+	req := c.Request
+	if req.Method == http.MethodGet || req.Body == nil || req.Body == http.NoBody {
+		return ""
+	}
+	c.rewindBody()
+	br := bufio.NewReader(req.Body)
+	req.Body = struct {
+		io.Reader
+		io.Closer
+	}{br, req.Body}
+	return binding.SniffContentType(br)
+}
+
 // BindJSON is a shortcut for c.BindWith(obj, binding.JSON).
 func (c *Context) BindJSON(obj interface{}) error {
 	return c.BindWith(obj, binding.JSON)
@@ -316,22 +770,60 @@ func (c *Context) BindHeader(obj interface{}) error {
 }
 
 // BindUri binds the passed struct pointer using binding.Uri.
+//
+// The unnamed params, such as the captures of wildcard `(.*)`, can be bound
+// by their index with tags like `uri:"0"`, or all together in order with the
+// catch-all tag `uri:"*"`.
 func (c *Context) BindUri(obj interface{}) error {
 	m := make(map[string][]string)
+	var indexes []int
 	for k, v := range c.Request.Params {
 		if s, ok := k.(string); ok {
 			m[s] = []string{v}
 		} else if i, ok := k.(int); ok {
 			m[strconv.Itoa(i)] = []string{v}
+			indexes = append(indexes, i)
+		}
+	}
+	if len(indexes) > 0 {
+		sort.Ints(indexes)
+		all := make([]string, len(indexes))
+		for i, index := range indexes {
+			all[i] = c.Request.Params[index]
 		}
+		m["*"] = all
 	}
 	return binding.Uri.BindUri(m, obj)
 }
 
 // BindWith binds the passed struct pointer using the specified binding engine.
-// See the binding package.
+// See the binding package. The body can be bound more than once if it's cached
+// by the CacheBody middleware.
 func (c *Context) BindWith(obj interface{}, b binding.Binding) error {
-	return b.Bind(c.Request.Request, obj)
+	c.rewindBody()
+	return c.binding(b).Bind(c.Request.Request, obj)
+}
+
+// BindOptions are the options of c.BindWithOptions, see binding.BindOptions.
+type BindOptions = binding.BindOptions
+
+// BindWithOptions binds the passed struct pointer using the specified binding
+// engine with the given options, such as skipping the validation for this
+// call only, without changing binding.Validator.
+func (c *Context) BindWithOptions(obj interface{}, b binding.Binding, options BindOptions) error {
+	c.rewindBody()
+	return binding.BindWithOptions(c.Request.Request, obj, c.binding(b), options)
+}
+
+// MustBind is similar with Bind, but it will panic with HTTP 400 if any error
+// occurs, or HTTP 415 if no engine can handle the Content-Type.
+func (c *Context) MustBind(obj interface{}) {
+	if err := c.Bind(obj); err != nil {
+		if err == binding.ErrUnsupportedMediaType {
+			panic(newBindingError(http.StatusUnsupportedMediaType, err))
+		}
+		panic(newBindingError(http.StatusBadRequest, err))
+	}
 }
 
 // MustBindJSON is a shortcut for c.MustBindWith(obj, binding.JSON).
@@ -354,7 +846,7 @@ func (c *Context) MustBindHeader(obj interface{}) {
 // See the binding package.
 func (c *Context) MustBindUri(obj interface{}) {
 	if err := c.BindUri(obj); err != nil {
-		panic(internal.NewStatusError(http.StatusBadRequest, err))
+		panic(newBindingError(http.StatusBadRequest, err))
 	}
 }
 
@@ -363,7 +855,15 @@ func (c *Context) MustBindUri(obj interface{}) {
 // See the binding package.
 func (c *Context) MustBindWith(obj interface{}, b binding.Binding) {
 	if err := c.BindWith(obj, b); err != nil {
-		panic(internal.NewStatusError(http.StatusBadRequest, err))
+		panic(newBindingError(http.StatusBadRequest, err))
+	}
+}
+
+// MustBindWithOptions is similar with BindWithOptions, but it will panic with
+// HTTP 400 if any error occurs.
+func (c *Context) MustBindWithOptions(obj interface{}, b binding.Binding, options BindOptions) {
+	if err := c.BindWithOptions(obj, b, options); err != nil {
+		panic(newBindingError(http.StatusBadRequest, err))
 	}
 }
 
@@ -372,6 +872,9 @@ func (c *Context) MustBindWith(obj interface{}, b binding.Binding) {
 //
 // NOTE: This method reads the body before binding. So you should use
 // BindWith for better performance if you need to call only once.
+//
+// The reading is aborted with the error of request context if it's done
+// before the body is read, such as the deadline set by a timeout middleware.
 func (c *Context) BindBodyWith(obj interface{}, bb binding.BindingBody) (err error) {
 	var body []byte
 	if cb, ok := c.GetLocal(BodyBytesKey); ok {
@@ -380,13 +883,81 @@ func (c *Context) BindBodyWith(obj interface{}, bb binding.BindingBody) (err err
 		}
 	}
 	if body == nil {
-		body, err = ioutil.ReadAll(c.Request.Body)
+		body, err = util.ReadAllContext(c.Request.Context(), c.Request.Body)
 		if err != nil {
 			return err
 		}
 		c.SetLocal(BodyBytesKey, body)
 	}
-	return bb.BindBody(body, obj)
+	return c.binding(bb).(binding.BindingBody).BindBody(body, obj)
+}
+
+// cacheBody reads the request body and stores it into the context with
+// BodyBytesKey, which is shared with BindBodyWith.
+func (c *Context) cacheBody() error {
+	body, err := util.ReadAllContext(c.Request.Context(), c.Request.Body)
+	if err != nil {
+		return err
+	}
+	c.SetLocal(BodyBytesKey, body)
+	c.bodyCached = true
+	c.rewindBody()
+	return nil
+}
+
+// rewindBody replaces the request body with a new reader of the cached body,
+// so that it can be read from the beginning again.
+func (c *Context) rewindBody() {
+	if !c.bodyCached {
+		return
+	}
+	if cb, ok := c.GetLocal(BodyBytesKey); ok {
+		if body, ok := cb.([]byte); ok {
+			c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+	}
+}
+
+// MultipartForm returns the parsed multipart form, including file uploads.
+// At most binding.MaxMultipartMemory bytes of file parts, or the
+// "max multipart memory" setting of app, are stored in memory, the remainder
+// is stored on disk in temporary files.
+func (c *Context) MultipartForm() (*multipart.Form, error) {
+	err := c.Request.ParseMultipartForm(c.maxMultipartMemory())
+	return c.Request.MultipartForm, err
+}
+
+// FormFile returns the first file header for the provided form key.
+func (c *Context) FormFile(name string) (*multipart.FileHeader, error) {
+	if c.Request.MultipartForm == nil {
+		if err := c.Request.ParseMultipartForm(c.maxMultipartMemory()); err != nil {
+			return nil, err
+		}
+	}
+	f, fh, err := c.Request.FormFile(name)
+	if err != nil {
+		return nil, err
+	}
+	f.Close()
+	return fh, nil
+}
+
+// SaveUploadedFile uploads the form file to specific dst.
+func (c *Context) SaveUploadedFile(file *multipart.FileHeader, dst string) error {
+	src, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, src)
+	return err
 }
 
 // Send is alias for String method
@@ -394,6 +965,48 @@ func (c *Context) Send(s string) {
 	c.String(s)
 }
 
+// LastModified sets the Last-Modified header to `t` in http.TimeFormat, which
+// is then used by c.Fresh and the senders to respond 304 for the request with
+// a matching If-Modified-Since header.
+func (c *Context) LastModified(t time.Time) *Context {
+	return c.Set("Last-Modified", t.UTC().Format(http.TimeFormat))
+}
+
+// Fresh checks if the request is fresh, aka Last-Modified and/or the ETag
+// of response still match, which should be set before calling it. It's
+// useful to skip building an expensive body, since c.Send, c.Json and other
+// senders respond 304 with no body for the fresh request anyway.
+func (c *Context) Fresh() bool {
+	return c.Request.Fresh()
+}
+
+// Stale is the opposite of Fresh.
+func (c *Context) Stale() bool {
+	return !c.Fresh()
+}
+
+// IsXHR reports whether the request is issued by a client library such as
+// jQuery, aka the X-Requested-With header is "XMLHttpRequest".
+func (c *Context) IsXHR() bool {
+	return c.Request.Xhr
+}
+
+// IsWebsocket reports whether the request is a websocket handshake, aka the
+// Connection header contains "Upgrade" and the Upgrade header is "websocket".
+func (c *Context) IsWebsocket() bool {
+	if !strings.EqualFold(strings.TrimSpace(c.Request.Get("Upgrade")), "websocket") {
+		return false
+	}
+	for _, v := range util.GetHeaderValues(c.Request.Header, "Connection") {
+		for _, token := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(token), "upgrade") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // String sends a plain text response.
 func (c *Context) String(s string) {
 	c.Render(&renderer.String{Data: s})
@@ -409,27 +1022,144 @@ func (c *Context) Html(s string) {
 // This method sends a response (with the correct content-type) that is
 // the parameter converted to a JSON string.
 func (c *Context) Json(v interface{}) {
-	c.Render(&renderer.JSON{Data: v})
+	c.Render(&renderer.JSON{Data: v, Indent: c.jsonIndent(), Codec: c.jsonCodec()})
+}
+
+// JSONStream sends a JSON array response, which consists of the items received
+// from channel until it's closed. The items are encoded and flushed one by one
+// instead of marshaling the whole slice into memory, it's useful for large
+// result sets.
+func (c *Context) JSONStream(items <-chan interface{}) {
+	c.Render(&renderer.JSONStream{Items: items, Codec: c.jsonCodec()})
+}
+
+// AsciiJSON sends a JSON response with all non-ASCII characters escaped as
+// `\uXXXX`, such as "中文" becomes "\u4e2d\u6587".
+func (c *Context) AsciiJSON(v interface{}) {
+	c.Render(&renderer.AsciiJSON{Data: v, Codec: c.jsonCodec()})
+}
+
+// PureJSON sends a JSON response without escaping the HTML characters, such
+// as "<b>" is kept literally rather than "\u003cb\u003e".
+func (c *Context) PureJSON(v interface{}) {
+	c.Render(&renderer.PureJSON{Data: v, Codec: c.jsonCodec()})
+}
+
+// HTMLTemplate renders the template with the given name by the "templates"
+// setting of app, and sends it as an HTML response, like res.render of
+// Express. If the "layout" setting is set, the page is rendered into the
+// layout template, see renderer.LayoutData.
+//
+//	app.Set("templates", template.Must(template.ParseGlob("views/*.html")))
+//	app.Set("layout", "layout.html")
+//	c.HTMLTemplate("index.html", data)
+func (c *Context) HTMLTemplate(name string, data interface{}) {
+	tmpl, _ := c.setting("templates")
+	layout, _ := c.setting("layout")
+	t, _ := tmpl.(*template.Template)
+	l, _ := layout.(string)
+	c.Render(&renderer.HTML{Template: t, Name: name, Data: data, Layout: l})
 }
 
 // Jsonp sends a JSON response with JSONP support. This method is identical
 // to c.Json(), except that it opts-in to JSONP callback support.
 func (c *Context) Jsonp(v interface{}) {
-	c.Render(&renderer.JSONP{Data: v})
+	c.Render(&renderer.JSONP{Data: v, Indent: c.jsonIndent(), Codec: c.jsonCodec()})
+}
+
+// Xml sends a XML response.
+func (c *Context) Xml(v interface{}) {
+	c.Render(&renderer.XML{Data: v})
+}
+
+// Gob encodes the given value by encoding/gob, and sends it with the
+// content type "application/x-gob".
+func (c *Context) Gob(v interface{}) {
+	c.Render(&renderer.GOB{Data: v})
+}
+
+// Data sends the bytes with the given content type, which defaults to
+// "application/octet-stream" if it's empty.
+func (c *Context) Data(contentType string, data []byte) {
+	c.Render(&renderer.Data{ContentType: contentType, Data: data})
+}
+
+// JSONBlob sends the pre-encoded JSON bytes, such as a cached payload, with
+// the content type "application/json; charset=utf-8". In debug mode, a
+// warning is printed if the bytes aren't well-formed JSON.
+func (c *Context) JSONBlob(b []byte) {
+	if IsDebugging() && !json.Valid(b) {
+		debugPrintWARNING("c.JSONBlob is called with malformed JSON")
+	}
+	c.Data("application/json; charset=utf-8", b)
+}
+
+// XMLBlob sends the pre-encoded XML bytes, such as a cached payload, with
+// the content type "application/xml; charset=utf-8". In debug mode, a
+// warning is printed if the bytes aren't well-formed XML.
+func (c *Context) XMLBlob(b []byte) {
+	if IsDebugging() && !isValidXML(b) {
+		debugPrintWARNING("c.XMLBlob is called with malformed XML")
+	}
+	c.Data("application/xml; charset=utf-8", b)
+}
+
+// isValidXML reports whether b is well-formed XML.
+func isValidXML(b []byte) bool {
+	d := xml.NewDecoder(bytes.NewReader(b))
+	hasElement := false
+	for {
+		t, err := d.Token()
+		if err == io.EOF {
+			return hasElement
+		}
+		if err != nil {
+			return false
+		}
+		if _, ok := t.(xml.StartElement); ok {
+			hasElement = true
+		}
+	}
 }
 
 // SendFile transfers the file at the given path. Sets the Content-Type
 // response HTTP header field based on the filename’s extension.
 // Unless the root option is set in the options object, path must be an
-// absolute path to the file.
+// absolute path to the file. The ETag is also disabled by the "etag" setting
+// of app.
 func (c *Context) SendFile(filePath string, options ...renderer.FileOptions) {
 	var opts renderer.FileOptions
 	if len(options) > 0 {
 		opts = options[0]
 	}
+	opts.ETagDisabled = opts.ETagDisabled || c.etagDisabled()
 	c.Render(&renderer.File{FilePath: filePath, Options: opts})
 }
 
+// SendFileInline transfers the file at path with the Content-Disposition
+// “inline”, so that browsers display the file, such as PDF or image, instead
+// of prompting for download, while the “filename=” parameter is still used as
+// the name to save. The name defaults to the base of path if it's empty.
+//
+// The optional options argument passes through to the underlying c.SendFile()
+// call, and takes the exact same parameters.
+func (c *Context) SendFileInline(filePath, name string, options ...renderer.FileOptions) {
+	if name == "" {
+		name = filepath.Base(filePath)
+	}
+	var opts renderer.FileOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	header := make(map[string]string, len(opts.Header)+1)
+	for k, v := range opts.Header {
+		header[k] = v
+	}
+	header["Content-Disposition"] = util.ContentDispositionType("inline", name)
+	opts.Header = header
+	c.SendFile(filePath, opts)
+}
+
 // Download transfers the file at path as an “attachment”. Typically, browsers will
 // prompt the user for download. By default, the Content-Disposition header
 // “filename=” parameter is path (this typically appears in the browser dialog).
@@ -450,51 +1180,209 @@ func (c *Context) Download(filePath string, options ...renderer.FileOptions) {
 	if opts.Header == nil {
 		opts.Header = make(map[string]string, 1)
 	}
-	opts.Header["Content-Disposition"] = fmt.Sprintf("attachment; filename=\"%s\"", name)
+	opts.Header["Content-Disposition"] = util.ContentDisposition(name)
 	c.SendFile(filePath, opts)
 }
 
+// DownloadReader transfers the content of reader `r` as an “attachment”
+// named `name`, which is useful to stream the content generated in memory.
+// If `contentType` is empty, the Content-Type is determined by the
+// extension of `name`.
+func (c *Context) DownloadReader(name, contentType string, r io.Reader) {
+	if contentType == "" {
+		contentType = filepath.Ext(name)
+	}
+	c.Type(contentType)
+	c.Set("Content-Disposition", util.ContentDisposition(name))
+	c.Render(&renderer.Reader{Reader: r})
+}
+
 // Redirect to the given `location` with `status`.
 func (c *Context) Redirect(status int, location string) {
 	c.Render(&renderer.Redirect{Code: status, Location: location})
 }
 
-// sets the common http header.
+// sets the common http header. Connection header is managed by net/http,
+// since it's illegal in HTTP/2.
 func (c *Context) renderHeader() {
-	c.Writer.Header().Set("Connection", "keep-alive")
-	c.Writer.Header().Set("X-Powered-By", "Soon")
+	if v, ok := c.setting("x-powered-by"); !ok || truthy(v) {
+		c.Writer.Header().Set("X-Powered-By", "Soon")
+	}
 }
 
-// Render uses the specified renderer to deal with http response body.
-func (c *Context) Render(r renderer.Renderer) {
-	if !c.finished {
-		c.renderHeader()
-		r.RenderHeader(c.Writer, c.Request.Request)
+// etagDisabled reports whether the ETag of files is disabled by the "etag"
+// setting.
+func (c *Context) etagDisabled() bool {
+	v, ok := c.setting("etag")
+	return ok && !truthy(v)
+}
 
-		if c.Request.Fresh() {
-			c.Status(304)
+// jsonIndent returns the indentation by the "json spaces" setting, which is the
+// number of spaces, or the indentation string itself.
+func (c *Context) jsonIndent() string {
+	v, _ := c.setting("json spaces")
+	switch x := v.(type) {
+	case int:
+		if x > 0 {
+			return strings.Repeat(" ", x)
 		}
+	case string:
+		return x
+	}
+	return ""
+}
 
-		status := c.Writer.Status()
+// jsonCodec returns the JSON implementation by the "json codec" setting, or
+// nil if it's not set, so that the one set by SetJSONCodec is used.
+func (c *Context) jsonCodec() JSONCodec {
+	v, _ := c.setting("json codec")
+	codec, _ := v.(JSONCodec)
+	return codec
+}
 
-		// strip irrelevant headers
-		if status == 204 || status == 304 {
-			c.del("Content-Type")
-			c.del("Content-Length")
-			c.del("Transfer-Encoding")
+// binding returns b which decodes JSON with the "json codec" setting, and
+// parses multipart forms with the "max multipart memory" setting if they're
+// set, see binding.WithJSONCodec and binding.WithMaxMultipartMemory.
+func (c *Context) binding(b binding.Binding) binding.Binding {
+	if codec := c.jsonCodec(); codec != nil {
+		b = binding.WithJSONCodec(b, codec)
+	}
+	if _, ok := c.setting("max multipart memory"); ok {
+		b = binding.WithMaxMultipartMemory(b, c.maxMultipartMemory())
+	}
+	return b
+}
+
+// maxMultipartMemory returns the "max multipart memory" setting, or
+// binding.MaxMultipartMemory if it's not set.
+func (c *Context) maxMultipartMemory() int64 {
+	v, _ := c.setting("max multipart memory")
+	switch size := v.(type) {
+	case int64:
+		return size
+	case int:
+		return int64(size)
+	}
+	return binding.MaxMultipartMemory
+}
+
+// cookieDefaults returns the "cookie defaults" setting, or the one set by
+// SetCookieDefaults if it's not set.
+func (c *Context) cookieDefaults() CookieDefaults {
+	if v, ok := c.setting("cookie defaults"); ok {
+		if defaults, ok := v.(CookieDefaults); ok {
+			return defaults
 		}
+	}
+	return cookieDefaults
+}
+
+// setting returns the value of the setting name of the app serving the
+// request, and whether it's set. It's always unset if there is no app.
+func (c *Context) setting(name string) (interface{}, bool) {
+	if c.Request.app == nil {
+		return nil, false
+	}
+	return c.Request.app.setting(name)
+}
+
+// Render uses the specified renderer to deal with http response body.
+func (c *Context) Render(r renderer.Renderer) {
+	// the second send is probably a bug of handler
+	if c.ignoredAfterFinished("Render") {
+		discard(r)
+		return
+	}
+
+	c.renderHeader()
+	r.RenderHeader(c.Writer, c.Request.Request)
+
+	if c.Request.Fresh() {
+		c.Status(304)
+	}
 
-		if c.Request.Method == http.MethodHead || !bodyAllowedForStatus(status) {
-			c.Writer.WriteHeaderNow()
-			return
+	status := c.Writer.Status()
+
+	// strip irrelevant headers
+	if status == 204 || status == 304 {
+		c.del("Content-Type")
+		c.del("Content-Length")
+		c.del("Transfer-Encoding")
+	}
+
+	if !bodyAllowedForStatus(status) {
+		discard(r)
+		c.Writer.WriteHeaderNow()
+		c.finished = true
+		return
+	}
+
+	if m, ok := r.(renderer.MeasurableRenderer); ok && c.Get("Content-Length") == "" {
+		if n := m.ContentLength(); n >= 0 {
+			c.Set("Content-Length", strconv.Itoa(n))
 		}
+	}
 
+	if b, ok := r.(renderer.BufferedRenderer); ok && b.Buffered() {
+		c.renderBuffered(r)
+	} else if c.Request.Method != http.MethodHead || handlesHead(r) {
 		if err := r.Render(c.Writer, c.Request.Request); err != nil {
-			panic(err)
+			c.renderError(err)
 		}
+	} else {
+		discard(r)
+	}
 
-		c.finished = true
+	// the renderer may write no body, e.g. redirect of POST request
+	c.Writer.WriteHeaderNow()
+	c.finished = true
+}
+
+// renderBuffered renders the body into memory first, and then sends it with
+// the Content-Length header, the body isn't sent for HEAD request.
+func (c *Context) renderBuffered(r renderer.Renderer) {
+	w := &bufferedResponseWriter{ResponseWriter: c.Writer}
+	if err := r.Render(w, c.Request.Request); err != nil {
+		c.renderError(err)
+	}
+	if c.Get("Content-Length") == "" {
+		c.Set("Content-Length", strconv.Itoa(w.buf.Len()))
+	}
+	if c.Request.Method != http.MethodHead {
+		c.Writer.Write(w.buf.Bytes())
+	}
+}
+
+// handlesHead reports whether the renderer handles the HEAD request by itself,
+// the other renderers write the headers only for the HEAD request.
+func handlesHead(r renderer.Renderer) bool {
+	h, ok := r.(renderer.HeadRenderer)
+	return ok && h.HandlesHead()
+}
+
+// discard releases the resources held by the renderer which isn't rendered.
+func discard(r renderer.Renderer) {
+	if d, ok := r.(renderer.DiscardableRenderer); ok {
+		d.Discard()
+	}
+}
+
+// RenderStatus sets the response HTTP status code to `code`, and then uses
+// the specified renderer to deal with http response body.
+func (c *Context) RenderStatus(code int, r renderer.Renderer) {
+	c.Status(code)
+	c.Render(r)
+}
+
+// renderError maps the error returned by renderer to the response status,
+// such as 404 of missing file, or 500 of the error without status, and then
+// panics the error to the error handlers.
+func (c *Context) renderError(err error) {
+	if !c.Writer.Written() {
+		status, _ := resolveError(err)
+		c.Status(status)
 	}
+	panic(err)
 }
 
 // bodyAllowedForStatus reports whether a given response status code
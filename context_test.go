@@ -6,12 +6,21 @@ package soon
 
 import (
 	"bytes"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"encoding/xml"
 	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
 	"path"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -190,6 +199,39 @@ func TestContext_Locals(t *testing.T) {
 	})
 }
 
+type contextValueKey string
+
+func TestContext_Value(t *testing.T) {
+	key := contextValueKey("name")
+	c := NewContext(emptyRequest, httptest.NewRecorder())
+	assert.Nil(t, c.Value(key))
+
+	c.SetLocal("name", "local")
+	c.SetValue(key, "value")
+	assert.Equal(t, "value", c.Value(key))
+	assert.Nil(t, c.Value("name"))
+	assert.Equal(t, "local", c.MustGetLocal("name"))
+	assert.Equal(t, "value", c.Request.Context().Value(key))
+	assert.Nil(t, emptyRequest.Context().Value(key))
+
+	c.SetValue(key, "override")
+	assert.Equal(t, "override", c.Value(key))
+
+	router := NewRouter()
+	router.Use(func(c *Context) {
+		c.SetValue(key, "foo")
+		c.Next()
+	})
+	router.GET("/", func(c *Context) {
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, r.Context().Value(key))
+		}).ServeHTTP(c.Writer, c.Request.Request)
+	})
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, "foo", w.Body.String())
+}
+
 func TestContext_Params(t *testing.T) {
 	tests := []struct {
 		params Params
@@ -208,6 +250,56 @@ func TestContext_Params(t *testing.T) {
 	}
 }
 
+func TestContext_Param(t *testing.T) {
+	tests := []struct {
+		params   Params
+		name     string
+		expected string
+	}{
+		{Params{}, "id", ""},
+		{Params{"id": "12"}, "id", "12"},
+		{Params{"id": "12"}, "name", ""},
+		{Params{"name": "foo", 0: "12"}, "0", ""},
+	}
+
+	for _, tt := range tests {
+		c := NewContext(httptest.NewRequest("GET", "/", nil), nil)
+		c.Request.Params = tt.params
+		assert.Equal(t, tt.expected, c.Param(tt.name))
+	}
+}
+
+func TestContext_ParamAt(t *testing.T) {
+	tests := []struct {
+		params   Params
+		i        int
+		expected string
+	}{
+		{Params{}, 0, ""},
+		{Params{"name": "foo", 0: "12"}, 0, "12"},
+		{Params{"name": "foo", 0: "12"}, 1, ""},
+	}
+
+	for _, tt := range tests {
+		c := NewContext(httptest.NewRequest("GET", "/", nil), nil)
+		c.Request.Params = tt.params
+		assert.Equal(t, tt.expected, c.ParamAt(tt.i))
+	}
+
+	t.Run("router", func(t *testing.T) {
+		router := NewRouter()
+		router.GET("/users/:id/(.*)", func(c *Context) {
+			c.Send(c.Param("id") + "," + c.ParamAt(0) + "," + c.Param("name"))
+		})
+		server := httptest.NewServer(router)
+		defer server.Close()
+		code, _, body, err := request("GET", server.URL+"/users/12/foo/bar", nil)
+		require.NoError(t, err)
+		assert.Equal(t, 200, code)
+		assert.Equal(t, "12,foo/bar,", body)
+	})
+}
+
 func TestContext_Query(t *testing.T) {
 	tests := []struct {
 		q        string
@@ -227,6 +319,70 @@ func TestContext_Query(t *testing.T) {
 		req := httptest.NewRequest("GET", "/?"+tt.q, nil)
 		c := NewContext(req, nil)
 		assert.Equal(t, tt.expected, c.Request.Query)
+		assert.Equal(t, "foo", c.Query("name"))
+		assert.Equal(t, "18", c.Query("age"))
+		assert.Equal(t, "", c.Query("gender"))
+	}
+}
+
+func TestContext_DefaultQuery(t *testing.T) {
+	tests := []struct {
+		q        string
+		name     string
+		def      string
+		expected string
+	}{
+		{"name=foo", "name", "bar", "foo"},
+		{"name=foo&name=bar", "name", "baz", "foo"},
+		{"name=", "name", "bar", ""},
+		{"age=18", "name", "bar", "bar"},
+		{"", "name", "", ""},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/?"+tt.q, nil)
+		c := NewContext(req, nil)
+		assert.Equal(t, tt.expected, c.DefaultQuery(tt.name, tt.def))
+	}
+}
+
+func TestContext_QueryArray(t *testing.T) {
+	tests := []struct {
+		q        string
+		name     string
+		expected []string
+	}{
+		{"tag=a&tag=b", "tag", []string{"a", "b"}},
+		{"tag=a&name=foo", "tag", []string{"a"}},
+		{"tag=", "tag", []string{""}},
+		{"name=foo", "tag", []string{}},
+		{"", "tag", []string{}},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/?"+tt.q, nil)
+		c := NewContext(req, nil)
+		assert.Equal(t, tt.expected, c.QueryArray(tt.name))
+	}
+}
+
+func TestContext_QueryMap(t *testing.T) {
+	tests := []struct {
+		q        string
+		name     string
+		expected map[string]string
+	}{
+		{"ids[1]=x&ids[2]=y", "ids", map[string]string{"1": "x", "2": "y"}},
+		{"ids[a]=x&ids[a]=y&names[b]=z", "ids", map[string]string{"a": "x"}},
+		{"ids[a]=x&idsx[b]=y&ids=z&ids[]=w&ids[c=v", "ids", map[string]string{"a": "x"}},
+		{"ids=x", "ids", map[string]string{}},
+		{"", "ids", map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/?"+tt.q, nil)
+		c := NewContext(req, nil)
+		assert.Equal(t, tt.expected, c.QueryMap(tt.name))
 	}
 }
 
@@ -377,6 +533,35 @@ func TestContext_Status(t *testing.T) {
 	}
 }
 
+func TestContext_Chaining(t *testing.T) {
+	assert := assert.New(t)
+	w := httptest.NewRecorder()
+	c := NewContext(emptyRequest, w)
+	assert.Same(c, c.Status(201))
+	assert.False(c.Writer.HeaderWritten())
+	assert.Equal(200, w.Code)
+
+	c.Status(201).
+		Set("X-Foo", "foo").
+		Append("X-Bar", "bar").
+		Vary("Accept").
+		Links(map[string]string{"next": "/page/2"}).
+		Location("/foo").
+		Attachment().
+		Type("json").
+		Json(map[string]string{"foo": "bar"})
+
+	assert.Equal(201, w.Code)
+	assert.Equal("foo", w.Header().Get("X-Foo"))
+	assert.Equal("bar", w.Header().Get("X-Bar"))
+	assert.Equal("Accept", w.Header().Get("Vary"))
+	assert.Equal("</page/2>; rel=\"next\"", w.Header().Get("Link"))
+	assert.Equal("/foo", w.Header().Get("Location"))
+	assert.Equal("attachment", w.Header().Get("Content-Disposition"))
+	assert.Equal(jsonType, w.Header().Get("Content-Type"))
+	assert.Equal("{\"foo\":\"bar\"}\n", w.Body.String())
+}
+
 func TestContext_SendStatus(t *testing.T) {
 	tests := []struct {
 		code int
@@ -397,6 +582,51 @@ func TestContext_SendStatus(t *testing.T) {
 	}
 }
 
+func TestContext_JSONError(t *testing.T) {
+	tests := []struct {
+		code     int
+		message  string
+		expected string
+	}{
+		{404, "", `{"error":"Not Found","status":404}`},
+		{404, "user not found", `{"error":"user not found","status":404}`},
+		{422, "invalid email", `{"error":"invalid email","status":422}`},
+	}
+
+	assert := assert.New(t)
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c := NewContext(emptyRequest, w)
+		c.JSONError(tt.code, tt.message)
+		c.Send("ignored")
+		assert.Equal(tt.code, w.Code)
+		assert.Equal(jsonType, w.Header().Get("Content-Type"))
+		assert.Equal(tt.expected+"\n", w.Body.String())
+	}
+}
+
+func TestContext_AbortWithStatusJSON(t *testing.T) {
+	tests := []struct {
+		code     int
+		obj      interface{}
+		expected string
+	}{
+		{404, map[string]string{"message": "not found"}, `{"message":"not found"}`},
+		{422, []string{"name is required"}, `["name is required"]`},
+	}
+
+	assert := assert.New(t)
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c := NewContext(emptyRequest, w)
+		c.AbortWithStatusJSON(tt.code, tt.obj)
+		c.Json("ignored")
+		assert.Equal(tt.code, w.Code)
+		assert.Equal(jsonType, w.Header().Get("Content-Type"))
+		assert.Equal(tt.expected+"\n", w.Body.String())
+	}
+}
+
 func TestContext_Type(t *testing.T) {
 	tests := []struct {
 		t        string
@@ -416,6 +646,33 @@ func TestContext_Type(t *testing.T) {
 	}
 }
 
+func TestContext_Types(t *testing.T) {
+	tests := []struct {
+		exts                []string
+		accept              string
+		expected            string
+		expectedContentType string
+	}{
+		{[]string{"json", "html"}, "application/json", "json", jsonType},
+		{[]string{"json", "html"}, "text/html", "html", htmlType},
+		{[]string{"json", "html"}, "text/html;q=0.5, application/json", "json", jsonType},
+		{[]string{"json", "html"}, "", "json", jsonType},
+		{[]string{"html", "json"}, "*/*", "html", htmlType},
+		{[]string{"json", "html"}, "image/png", "", ""},
+		{nil, "application/json", "", ""},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		c := NewContext(req, httptest.NewRecorder())
+		assert.Equal(t, tt.expected, c.Types(tt.exts...))
+		assert.Equal(t, tt.expectedContentType, c.Get("Content-Type"))
+	}
+}
+
 func TestContext_Links(t *testing.T) {
 	tests := []struct {
 		origin   string
@@ -452,6 +709,60 @@ func TestContext_Links(t *testing.T) {
 	}
 }
 
+func TestContext_Deprecate(t *testing.T) {
+	sunset := "Sat, 31 Dec 2022 23:59:59 GMT"
+	tests := []struct {
+		date     string
+		link     string
+		expected http.Header
+	}{
+		{"", "", http.Header{"Deprecation": {"true"}}},
+		{sunset, "", http.Header{"Deprecation": {"true"}, "Sunset": {sunset}}},
+		{
+			sunset,
+			"https://api.example.com/deprecation",
+			http.Header{
+				"Deprecation": {"true"},
+				"Sunset":      {sunset},
+				"Link":        {"<https://api.example.com/deprecation>; rel=\"deprecation\""},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		c := NewContext(emptyRequest, httptest.NewRecorder())
+		assert.Equal(t, c, c.Deprecate(tt.date, tt.link))
+		assert.Equal(t, tt.expected, c.Writer.Header())
+	}
+}
+
+func TestContext_Warning(t *testing.T) {
+	tests := []struct {
+		code     int
+		agent    string
+		text     string
+		expected string
+	}{
+		{299, "soon", "Deprecated API", `299 soon "Deprecated API"`},
+		{199, "", "Miscellaneous warning", `199 - "Miscellaneous warning"`},
+		{299, "soon", `say "hi"`, `299 soon "say \"hi\""`},
+		{10, "soon", "", `010 soon ""`},
+	}
+
+	for _, tt := range tests {
+		c := NewContext(emptyRequest, httptest.NewRecorder())
+		c.Warning(tt.code, tt.agent, tt.text)
+		assert.Equal(t, tt.expected, c.Get("Warning"))
+	}
+
+	t.Run("multiple", func(t *testing.T) {
+		c := NewContext(emptyRequest, httptest.NewRecorder())
+		c.Warning(199, "soon", "foo").Warning(299, "soon", "bar")
+		expected := []string{`199 soon "foo"`, `299 soon "bar"`}
+		assert.Equal(t, expected, c.Writer.Header()["Warning"])
+	})
+}
+
 func TestContext_Location(t *testing.T) {
 	tests := []struct {
 		location string
@@ -500,6 +811,31 @@ func TestContext_Attachment(t *testing.T) {
 	}
 }
 
+func TestContext_SignedCookie(t *testing.T) {
+	secret := []byte("secret")
+	router := NewRouter()
+	router.GET("/set", func(c *Context) {
+		require.NoError(t, c.SignedCookie(&http.Cookie{Name: "user", Value: "foo", HttpOnly: true}, secret))
+		c.End()
+	})
+	router.GET("/get", func(c *Context) {
+		value, _ := c.Request.SignedCookie("user", secret)
+		c.Send(value)
+	})
+
+	w := Test(router).Get("/set").Do()
+	cookies := w.Result().Cookies()
+	require.Len(t, cookies, 1)
+	assert.Equal(t, util.SignCookie("foo", secret), cookies[0].Value)
+	assert.True(t, cookies[0].HttpOnly)
+
+	w = Test(router).Get("/get").Set("Cookie", "user="+cookies[0].Value).Do()
+	assert.Equal(t, "foo", w.Body.String())
+
+	w = Test(router).Get("/get").Set("Cookie", "user=bar"+cookies[0].Value[3:]).Do()
+	assert.Equal(t, "", w.Body.String())
+}
+
 func TestContext_Cookie(t *testing.T) {
 	now := time.Now()
 	nowStr := now.UTC().Format(timeFormat)
@@ -531,6 +867,98 @@ func TestContext_Cookie(t *testing.T) {
 	}
 }
 
+func TestContext_SetCookie(t *testing.T) {
+	c := NewContext(emptyRequest, httptest.NewRecorder())
+	assert.NoError(t, c.SetCookie("foo", "bar", 0, "/", "", false, true))
+	assert.Equal(t, "foo=bar; Path=/; HttpOnly", c.Get("Set-Cookie"))
+
+	c = NewContext(emptyRequest, httptest.NewRecorder())
+	assert.NoError(t, c.SetCookie("foo", "bar", 3600, "/users", "example.com", true, false))
+	cookie := c.Get("Set-Cookie")
+	assert.True(t, strings.HasPrefix(cookie, "foo=bar; Path=/users; Domain=example.com; Expires="), cookie)
+	assert.True(t, strings.HasSuffix(cookie, "; Max-Age=3600; Secure"), cookie)
+	expires, err := http.ParseTime(strings.TrimPrefix(strings.Split(cookie, "; ")[3], "Expires="))
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(time.Hour), expires, 2*time.Second)
+
+	c = NewContext(emptyRequest, httptest.NewRecorder())
+	assert.NoError(t, c.SetCookie("foo", "", -1, "", "", false, false))
+	assert.Equal(t, "foo=; Expires=Thu, 01 Jan 1970 00:00:00 GMT; Max-Age=0", c.Get("Set-Cookie"))
+
+	SetCookieDefaults(CookieDefaults{Path: "/", SameSite: http.SameSiteStrictMode})
+	defer SetCookieDefaults(CookieDefaults{})
+	c = NewContext(emptyRequest, httptest.NewRecorder())
+	assert.NoError(t, c.SetCookie("foo", "bar", 0, "", "", false, false))
+	assert.Equal(t, "foo=bar; Path=/; SameSite=Strict", c.Get("Set-Cookie"))
+
+	c = NewContext(emptyRequest, httptest.NewRecorder())
+	assert.Error(t, c.SetCookie("foo", "b;r", 0, "/", "", false, false))
+	assert.Equal(t, "", c.Get("Set-Cookie"))
+}
+
+func TestContext_CookieDefaults(t *testing.T) {
+	SetCookieDefaults(CookieDefaults{
+		Path:     "/",
+		SameSite: http.SameSiteLaxMode,
+		Secure:   true,
+		HttpOnly: true,
+	})
+	defer SetCookieDefaults(CookieDefaults{})
+
+	tests := []struct {
+		cookie   *http.Cookie
+		expected string
+	}{
+		{
+			&http.Cookie{Name: "foo", Value: "bar"},
+			"foo=bar; Path=/; HttpOnly; Secure; SameSite=Lax",
+		},
+		{
+			&http.Cookie{Name: "foo", Value: "bar", Path: "/admin", SameSite: http.SameSiteStrictMode},
+			"foo=bar; Path=/admin; HttpOnly; Secure; SameSite=Strict",
+		},
+	}
+
+	for _, tt := range tests {
+		c := NewContext(emptyRequest, httptest.NewRecorder())
+		assert.NoError(t, c.Cookie(tt.cookie))
+		assert.Equal(t, tt.expected, c.Get("Set-Cookie"))
+	}
+
+	cookie := &http.Cookie{Name: "foo", Value: "bar"}
+	c := NewContext(emptyRequest, httptest.NewRecorder())
+	c.Cookie(cookie)
+	assert.Equal(t, &http.Cookie{Name: "foo", Value: "bar"}, cookie)
+}
+
+func TestContext_CookieInvalidValue(t *testing.T) {
+	tests := []struct {
+		value       string
+		expectedErr bool
+	}{
+		{"bar", false},
+		{"bar baz,qux", false},
+		{`"bar"`, false},
+		{"", false},
+		{"bar;baz", true},
+		{`bar"baz`, true},
+		{`bar\baz`, true},
+		{"bär", true},
+		{"bar\n", true},
+	}
+
+	for _, tt := range tests {
+		c := NewContext(emptyRequest, httptest.NewRecorder())
+		err := c.Cookie(&http.Cookie{Name: "foo", Value: tt.value})
+		assert.Equal(t, tt.expectedErr, err != nil, tt.value)
+		if tt.expectedErr {
+			assert.Equal(t, "", c.Get("Set-Cookie"))
+		} else {
+			assert.NotEqual(t, "", c.Get("Set-Cookie"))
+		}
+	}
+}
+
 func TestContext_ClearCookie(t *testing.T) {
 	tests := []struct {
 		cookie   *http.Cookie
@@ -550,6 +978,24 @@ func TestContext_ClearCookie(t *testing.T) {
 				fmt.Sprintf("foo=; Path=/; Expires=%s", time.Unix(0, 0).UTC().Format(timeFormat)),
 			},
 		},
+		{
+			&http.Cookie{
+				Name:     "foo",
+				Value:    "bar",
+				Path:     "/admin",
+				Domain:   "example.com",
+				Secure:   true,
+				HttpOnly: true,
+				SameSite: http.SameSiteStrictMode,
+			},
+			[]string{
+				"foo=bar; Path=/admin; Domain=example.com; HttpOnly; Secure; SameSite=Strict",
+				fmt.Sprintf(
+					"foo=; Path=/admin; Domain=example.com; Expires=%s; Secure; SameSite=Strict",
+					time.Unix(0, 0).UTC().Format(timeFormat),
+				),
+			},
+		},
 	}
 
 	assert := assert.New(t)
@@ -562,6 +1008,154 @@ func TestContext_ClearCookie(t *testing.T) {
 	}
 }
 
+func TestContext_ContentLength(t *testing.T) {
+	tests := []struct {
+		method       string
+		send         func(c *Context)
+		expected     string
+		expectedBody string
+	}{
+		{"GET", func(c *Context) { c.String("hello") }, "5", "hello"},
+		{"GET", func(c *Context) { c.Send("你好") }, "6", "你好"},
+		{"GET", func(c *Context) { c.Json(map[string]string{"foo": "bar"}) }, "14", `{"foo":"bar"}` + "\n"},
+		{"GET", func(c *Context) { c.Data("image/png", []byte{1, 2, 3}) }, "3", "\x01\x02\x03"},
+		{"GET", func(c *Context) { c.Data("", nil) }, "0", ""},
+		{"HEAD", func(c *Context) { c.Json(map[string]string{"foo": "bar"}) }, "14", ""},
+		{"HEAD", func(c *Context) { c.String("hello") }, "5", ""},
+		{"HEAD", func(c *Context) { c.Render(&renderer.Reader{Reader: &ErrTooLargeReader{}}) }, "", ""},
+		{"GET", func(c *Context) { c.Status(204).String("hello") }, "", ""},
+		{"GET", func(c *Context) { c.Set("Content-Length", "2").String("hi") }, "2", "hi"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c := NewContext(httptest.NewRequest(tt.method, "/", nil), w)
+			tt.send(c)
+			assert.Equal(t, tt.expected, w.Header().Get("Content-Length"))
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+		})
+	}
+}
+
+func TestContext_Data(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := NewContext(emptyRequest, w)
+	c.Data("image/png", []byte("png"))
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+	assert.Equal(t, "png", w.Body.String())
+
+	w = httptest.NewRecorder()
+	c = NewContext(emptyRequest, w)
+	c.Data("", []byte("bin"))
+	assert.Equal(t, "application/octet-stream", w.Header().Get("Content-Type"))
+	assert.Equal(t, "bin", w.Body.String())
+}
+
+func TestContext_JSONBlob(t *testing.T) {
+	tests := []struct {
+		data    string
+		warning bool
+	}{
+		{`{"foo":"bar"}`, false},
+		{`[1,2,3]`, false},
+		{`{"foo":`, true},
+		{``, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c := NewContext(emptyRequest, w)
+			output := captureOutput(t, func() {
+				SetMode(DebugMode)
+				defer SetMode(TestMode)
+				c.JSONBlob([]byte(tt.data))
+			})
+			assert.Equal(t, jsonType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.data, w.Body.String())
+			if tt.warning {
+				assert.Equal(t, "[SOON-debug] [WARNING] c.JSONBlob is called with malformed JSON\n", output)
+			} else {
+				assert.Equal(t, "", output)
+			}
+		})
+	}
+
+	w := httptest.NewRecorder()
+	output := captureOutput(t, func() {
+		NewContext(emptyRequest, w).JSONBlob([]byte(`{"foo":`))
+	})
+	assert.Equal(t, `{"foo":`, w.Body.String())
+	assert.Equal(t, "", output)
+}
+
+func TestContext_XMLBlob(t *testing.T) {
+	tests := []struct {
+		data    string
+		warning bool
+	}{
+		{`<book><name>foo</name></book>`, false},
+		{`<?xml version="1.0"?><book pageTotal="50"/>`, false},
+		{`<book><name>foo</book>`, true},
+		{`foo`, true},
+		{``, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.data, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c := NewContext(emptyRequest, w)
+			output := captureOutput(t, func() {
+				SetMode(DebugMode)
+				defer SetMode(TestMode)
+				c.XMLBlob([]byte(tt.data))
+			})
+			assert.Equal(t, "application/xml; charset=utf-8", w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.data, w.Body.String())
+			if tt.warning {
+				assert.Equal(t, "[SOON-debug] [WARNING] c.XMLBlob is called with malformed XML\n", output)
+			} else {
+				assert.Equal(t, "", output)
+			}
+		})
+	}
+}
+
+func TestContext_Gob(t *testing.T) {
+	type user struct {
+		Name string `validate:"required"`
+		Age  int
+	}
+
+	router := NewRouter()
+	router.POST("/users", func(c *Context) {
+		var u user
+		c.MustBindWith(&u, binding.GOB)
+		u.Age++
+		c.Gob(u)
+	})
+	router.POST("/auto", func(c *Context) {
+		var u user
+		c.MustBind(&u)
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, gob.NewEncoder(&buf).Encode(user{"foo", 17}))
+
+	// gob isn't bound by the content type
+	w := Test(router).Post("/auto").Set("Content-Type", binding.MIMEGOB).Send(buf.Bytes()).Do()
+	assert.Equal(t, http.StatusUnsupportedMediaType, w.Code)
+
+	w = Test(router).Post("/users").Set("Content-Type", binding.MIMEGOB).Send(buf.Bytes()).Do()
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "application/x-gob", w.Header().Get("Content-Type"))
+
+	var u user
+	require.NoError(t, gob.NewDecoder(w.Body).Decode(&u))
+	assert.Equal(t, user{"foo", 18}, u)
+}
+
 func TestContext_SendFile(t *testing.T) {
 	pwd, err := os.Getwd()
 	if err != nil {
@@ -747,6 +1341,74 @@ func TestContext_Download(t *testing.T) {
 	}
 }
 
+func TestContext_SendFileInline(t *testing.T) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name                string
+		options             []renderer.FileOptions
+		expectedDisposition string
+	}{
+		{"", nil, `inline; filename="README.md"`},
+		{"readme.md", nil, `inline; filename="readme.md"`},
+		{"说明.md", nil, `inline; filename="??.md"; filename*=UTF-8''%E8%AF%B4%E6%98%8E.md`},
+		{
+			"readme.md",
+			[]renderer.FileOptions{{Header: map[string]string{"X-Foo": "bar"}}},
+			`inline; filename="readme.md"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filePath := path.Join(pwd, "README.md")
+			w := httptest.NewRecorder()
+			c := NewContext(emptyRequest, w)
+			c.SendFileInline(filePath, tt.name, tt.options...)
+			assert.Equal(t, 200, w.Code)
+			assert.Equal(t, tt.expectedDisposition, w.Header().Get("Content-Disposition"))
+			assert.Equal(t, "text/markdown; charset=utf-8", w.Header().Get("Content-Type"))
+			_, fileContent := getFileContent(filePath, nil)
+			assert.Equal(t, fileContent, w.Body.String())
+			if len(tt.options) > 0 {
+				assert.Equal(t, "bar", w.Header().Get("X-Foo"))
+				assert.Equal(t, 1, len(tt.options[0].Header))
+			}
+		})
+	}
+}
+
+func TestContext_DownloadReader(t *testing.T) {
+	tests := []struct {
+		name                string
+		contentType         string
+		content             string
+		expectedType        string
+		expectedDisposition string
+	}{
+		{"report.csv", "", "a,b\n1,2\n", "text/csv; charset=utf-8", `attachment; filename="report.csv"`},
+		{"report", "", "foo", "application/octet-stream", `attachment; filename="report"`},
+		{"report.bin", "application/pdf", "%PDF", "application/pdf", `attachment; filename="report.bin"`},
+		{"报表.csv", "text/csv", "a,b", "text/csv; charset=utf-8", `attachment; filename="??.csv"; filename*=UTF-8''%E6%8A%A5%E8%A1%A8.csv`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert := assert.New(t)
+			w := httptest.NewRecorder()
+			c := NewContext(emptyRequest, w)
+			c.DownloadReader(tt.name, tt.contentType, bytes.NewReader([]byte(tt.content)))
+			assert.Equal(200, w.Code)
+			assert.Equal(tt.expectedType, w.Header().Get("Content-Type"))
+			assert.Equal(tt.expectedDisposition, w.Header().Get("Content-Disposition"))
+			assert.Equal(tt.content, w.Body.String())
+		})
+	}
+}
+
 func TestContext_End(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -790,6 +1452,106 @@ func TestContext_End(t *testing.T) {
 	}
 }
 
+func TestContext_HeaderAfterEnd(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := NewContext(emptyRequest, w)
+	c.Set("X-Foo", "foo")
+	c.End()
+
+	output := captureOutput(t, func() {
+		SetMode(DebugMode)
+		defer SetMode(TestMode)
+		assert.NotPanics(t, func() {
+			c.Set("X-Foo", "bar").Append("X-Bar", "bar").Vary("Accept")
+			c.Status(500).Type("json").Attachment("foo.txt")
+			c.Cookie(&http.Cookie{Name: "foo", Value: "bar"})
+		})
+	})
+
+	assert.Equal(t, "foo", c.Get("X-Foo"))
+	assert.Equal(t, "", c.Get("X-Bar"))
+	assert.Equal(t, "", c.Get("Vary"))
+	assert.Equal(t, "", c.Get("Content-Type"))
+	assert.Equal(t, "", c.Get("Content-Disposition"))
+	assert.Equal(t, "", c.Get("Set-Cookie"))
+	assert.Equal(t, 200, c.Writer.Status())
+	assert.Equal(t, 200, w.Code)
+	assert.Contains(t, output, "[WARNING] c.Set is ignored since the response is finished")
+	assert.Contains(t, output, "[WARNING] c.Status is ignored since the response is finished")
+	assert.Contains(t, output, "[WARNING] c.Cookie is ignored since the response is finished")
+}
+
+func TestContext_DoubleSend(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := NewContext(emptyRequest, w)
+	output := captureOutput(t, func() {
+		SetMode(DebugMode)
+		defer SetMode(TestMode)
+		c.Json("foo")
+		c.Json("bar")
+	})
+	assert.Equal(t, `"foo"`, strings.TrimSpace(w.Body.String()))
+	assert.Equal(t, "[SOON-debug] [WARNING] c.Render is ignored since the response is finished\n", output)
+
+	w = httptest.NewRecorder()
+	c = NewContext(emptyRequest, w)
+	output = captureOutput(t, func() {
+		c.Send("foo")
+		c.Send("bar")
+	})
+	assert.Equal(t, "foo", w.Body.String())
+	assert.Equal(t, "", output)
+}
+
+func TestContext_Push(t *testing.T) {
+	c := NewContext(httptest.NewRequest("GET", "/", nil), httptest.NewRecorder())
+	assert.Equal(t, ErrNotSupported, c.Push("/app.css", nil))
+
+	pusher := &testPusher{ResponseRecorder: httptest.NewRecorder()}
+	c = NewContext(httptest.NewRequest("GET", "/", nil), pusher)
+	assert.NoError(t, c.Push("/app.css", nil))
+	assert.NoError(t, c.Push("/app.js", &http.PushOptions{Method: "GET"}))
+	assert.Equal(t, []string{"/app.css", "/app.js"}, pusher.targets)
+
+	// the writer replaced by middleware may support the server push too
+	c = NewContext(httptest.NewRequest("GET", "/", nil), httptest.NewRecorder())
+	c.Writer = &pushResponseWriter{ResponseWriter: c.Writer}
+	assert.NoError(t, c.Push("/app.css", nil))
+	assert.Equal(t, []string{"/app.css"}, c.Writer.(*pushResponseWriter).targets)
+}
+
+type pushResponseWriter struct {
+	ResponseWriter
+
+	targets []string
+}
+
+func (w *pushResponseWriter) Push(target string, opts *http.PushOptions) error {
+	w.targets = append(w.targets, target)
+	return nil
+}
+
+func TestContext_Write(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := NewContext(emptyRequest, w)
+	c.Status(201)
+	n, err := fmt.Fprintf(c, "hello %s", "world")
+	assert.NoError(t, err)
+	assert.Equal(t, 11, n)
+	_, err = io.Copy(c, strings.NewReader("!"))
+	assert.NoError(t, err)
+	tmpl := template.Must(template.New("").Parse(`<p>{{.}}</p>`))
+	assert.NoError(t, tmpl.Execute(c, "foo"))
+	assert.Equal(t, 201, w.Code)
+	assert.Equal(t, "hello world!<p>foo</p>", w.Body.String())
+
+	c.End()
+	n, err = c.Write([]byte("bar"))
+	assert.Equal(t, ErrResponseFinished, err)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, "hello world!<p>foo</p>", w.Body.String())
+}
+
 func TestContext_Format(t *testing.T) {
 	handles := map[string]Handle{
 		"text/plain": func(c *Context) {
@@ -858,28 +1620,252 @@ func TestContext_Format(t *testing.T) {
 		{"application/*", extensionHandlesWithDefault, 200, `{"message":"hey"}`},
 	}
 
-	app := New()
-	server := httptest.NewServer(app)
-	defer server.Close()
+	app := New()
+	server := httptest.NewServer(app)
+	defer server.Close()
+
+	for i, tt := range tests {
+		path := "/" + strconv.Itoa(i)
+		app.GET(path, func(tt testObj) func(c *Context) {
+			return func(c *Context) {
+				c.Format(tt.format)
+			}
+		}(tt))
+	}
+
+	assert := assert.New(t)
+	for i, tt := range tests {
+		path := "/" + strconv.Itoa(i)
+		h := http.Header{"Accept": dotRegexp.Split(tt.accept, -1)}
+		statusCode, _, body, err := request(http.MethodGet, server.URL+path, h)
+		body = strings.TrimSpace(body)
+		assert.Nil(err)
+		assert.Equal(tt.expectedStatus, statusCode)
+		assert.Equal(tt.expectedBody, body)
+	}
+}
+
+func TestContext_Negotiate(t *testing.T) {
+	type message struct {
+		XMLName xml.Name `json:"-" xml:"message"`
+		Text    string   `json:"text" xml:"text"`
+	}
+	tmpl := template.Must(template.New("index").Parse(`<p>{{.Text}}</p>`))
+	template.Must(tmpl.New("title").Parse(`<h1>{{.Text}}</h1>`))
+	offered := []string{"application/json", "application/xml", "text/html"}
+
+	tests := []struct {
+		accept              string
+		config              NegotiateConfig
+		expectedStatus      int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{
+			"application/json",
+			NegotiateConfig{Offered: offered, JSONData: message{Text: "json"}, Data: message{Text: "data"}},
+			200, jsonType, `{"text":"json"}`,
+		},
+		{
+			"application/json",
+			NegotiateConfig{Offered: offered, Data: message{Text: "data"}},
+			200, jsonType, `{"text":"data"}`,
+		},
+		{
+			"application/xml",
+			NegotiateConfig{Offered: offered, XMLData: message{Text: "xml"}},
+			200, "application/xml; charset=utf-8", `<message><text>xml</text></message>`,
+		},
+		{
+			"text/xml",
+			NegotiateConfig{Offered: []string{"json", "text/xml"}, Data: message{Text: "xml"}},
+			200, "text/xml; charset=utf-8", `<message><text>xml</text></message>`,
+		},
+		{
+			"text/html",
+			NegotiateConfig{Offered: offered, HTMLData: "<p>html</p>"},
+			200, htmlType, `<p>html</p>`,
+		},
+		{
+			"text/html",
+			NegotiateConfig{Offered: offered, HTMLTemplate: tmpl, HTMLData: message{Text: "html"}},
+			200, htmlType, `<p>html</p>`,
+		},
+		{
+			"text/html",
+			NegotiateConfig{Offered: offered, HTMLTemplate: tmpl, HTMLName: "title", Data: message{Text: "html"}},
+			200, htmlType, `<h1>html</h1>`,
+		},
+		{
+			"application/xml;q=0.9, application/json",
+			NegotiateConfig{Offered: []string{"xml", "json"}, Data: message{Text: "data"}},
+			200, jsonType, `{"text":"data"}`,
+		},
+		{
+			"*/*",
+			NegotiateConfig{Offered: []string{"xml", "json"}, Data: message{Text: "data"}},
+			200, "application/xml; charset=utf-8", `<message><text>data</text></message>`,
+		},
+		{
+			"image/png",
+			NegotiateConfig{Offered: offered, Data: message{Text: "data"}},
+			406, plainType, http.StatusText(406),
+		},
+		{
+			"application/json",
+			NegotiateConfig{Data: message{Text: "data"}},
+			406, jsonType, `{"error":"Not Acceptable","status":406}`,
+		},
+		{
+			"image/png",
+			NegotiateConfig{Offered: []string{"image/png"}, Data: message{Text: "data"}},
+			500, plainType, "unsupported negotiate format: image/png",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			router := NewRouter()
+			router.GET("/", func(c *Context) {
+				c.Negotiate(tt.config)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Header.Set("Accept", tt.accept)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedContentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(w.Body.String()))
+			assert.Equal(t, "Accept", w.Header().Get("Vary"))
+		})
+	}
+}
+
+func TestContext_Bind(t *testing.T) {
+	type foo struct {
+		Foo string `json:"foo" form:"foo"`
+	}
+
+	tests := []struct {
+		method      string
+		contentType string
+		body        string
+		expected    string
+		expectedErr error
+	}{
+		{"GET", "", "", "query", nil},
+		{"POST", "application/json; charset=utf-8", `{"foo":"json"}`, "json", nil},
+		{"POST", "application/x-www-form-urlencoded", "foo=form", "form", nil},
+		{"POST", "", "", "query", nil},
+		{"POST", "application/octet-stream", "foo", "", binding.ErrUnsupportedMediaType},
+		{"PUT", "text/plain", "foo", "", binding.ErrUnsupportedMediaType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/?foo=query", strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			c := NewContext(req, httptest.NewRecorder())
+			var s foo
+			err := c.Bind(&s)
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expected, s.Foo)
+		})
+	}
+}
+
+func TestContext_BindSniffing(t *testing.T) {
+	binding.EnableContentTypeSniffing = true
+	defer func() {
+		binding.EnableContentTypeSniffing = false
+	}()
+
+	type foo struct {
+		Foo []string `json:"foo" form:"foo"`
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		body     string
+		expected []string
+	}{
+		{"json", "POST", `{"foo":["json"]}`, []string{"json"}},
+		{"json-with-spaces", "PUT", " \r\n\t{\"foo\":[\"json\"]}", []string{"json"}},
+		{"json-array", "POST", `[{"foo":["json"]}]`, nil},
+		{"form", "POST", "foo=form", []string{"query"}},
+		{"empty", "POST", "", []string{"query"}},
+		{"spaces", "POST", "   ", []string{"query"}},
+		{"get", "GET", `{"foo":["json"]}`, []string{"query"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/?foo=query", strings.NewReader(tt.body))
+			c := NewContext(req, httptest.NewRecorder())
+			var s foo
+			err := c.Bind(&s)
+			if tt.name == "json-array" {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, s.Foo)
+		})
+	}
+
+	router := NewRouter()
+	router.Use(CacheBody())
+	router.POST("/", func(c *Context) {
+		var s1, s2 foo
+		c.MustBind(&s1)
+		c.MustBind(&s2)
+		c.Send(s1.Foo[0] + s2.Foo[0])
+	})
+	w := Test(router).Post("/").Send(`{"foo":["bar"]}`).Do()
+	assert.Equal(t, "barbar", w.Body.String())
+
+	binding.EnableContentTypeSniffing = false
+	req := httptest.NewRequest("POST", "/?foo=query", strings.NewReader(`{"foo":["json"]}`))
+	var s foo
+	assert.NoError(t, NewContext(req, httptest.NewRecorder()).Bind(&s))
+	assert.Equal(t, []string{"query"}, s.Foo)
+}
+
+func TestContext_MustBind(t *testing.T) {
+	router := NewRouter()
+	router.POST("/", func(c *Context) {
+		var s struct {
+			Foo string `json:"foo"`
+		}
+		c.MustBind(&s)
+		c.Send(s.Foo)
+	})
 
-	for i, tt := range tests {
-		path := "/" + strconv.Itoa(i)
-		app.GET(path, func(tt testObj) func(c *Context) {
-			return func(c *Context) {
-				c.Format(tt.format)
-			}
-		}(tt))
+	tests := []struct {
+		contentType    string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"application/json", `{"foo":"bar"}`, 200, "bar"},
+		{"application/json", `{`, 400, ""},
+		{"application/octet-stream", "foo", 415, "unsupported media type"},
 	}
 
-	assert := assert.New(t)
-	for i, tt := range tests {
-		path := "/" + strconv.Itoa(i)
-		h := http.Header{"Accept": dotRegexp.Split(tt.accept, -1)}
-		statusCode, _, body, err := request(http.MethodGet, server.URL+path, h)
-		body = strings.TrimSpace(body)
-		assert.Nil(err)
-		assert.Equal(tt.expectedStatus, statusCode)
-		assert.Equal(tt.expectedBody, body)
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			req := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, strings.TrimSpace(w.Body.String()))
+			}
+		})
 	}
 }
 
@@ -917,6 +1903,34 @@ func TestContext_BindQuery(t *testing.T) {
 	assert.Equal(t, 0, w.Body.Len())
 }
 
+type testColor struct {
+	R, G, B uint8
+}
+
+func (c *testColor) UnmarshalText(text []byte) error {
+	_, err := fmt.Sscanf(string(text), "#%02x%02x%02x", &c.R, &c.G, &c.B)
+	return err
+}
+
+func TestContext_BindQuery_CustomTypes(t *testing.T) {
+	req := httptest.NewRequest("GET", "/?created=2020-01-02&color=%23ff8000", nil)
+	c := NewContext(req, httptest.NewRecorder())
+
+	var obj struct {
+		Created time.Time  `form:"created" time_format:"2006-01-02" time_utc:"1"`
+		Color   testColor  `form:"color"`
+		Missing *testColor `form:"missing"`
+	}
+	assert.NoError(t, c.BindQuery(&obj))
+	assert.Equal(t, time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC), obj.Created)
+	assert.Equal(t, testColor{255, 128, 0}, obj.Color)
+	assert.Nil(t, obj.Missing)
+
+	req = httptest.NewRequest("GET", "/?color=red", nil)
+	c = NewContext(req, httptest.NewRecorder())
+	assert.Error(t, c.BindQuery(&obj))
+}
+
 func TestContext_BindHeader(t *testing.T) {
 	req := httptest.NewRequest("POST", "/", nil)
 	w := httptest.NewRecorder()
@@ -1057,6 +2071,51 @@ func TestContext_MustBindHeader(t *testing.T) {
 	c.MustBindHeader(&testHeader)
 }
 
+func TestContext_BindUriWildcard(t *testing.T) {
+	type Rest struct {
+		Name  string   `uri:"name" validate:"required"`
+		First string   `uri:"0"`
+		Rest  string   `uri:"*"`
+		All   []string `uri:"*"`
+	}
+
+	tests := []struct {
+		route    string
+		path     string
+		expected Rest
+	}{
+		{
+			"/rest/:name/(.*)",
+			"/rest/foo/bar/baz",
+			Rest{Name: "foo", First: "bar/baz", Rest: "bar/baz", All: []string{"bar/baz"}},
+		},
+		{
+			"/rest/:name/([^/]+)/(.*)",
+			"/rest/foo/bar/baz/qux",
+			Rest{Name: "foo", First: "bar", Rest: "bar", All: []string{"bar", "baz/qux"}},
+		},
+		{
+			"/rest/:name",
+			"/rest/foo",
+			Rest{Name: "foo"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.route, func(t *testing.T) {
+			var rest Rest
+			router := NewRouter()
+			router.GET(tt.route, func(c *Context) {
+				assert.NoError(t, c.BindUri(&rest))
+			})
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expected, rest)
+		})
+	}
+}
+
 func TestContext_MustBindUri(t *testing.T) {
 	router := NewRouter()
 	server := httptest.NewServer(router)
@@ -1086,6 +2145,43 @@ func TestContext_MustBindUri(t *testing.T) {
 	assert.Equal(t, "Key: 'Person.ID' Error:Field validation for 'ID' failed on the 'min' tag", body)
 }
 
+func TestContext_BindWithOptions(t *testing.T) {
+	type user struct {
+		Name string `form:"name" validate:"required"`
+		Age  int    `form:"age"`
+	}
+
+	router := NewRouter()
+	router.GET("/strict", func(c *Context) {
+		var u user
+		c.MustBindWithOptions(&u, binding.Query, BindOptions{})
+		c.Send(strconv.Itoa(u.Age))
+	})
+	router.GET("/loose", func(c *Context) {
+		var u user
+		c.MustBindWithOptions(&u, binding.Query, BindOptions{SkipValidation: true})
+		c.Send(strconv.Itoa(u.Age))
+	})
+
+	w := Test(router).Get("/strict?age=3").Do()
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	w = Test(router).Get("/strict?name=foo&age=3").Do()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "3", w.Body.String())
+	w = Test(router).Get("/loose?age=3").Do()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "3", w.Body.String())
+	w = Test(router).Get("/loose?age=x").Do()
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+
+	req := httptest.NewRequest("GET", "/?age=3", nil)
+	c := NewContext(req, httptest.NewRecorder())
+	var u user
+	assert.Error(t, c.BindWithOptions(&u, binding.Query, BindOptions{}))
+	assert.NoError(t, c.BindWithOptions(&u, binding.Query, BindOptions{SkipValidation: true}))
+	assert.Equal(t, 3, u.Age)
+}
+
 func TestContext_MustBindWith(t *testing.T) {
 	for _, tt := range jsonBindTests {
 		t.Run("", func(t *testing.T) {
@@ -1132,6 +2228,290 @@ func TestContext_BindBodyWith(t *testing.T) {
 	require.Equal(t, bytes.ErrTooLarge, err)
 }
 
+type slowReader struct {
+	delay time.Duration
+	data  []byte
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if len(r.data) == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(r.delay)
+	n := copy(p[:1], r.data)
+	r.data = r.data[n:]
+	return n, nil
+}
+
+func TestContext_BindBodyWithDeadline(t *testing.T) {
+	body := []byte(`{"name":"foo"}`)
+	var obj struct {
+		Name string `json:"name"`
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req := httptest.NewRequest("POST", "/", &slowReader{50 * time.Millisecond, body})
+	c := NewContext(req.WithContext(ctx), httptest.NewRecorder())
+	err := c.BindBodyWith(&obj, binding.JSON)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Equal(t, "", obj.Name)
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	req = httptest.NewRequest("POST", "/", &slowReader{time.Millisecond, body})
+	c = NewContext(req.WithContext(ctx), httptest.NewRecorder())
+	assert.NoError(t, c.BindBodyWith(&obj, binding.JSON))
+	assert.Equal(t, "foo", obj.Name)
+}
+
+type multipartFile struct {
+	fieldname string
+	filename  string
+	content   []byte
+}
+
+func createMultipartRequest(t *testing.T, fields map[string]string, files ...multipartFile) *http.Request {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	for k, v := range fields {
+		require.NoError(t, mw.WriteField(k, v))
+	}
+	for _, file := range files {
+		fw, err := mw.CreateFormFile(file.fieldname, file.filename)
+		require.NoError(t, err)
+		_, err = fw.Write(file.content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, mw.Close())
+
+	req := httptest.NewRequest("POST", "/", &body)
+	req.Header.Set("Content-Type", binding.MIMEMultipartPOSTForm+"; boundary="+mw.Boundary())
+	return req
+}
+
+func TestContext_MultipartForm(t *testing.T) {
+	file := multipartFile{"file", "foo.txt", []byte("hello")}
+	req := createMultipartRequest(t, map[string]string{"name": "foo"}, file)
+	c := NewContext(req, httptest.NewRecorder())
+	form, err := c.MultipartForm()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"foo"}, form.Value["name"])
+	require.Len(t, form.File["file"], 1)
+	assert.Equal(t, file.filename, form.File["file"][0].Filename)
+
+	c = NewContext(httptest.NewRequest("POST", "/", nil), httptest.NewRecorder())
+	_, err = c.MultipartForm()
+	assert.Equal(t, http.ErrNotMultipart, err)
+}
+
+func TestContext_FormFile(t *testing.T) {
+	file := multipartFile{"file", "foo.txt", []byte("hello")}
+	req := createMultipartRequest(t, nil, file)
+	c := NewContext(req, httptest.NewRecorder())
+	fh, err := c.FormFile("file")
+	require.NoError(t, err)
+	assert.Equal(t, file.filename, fh.Filename)
+
+	_, err = c.FormFile("not_exists")
+	assert.Equal(t, http.ErrMissingFile, err)
+
+	c = NewContext(httptest.NewRequest("POST", "/", nil), httptest.NewRecorder())
+	_, err = c.FormFile("file")
+	assert.Equal(t, http.ErrNotMultipart, err)
+}
+
+func TestContext_FormFileMaxMemory(t *testing.T) {
+	defer func(size int64) {
+		binding.MaxMultipartMemory = size
+	}(binding.MaxMultipartMemory)
+	SetMaxMultipartMemory(8)
+
+	file := multipartFile{"file", "foo.txt", bytes.Repeat([]byte("hello"), 100)}
+	req := createMultipartRequest(t, nil, file)
+	c := NewContext(req, httptest.NewRecorder())
+	fh, err := c.FormFile("file")
+	require.NoError(t, err)
+
+	f, err := fh.Open()
+	require.NoError(t, err)
+	defer f.Close()
+	assert.IsType(t, &os.File{}, f)
+	content, err := ioutil.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, file.content, content)
+}
+
+func TestContext_SaveUploadedFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "soon")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	file := multipartFile{"file", "foo.txt", []byte("hello")}
+	req := createMultipartRequest(t, nil, file)
+	c := NewContext(req, httptest.NewRecorder())
+	fh, err := c.FormFile("file")
+	require.NoError(t, err)
+
+	dst := filepath.Join(dir, fh.Filename)
+	require.NoError(t, c.SaveUploadedFile(fh, dst))
+	content, err := ioutil.ReadFile(dst)
+	require.NoError(t, err)
+	assert.Equal(t, file.content, content)
+
+	err = c.SaveUploadedFile(fh, filepath.Join(dir, "not_exists", fh.Filename))
+	assert.Error(t, err)
+}
+
+func TestContext_Fresh(t *testing.T) {
+	lastModified := time.Now().UTC().Format(timeFormat)
+	tests := []struct {
+		method         string
+		resHeader      map[string]string
+		reqHeader      map[string]string
+		send           func(c *Context)
+		expectedFresh  bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			http.MethodGet,
+			map[string]string{"ETag": `"foo"`},
+			map[string]string{"If-None-Match": `"foo"`},
+			func(c *Context) { c.Send("hello") },
+			true, 304, "",
+		},
+		{
+			http.MethodGet,
+			map[string]string{"ETag": `"foo"`},
+			map[string]string{"If-None-Match": `"foo"`},
+			func(c *Context) { c.Json(map[string]string{"foo": "bar"}) },
+			true, 304, "",
+		},
+		{
+			http.MethodGet,
+			map[string]string{"Last-Modified": lastModified},
+			map[string]string{"If-Modified-Since": lastModified},
+			func(c *Context) { c.String("hello") },
+			true, 304, "",
+		},
+		{
+			http.MethodGet,
+			map[string]string{"ETag": `"foo"`},
+			map[string]string{"If-None-Match": `"bar"`},
+			func(c *Context) { c.Send("hello") },
+			false, 200, "hello",
+		},
+		{
+			http.MethodGet,
+			nil,
+			map[string]string{"If-None-Match": `"foo"`},
+			func(c *Context) { c.Send("hello") },
+			false, 200, "hello",
+		},
+		{
+			http.MethodPost,
+			map[string]string{"ETag": `"foo"`},
+			map[string]string{"If-None-Match": `"foo"`},
+			func(c *Context) { c.Send("hello") },
+			false, 200, "hello",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/", nil)
+			for k, v := range tt.reqHeader {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			c := NewContext(req, w)
+			for k, v := range tt.resHeader {
+				c.Set(k, v)
+			}
+			assert.Equal(t, tt.expectedFresh, c.Fresh())
+			assert.Equal(t, !tt.expectedFresh, c.Stale())
+			tt.send(c)
+			c.Send("ignored")
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(w.Body.String()))
+			if tt.expectedStatus == 304 {
+				assert.Equal(t, "", w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestContext_LastModified(t *testing.T) {
+	modified := time.Date(2020, 1, 2, 3, 4, 5, 0, time.FixedZone("CST", 8*3600))
+	tests := []struct {
+		ifModifiedSince string
+		expectedStatus  int
+		expectedBody    string
+	}{
+		{"", 200, `{"foo":"bar"}`},
+		{modified.Add(time.Hour).UTC().Format(timeFormat), 304, ""},
+		{modified.UTC().Format(timeFormat), 304, ""},
+		{modified.Add(-time.Hour).UTC().Format(timeFormat), 200, `{"foo":"bar"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.ifModifiedSince != "" {
+				req.Header.Set("If-Modified-Since", tt.ifModifiedSince)
+			}
+			w := httptest.NewRecorder()
+			c := NewContext(req, w)
+			c.LastModified(modified).Json(map[string]string{"foo": "bar"})
+			assert.Equal(t, "Wed, 01 Jan 2020 19:04:05 GMT", w.Header().Get("Last-Modified"))
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
+
+func TestContext_IsXHR(t *testing.T) {
+	tests := []struct {
+		header   map[string]string
+		expected bool
+	}{
+		{map[string]string{"X-Requested-With": "XMLHttpRequest"}, true},
+		{map[string]string{"X-Requested-With": "xmlhttprequest"}, true},
+		{map[string]string{"X-Requested-With": "fetch"}, false},
+		{nil, false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		for k, v := range tt.header {
+			req.Header.Set(k, v)
+		}
+		assert.Equal(t, tt.expected, NewContext(req, httptest.NewRecorder()).IsXHR())
+	}
+}
+
+func TestContext_IsWebsocket(t *testing.T) {
+	tests := []struct {
+		header   http.Header
+		expected bool
+	}{
+		{http.Header{"Connection": {"Upgrade"}, "Upgrade": {"websocket"}}, true},
+		{http.Header{"Connection": {"keep-alive, Upgrade"}, "Upgrade": {"WebSocket"}}, true},
+		{http.Header{"Connection": {"keep-alive", "upgrade"}, "Upgrade": {"websocket"}}, true},
+		{http.Header{"Connection": {"keep-alive"}, "Upgrade": {"websocket"}}, false},
+		{http.Header{"Connection": {"Upgrade"}, "Upgrade": {"h2c"}}, false},
+		{http.Header{"Upgrade": {"websocket"}}, false},
+		{http.Header{}, false},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header = tt.header
+		assert.Equal(t, tt.expected, NewContext(req, httptest.NewRecorder()).IsWebsocket(), tt.header)
+	}
+}
+
 func TestContext_String(t *testing.T) {
 	tests := []struct {
 		s                   string
@@ -1241,6 +2621,131 @@ func TestContext_Json(t *testing.T) {
 	}
 }
 
+func TestContext_JSONStream(t *testing.T) {
+	router := NewRouter()
+	router.GET("/", func(c *Context) {
+		items := make(chan interface{})
+		go func() {
+			defer close(items)
+			for i := 0; i < 1000; i++ {
+				items <- map[string]int{"id": i}
+			}
+		}()
+		c.JSONStream(items)
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, jsonType, w.Header().Get("Content-Type"))
+	assert.True(t, w.Flushed)
+	var result []map[string]int
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Len(t, result, 1000)
+
+	w = httptest.NewRecorder()
+	c := NewContext(httptest.NewRequest(http.MethodGet, "/", nil), w)
+	items := make(chan interface{})
+	close(items)
+	c.JSONStream(items)
+	assert.Equal(t, "[]", w.Body.String())
+}
+
+func TestContext_JSONStreamDiscard(t *testing.T) {
+	tests := []struct {
+		method         string
+		header         map[string]string
+		handle         func(c *Context)
+		expectedStatus int
+	}{
+		{method: http.MethodHead, expectedStatus: 200},
+		{
+			method: http.MethodGet,
+			header: map[string]string{"If-None-Match": `"foo"`},
+			handle: func(c *Context) {
+				c.Set("ETag", `"foo"`)
+			},
+			expectedStatus: 304,
+		},
+		{
+			method: http.MethodGet,
+			handle: func(c *Context) {
+				c.Status(204)
+			},
+			expectedStatus: 204,
+		},
+		{
+			method: http.MethodGet,
+			handle: func(c *Context) {
+				c.Send("foo")
+			},
+			expectedStatus: 200,
+		},
+	}
+
+	for _, tt := range tests {
+		done := make(chan struct{})
+		router := NewRouter()
+		router.Use(func(c *Context) {
+			items := make(chan interface{})
+			go func() {
+				defer close(done)
+				defer close(items)
+				for i := 0; i < 100; i++ {
+					items <- i
+				}
+			}()
+			if tt.handle != nil {
+				tt.handle(c)
+			}
+			c.JSONStream(items)
+		})
+
+		req := httptest.NewRequest(tt.method, "/", nil)
+		for k, v := range tt.header {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, tt.expectedStatus, w.Code)
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatalf("the producer of %s %d is blocked", tt.method, tt.expectedStatus)
+		}
+	}
+}
+
+func TestContext_AsciiJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := NewContext(emptyRequest, w)
+	c.AsciiJSON(struct {
+		Lang string `json:"lang"`
+	}{"中文"})
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, jsonType, w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"lang":"\u4e2d\u6587"}`, w.Body.String())
+	assert.NotContains(t, w.Body.String(), "中文")
+	for _, b := range w.Body.Bytes() {
+		assert.Less(t, b, byte(0x80))
+	}
+}
+
+func TestContext_PureJSON(t *testing.T) {
+	w := httptest.NewRecorder()
+	c := NewContext(emptyRequest, w)
+	c.PureJSON(map[string]string{"html": "<b>Hello</b>"})
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, jsonType, w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"html":"<b>Hello</b>"}`+"\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	c = NewContext(emptyRequest, w)
+	c.Json(map[string]string{"html": "<b>Hello</b>"})
+	assert.Equal(t, `{"html":"\u003cb\u003eHello\u003c/b\u003e"}`+"\n", w.Body.String())
+}
+
 func TestContext_Jsonp(t *testing.T) {
 	tests := []struct {
 		request             *http.Request
@@ -1536,6 +3041,110 @@ func TestContext_Render(t *testing.T) {
 	}
 }
 
+func TestContext_RenderHTTP2(t *testing.T) {
+	router := NewRouter()
+	router.GET("/", func(c *Context) {
+		c.String(body200)
+	})
+	server := httptest.NewUnstartedServer(router)
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	res, err := server.Client().Get(server.URL)
+	require.NoError(t, err)
+	defer res.Body.Close()
+	body, err := ioutil.ReadAll(res.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "HTTP/2.0", res.Proto)
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, body200, string(body))
+	assert.Equal(t, "", res.Header.Get("Connection"))
+	assert.Equal(t, "Soon", res.Header.Get("X-Powered-By"))
+}
+
+func TestContext_RenderStatus(t *testing.T) {
+	tests := []struct {
+		code     int
+		renderer renderer.Renderer
+		body     string
+	}{
+		{201, &renderer.String{Data: "created"}, "created"},
+		{422, &renderer.JSON{Data: map[string]string{"foo": "bar"}}, "{\"foo\":\"bar\"}\n"},
+		{204, &renderer.String{Data: "foo"}, ""},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		c := NewContext(emptyRequest, w)
+		c.RenderStatus(tt.code, tt.renderer)
+		assert.Equal(t, tt.code, w.Code)
+		assert.Equal(t, tt.body, w.Body.String())
+	}
+}
+
+func TestContext_RenderTwice(t *testing.T) {
+	tests := []struct {
+		method string
+		code   int
+	}{
+		{http.MethodHead, 200},
+		{http.MethodHead, 204},
+		{http.MethodGet, 204},
+		{http.MethodGet, 304},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+strconv.Itoa(tt.code), func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c := NewContext(httptest.NewRequest(tt.method, "/", nil), w)
+			c.RenderStatus(tt.code, &renderer.String{Data: "foo"})
+			assert.True(t, c.finished)
+			header := w.Header().Clone()
+
+			c.RenderStatus(500, &renderer.JSON{Data: "bar"})
+			assert.Equal(t, tt.code, w.Code)
+			assert.Equal(t, tt.code, c.Writer.Status())
+			assert.Equal(t, header, w.Header())
+			assert.Equal(t, "", w.Body.String())
+		})
+	}
+}
+
+func TestContext_RenderError(t *testing.T) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		filePath   string
+		options    renderer.FileOptions
+		statusCode int
+	}{
+		{path.Join(pwd, "not-exist.md"), renderer.FileOptions{}, 404},
+		{path.Join(pwd, ".gitignore"), renderer.FileOptions{DotfilesPolicy: renderer.DotfilesPolicyDeny}, 403},
+		{"README.md", renderer.FileOptions{}, 500},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			router := NewRouter()
+			router.GET("/", func(c *Context) {
+				c.SendFile(tt.filePath, tt.options)
+			})
+			router.Use(func(v interface{}, c *Context) {
+				c.Send("error")
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+			assert.Equal(t, tt.statusCode, w.Code)
+			assert.Equal(t, "error", w.Body.String())
+		})
+	}
+}
+
 func getFileContent(p string, r *util.Range) (os.FileInfo, string) {
 	f, err := os.Open(p)
 	if err != nil {
@@ -6,6 +6,7 @@ package soon
 
 import (
 	"fmt"
+	"io"
 	"strings"
 )
 
@@ -16,10 +17,19 @@ func IsDebugging() bool {
 }
 
 func debugPrint(format string, values ...interface{}) {
+	debugFprint(DefaultWriter, format, values...)
+}
+
+// debugPrintWARNING prints the warning to DefaultErrorWriter in debug mode.
+func debugPrintWARNING(format string, values ...interface{}) {
+	debugFprint(DefaultErrorWriter, "[WARNING] "+format, values...)
+}
+
+func debugFprint(w io.Writer, format string, values ...interface{}) {
 	if IsDebugging() {
 		if !strings.HasSuffix(format, "\n") {
 			format += "\n"
 		}
-		fmt.Fprintf(DefaultWriter, "[SOON-debug] "+format, values...)
+		fmt.Fprintf(w, "[SOON-debug] "+format, values...)
 	}
 }
@@ -46,6 +46,33 @@ func TestDebugPrint(t *testing.T) {
 	assert.Equal(t, expected, got)
 }
 
+func TestDebugPrintWARNING(t *testing.T) {
+	var out, errOut bytes.Buffer
+	stdout, stderr := captureWriters(&out, &errOut)
+	defer restoreWriters(stdout, stderr)
+
+	SetMode(DebugMode)
+	debugPrint("info")
+	debugPrintWARNING("warning %d", 1)
+	SetMode(TestMode)
+	debugPrintWARNING("ignored")
+
+	assert.Equal(t, "[SOON-debug] info\n", out.String())
+	assert.Equal(t, "[SOON-debug] [WARNING] warning 1\n", errOut.String())
+}
+
+// captureWriters replaces DefaultWriter and DefaultErrorWriter, and returns
+// the original ones.
+func captureWriters(w, errW io.Writer) (io.Writer, io.Writer) {
+	stdout, stderr := DefaultWriter, DefaultErrorWriter
+	DefaultWriter, DefaultErrorWriter = w, errW
+	return stdout, stderr
+}
+
+func restoreWriters(w, errW io.Writer) {
+	DefaultWriter, DefaultErrorWriter = w, errW
+}
+
 func captureOutput(t *testing.T, f func()) string {
 	reader, writer, err := os.Pipe()
 	if err != nil {
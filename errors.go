@@ -4,6 +4,170 @@
 
 package soon
 
-import "github.com/soongo/soon/internal"
+import (
+	"errors"
+	"fmt"
+	"html"
+	"net/http"
 
+	"github.com/go-playground/validator/v10"
+
+	"github.com/soongo/soon/internal"
+)
+
+// HttpError is an error with http status code, the default error handler
+// responds with its status code and error text.
 type HttpError internal.HttpError
+
+// NewError creates a HttpError with the given http status code and message,
+// which can be thrown by panic or passed to c.Next. If msg is empty, the
+// status text of code is used.
+func NewError(status int, msg string) HttpError {
+	if msg == "" {
+		return internal.NewStatusCodeError(status)
+	}
+	return internal.NewStatusTextError(status, msg)
+}
+
+// NotAcceptableError is the error passed to c.Next by c.Format and c.Negotiate
+// when none of the offered types is acceptable, so that error handlers are
+// able to render the offered types.
+type NotAcceptableError struct {
+	// The offered content types.
+	Offered []string
+}
+
+var _ HttpError = &NotAcceptableError{}
+
+// Error returns the status text of 406.
+func (e *NotAcceptableError) Error() string {
+	return http.StatusText(http.StatusNotAcceptable)
+}
+
+// Status returns 406.
+func (e *NotAcceptableError) Status() int {
+	return http.StatusNotAcceptable
+}
+
+// NotAcceptableHandle handles the request which accepts none of the offered
+// content types, see SetNotAcceptableHandler.
+type NotAcceptableHandle func(c *Context, offered []string)
+
+var notAcceptableHandler NotAcceptableHandle
+
+// notAcceptable responds the request which accepts none of the offered types
+// by the handler set by SetNotAcceptableHandler, or passes NotAcceptableError
+// to the error handlers.
+func notAcceptable(c *Context, offered []string) {
+	if notAcceptableHandler != nil {
+		c.Status(http.StatusNotAcceptable)
+		notAcceptableHandler(c, offered)
+		return
+	}
+	c.Next(&NotAcceptableError{Offered: offered})
+}
+
+// BindingError is the error thrown by c.MustBind and the similar methods when
+// the binding fails, its status code is 400 or 415.
+type BindingError struct {
+	status int
+
+	// The error returned by the binding.
+	Err error
+}
+
+var _ HttpError = &BindingError{}
+
+// newBindingError creates a BindingError with the given status code and error.
+func newBindingError(status int, err error) *BindingError {
+	return &BindingError{status: status, Err: err}
+}
+
+// Error returns the error text of the binding error.
+func (e *BindingError) Error() string {
+	return e.Err.Error()
+}
+
+// Status returns the http status code.
+func (e *BindingError) Status() int {
+	return e.status
+}
+
+// Unwrap returns the binding error.
+func (e *BindingError) Unwrap() error {
+	return e.Err
+}
+
+// FieldError describes a field which fails the validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Tag     string `json:"tag"`
+	Param   string `json:"param,omitempty"`
+	Message string `json:"message"`
+}
+
+// Fields returns the fields failing the validation, or nil if the error is not
+// a validation error, such as malformed JSON.
+func (e *BindingError) Fields() []FieldError {
+	var errs validator.ValidationErrors
+	if !errors.As(e.Err, &errs) {
+		return nil
+	}
+
+	fields := make([]FieldError, len(errs))
+	for i, fe := range errs {
+		fields[i] = FieldError{
+			Field:   fe.Field(),
+			Tag:     fe.Tag(),
+			Param:   fe.Param(),
+			Message: fmt.Sprintf("Field validation for '%s' failed on the '%s' tag", fe.Field(), fe.Tag()),
+		}
+	}
+	return fields
+}
+
+var bindingErrorJSON bool
+
+// jsonBindingError is the JSON response body of BindingError, see
+// SetBindingErrorJSON.
+type jsonBindingError struct {
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"fields,omitempty"`
+	Status int          `json:"status"`
+}
+
+// renderBindingError responds the BindingError as JSON, in the form of
+// `{"error":"validation failed","fields":[...],"status":400}` if it's a
+// validation error, otherwise in the same form as c.JSONError.
+func renderBindingError(c *Context, e *BindingError) {
+	body := jsonBindingError{Error: e.Error(), Status: e.Status()}
+	if fields := e.Fields(); fields != nil {
+		body.Error, body.Fields = "validation failed", fields
+	}
+	c.AbortWithStatusJSON(e.Status(), body)
+}
+
+// jsonPanicError is the JSON response body of the panic in debug mode.
+type jsonPanicError struct {
+	Error  string `json:"error"`
+	Status int    `json:"status"`
+	Panic  string `json:"panic"`
+	Stack  string `json:"stack"`
+}
+
+// renderPanic responds the panic value with the stack trace captured when it
+// was recovered, as JSON if accepts is "application/json", otherwise HTML.
+// It's only used in debug mode, see defaultErrorHandler.
+func renderPanic(c *Context, status int, text, accepts string, v interface{}) {
+	panicText, stack := fmt.Sprint(v), string(c.panicStack)
+	if accepts == "application/json" {
+		c.AbortWithStatusJSON(status, jsonPanicError{text, status, panicText, stack})
+		return
+	}
+
+	c.Type("html")
+	c.Status(status).Send("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n" +
+		"<title>" + html.EscapeString(text) + "</title>\n</head>\n<body>\n" +
+		"<h1>panic: " + html.EscapeString(panicText) + "</h1>\n" +
+		"<pre>" + html.EscapeString(stack) + "</pre>\n</body>\n</html>\n")
+}
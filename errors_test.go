@@ -0,0 +1,157 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package soon
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewError(t *testing.T) {
+	tests := []struct {
+		status   int
+		msg      string
+		expected string
+	}{
+		{403, "forbidden", "forbidden"},
+		{403, "", http.StatusText(403)},
+		{422, "invalid name", "invalid name"},
+	}
+
+	for _, tt := range tests {
+		err := NewError(tt.status, tt.msg)
+		assert.Equal(t, tt.status, err.Status())
+		assert.Equal(t, tt.expected, err.Error())
+	}
+}
+
+func TestNotAcceptableError(t *testing.T) {
+	err := &NotAcceptableError{Offered: []string{"json", "html"}}
+	assert.Equal(t, http.StatusNotAcceptable, err.Status())
+	assert.Equal(t, http.StatusText(http.StatusNotAcceptable), err.Error())
+}
+
+func TestSetNotAcceptableHandler(t *testing.T) {
+	newRouter := func() *Router {
+		router := NewRouter()
+		router.GET("/format", func(c *Context) {
+			c.Format(map[string]Handle{
+				"text/html":        func(c *Context) { c.Html("<p>html</p>") },
+				"application/json": func(c *Context) { c.Json("json") },
+			})
+		})
+		router.GET("/negotiate", func(c *Context) {
+			c.Negotiate(NegotiateConfig{Offered: []string{"json", "xml"}, Data: "data"})
+		})
+		return router
+	}
+
+	tests := []struct {
+		path         string
+		expectedBody string
+	}{
+		{"/format", `{"available":["application/json","text/html"]}`},
+		{"/negotiate", `{"available":["json","xml"]}`},
+	}
+
+	t.Run("handler", func(t *testing.T) {
+		SetNotAcceptableHandler(func(c *Context, offered []string) {
+			c.Json(map[string][]string{"available": offered})
+		})
+		defer SetNotAcceptableHandler(nil)
+
+		router := newRouter()
+		for _, tt := range tests {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Accept", "image/png")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusNotAcceptable, w.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(w.Body.String()))
+		}
+	})
+
+	t.Run("error handler", func(t *testing.T) {
+		router := newRouter()
+		router.Use(func(v interface{}, c *Context) {
+			if err, ok := v.(*NotAcceptableError); ok {
+				c.Status(err.Status())
+				c.Json(map[string][]string{"available": err.Offered})
+				return
+			}
+			c.Next(v)
+		})
+		for _, tt := range tests {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Header.Set("Accept", "image/png")
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, http.StatusNotAcceptable, w.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(w.Body.String()))
+		}
+	})
+
+	t.Run("default", func(t *testing.T) {
+		router := newRouter()
+		req := httptest.NewRequest(http.MethodGet, "/format", nil)
+		req.Header.Set("Accept", "image/png")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusNotAcceptable, w.Code)
+		assert.Equal(t, http.StatusText(http.StatusNotAcceptable), strings.TrimSpace(w.Body.String()))
+	})
+}
+
+func TestSetBindingErrorJSON(t *testing.T) {
+	type user struct {
+		Name string `json:"name" validate:"required"`
+		Age  int    `json:"age" validate:"min=18"`
+	}
+
+	router := NewRouter()
+	router.POST("/", func(c *Context) {
+		var u user
+		c.MustBindJSON(&u)
+		c.Send(u.Name)
+	})
+
+	tests := []struct {
+		enabled      bool
+		accept       string
+		body         string
+		expectedType string
+		expectedBody string
+	}{
+		{
+			true, "application/json", `{"age":1}`, jsonType,
+			`{"error":"validation failed","fields":[` +
+				`{"field":"Name","tag":"required","message":"Field validation for 'Name' failed on the 'required' tag"},` +
+				`{"field":"Age","tag":"min","param":"18","message":"Field validation for 'Age' failed on the 'min' tag"}` +
+				`],"status":400}`,
+		},
+		{true, "application/json", `{`, jsonType, `{"error":"unexpected EOF","status":400}`},
+		{true, "text/plain", `{"age":1}`, "text/plain; charset=utf-8", ""},
+		{false, "application/json", `{"age":1}`, jsonType, ""},
+	}
+
+	defer SetBindingErrorJSON(false)
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			SetBindingErrorJSON(tt.enabled)
+			w := Test(router).Post("/").Set("Accept", tt.accept).Send(tt.body).Do()
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Equal(t, tt.expectedType, w.Header().Get("Content-Type"))
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, strings.TrimSpace(w.Body.String()))
+			} else {
+				assert.NotContains(t, w.Body.String(), "fields")
+			}
+		})
+	}
+}
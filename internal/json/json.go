@@ -0,0 +1,99 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+// Package json is the JSON codec indirection used by renderers and bindings,
+// it uses encoding/json by default and can be replaced by SetCodec.
+package json
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// Encoder writes JSON values to an output stream.
+type Encoder interface {
+	Encode(v interface{}) error
+	SetEscapeHTML(on bool)
+	SetIndent(prefix, indent string)
+}
+
+// Decoder reads and decodes JSON values from an input stream.
+type Decoder interface {
+	Decode(v interface{}) error
+	UseNumber()
+	DisallowUnknownFields()
+}
+
+// Codec is the JSON implementation, such as encoding/json, jsoniter or go-json.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	NewEncoder(w io.Writer) Encoder
+	NewDecoder(r io.Reader) Decoder
+}
+
+type stdCodec struct{}
+
+func (stdCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (stdCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (stdCodec) NewEncoder(w io.Writer) Encoder {
+	return json.NewEncoder(w)
+}
+
+func (stdCodec) NewDecoder(r io.Reader) Decoder {
+	return json.NewDecoder(r)
+}
+
+// Std is the Codec implemented by encoding/json.
+var Std Codec = stdCodec{}
+
+var codec = Std
+
+// SetCodec sets the Codec used by renderers and bindings, nil restores Std.
+func SetCodec(c Codec) {
+	if c == nil {
+		c = Std
+	}
+	codec = c
+}
+
+// GetCodec returns the Codec currently in use.
+func GetCodec() Codec {
+	return codec
+}
+
+// Or returns c, or the Codec currently in use if c is nil.
+func Or(c Codec) Codec {
+	if c == nil {
+		return codec
+	}
+	return c
+}
+
+// Marshal returns the JSON encoding of v.
+func Marshal(v interface{}) ([]byte, error) {
+	return codec.Marshal(v)
+}
+
+// Unmarshal parses the JSON-encoded data and stores the result in the value
+// pointed to by v.
+func Unmarshal(data []byte, v interface{}) error {
+	return codec.Unmarshal(data, v)
+}
+
+// NewEncoder returns a new encoder that writes to w.
+func NewEncoder(w io.Writer) Encoder {
+	return codec.NewEncoder(w)
+}
+
+// NewDecoder returns a new decoder that reads from r.
+func NewDecoder(r io.Reader) Decoder {
+	return codec.NewDecoder(r)
+}
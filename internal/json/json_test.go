@@ -0,0 +1,78 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package json
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type upperCodec struct {
+	Codec
+}
+
+func (c upperCodec) Marshal(v interface{}) ([]byte, error) {
+	bs, err := c.Codec.Marshal(v)
+	return bytes.ToUpper(bs), err
+}
+
+func (c upperCodec) NewEncoder(w io.Writer) Encoder {
+	return c.Codec.NewEncoder(w)
+}
+
+func TestSetCodec(t *testing.T) {
+	assert.Equal(t, Std, GetCodec())
+
+	codec := upperCodec{Std}
+	SetCodec(codec)
+	assert.Equal(t, codec, GetCodec())
+	bs, err := Marshal(map[string]string{"foo": "bar"})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"FOO":"BAR"}`, string(bs))
+
+	SetCodec(nil)
+	assert.Equal(t, Std, GetCodec())
+	bs, err = Marshal(map[string]string{"foo": "bar"})
+	assert.Nil(t, err)
+	assert.Equal(t, `{"foo":"bar"}`, string(bs))
+}
+
+func TestOr(t *testing.T) {
+	assert.Equal(t, Std, Or(nil))
+	codec := upperCodec{Std}
+	assert.Equal(t, codec, Or(codec))
+
+	SetCodec(codec)
+	defer SetCodec(nil)
+	assert.Equal(t, codec, Or(nil))
+}
+
+func TestStd(t *testing.T) {
+	var v struct {
+		Foo interface{} `json:"foo"`
+	}
+	assert.Nil(t, Unmarshal([]byte(`{"foo": 1}`), &v))
+	assert.Equal(t, float64(1), v.Foo)
+
+	decoder := NewDecoder(strings.NewReader(`{"foo": 1}`))
+	decoder.UseNumber()
+	assert.Nil(t, decoder.Decode(&v))
+	assert.Equal(t, "1", v.Foo.(interface{ String() string }).String())
+
+	decoder = NewDecoder(strings.NewReader(`{"bar": 1}`))
+	decoder.DisallowUnknownFields()
+	assert.NotNil(t, decoder.Decode(&v))
+
+	var buf bytes.Buffer
+	encoder := NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	assert.Nil(t, encoder.Encode(map[string]string{"foo": "<b>"}))
+	assert.Equal(t, "{\n  \"foo\": \"<b>\"\n}\n", buf.String())
+}
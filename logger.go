@@ -0,0 +1,41 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package soon
+
+import (
+	"log"
+)
+
+// LoggerFactory creates the logger returned by c.Logger.
+type LoggerFactory func(c *Context) *log.Logger
+
+var loggerFactory LoggerFactory = defaultLoggerFactory
+
+// defaultLoggerFactory creates a logger writing to DefaultWriter, the request
+// ID set by RequestID middleware is used as the prefix if any.
+func defaultLoggerFactory(c *Context) *log.Logger {
+	prefix := ""
+	if id := c.RequestID(); id != "" {
+		prefix = "[" + id + "] "
+	}
+	return log.New(DefaultWriter, prefix, log.LstdFlags)
+}
+
+// Logger returns a logger scoped to the request, which is created by the
+// factory set by SetLoggerFactory.
+func (c *Context) Logger() *log.Logger {
+	return loggerFactory(c)
+}
+
+// RequestID returns the request ID set by RequestID middleware, or an empty
+// string if it's not set.
+func (c *Context) RequestID() string {
+	if v, ok := c.GetLocal(RequestIDKey); ok {
+		if id, ok := v.(string); ok {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package soon
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext_Logger(t *testing.T) {
+	defaultWriter := DefaultWriter
+	defer func() {
+		DefaultWriter = defaultWriter
+	}()
+	var buf bytes.Buffer
+	DefaultWriter = &buf
+
+	router := NewRouter()
+	router.Use(RequestID())
+	router.GET("/", func(c *Context) {
+		c.Logger().Print("hello")
+		c.SendStatus(http.StatusNoContent)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "abc")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	assert.True(t, strings.HasPrefix(buf.String(), "[abc] "))
+	assert.True(t, strings.HasSuffix(buf.String(), " hello\n"))
+
+	buf.Reset()
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	id := w.Header().Get(RequestIDHeader)
+	assert.Len(t, id, 32)
+	assert.True(t, strings.HasPrefix(buf.String(), "["+id+"] "))
+
+	buf.Reset()
+	c := NewContext(httptest.NewRequest(http.MethodGet, "/", nil), httptest.NewRecorder())
+	c.Logger().Print("hello")
+	assert.False(t, strings.HasPrefix(buf.String(), "["))
+	assert.True(t, strings.HasSuffix(buf.String(), " hello\n"))
+}
+
+func TestSetLoggerFactory(t *testing.T) {
+	defer SetLoggerFactory(nil)
+
+	var buf bytes.Buffer
+	SetLoggerFactory(func(c *Context) *log.Logger {
+		return log.New(&buf, c.RequestID()+": ", 0)
+	})
+
+	router := NewRouter()
+	router.Use(RequestID())
+	router.GET("/", func(c *Context) {
+		c.Logger().Print("hello")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "abc")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "abc: hello\n", buf.String())
+
+	SetLoggerFactory(nil)
+	assert.NotNil(t, loggerFactory)
+}
@@ -0,0 +1,141 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package soon
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are the upper bounds of the latency histogram of
+// NewMetricsRecorder.
+var DefaultLatencyBuckets = []time.Duration{
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// MetricsRecorder counts the requests by status class, and the latencies in
+// a histogram, which are recorded by the Metrics middleware. It's safe for
+// concurrent use.
+type MetricsRecorder struct {
+	mu       sync.Mutex
+	buckets  []time.Duration
+	requests uint64
+	statuses map[string]uint64
+	counts   []uint64
+	sum      time.Duration
+}
+
+// MetricsSnapshot is a point-in-time copy of the numbers of MetricsRecorder.
+type MetricsSnapshot struct {
+	// Requests is the number of all the requests.
+	Requests uint64 `json:"requests"`
+
+	// Statuses is the number of requests by status class, such as "2xx".
+	Statuses map[string]uint64 `json:"statuses"`
+
+	// Latency is the histogram of latencies.
+	Latency LatencyHistogram `json:"latency"`
+}
+
+// LatencyHistogram is the histogram of latencies, the counts of buckets are
+// cumulative, the last bucket is "+Inf" which equals to Count.
+type LatencyHistogram struct {
+	Buckets []LatencyBucket `json:"buckets"`
+	Count   uint64          `json:"count"`
+
+	// Sum is the total latency in seconds.
+	Sum float64 `json:"sum"`
+}
+
+// LatencyBucket is the number of requests whose latency is less than or
+// equal to Le, such as "100ms".
+type LatencyBucket struct {
+	Le    string `json:"le"`
+	Count uint64 `json:"count"`
+}
+
+// NewMetricsRecorder returns a MetricsRecorder, the latency histogram uses
+// the given upper bounds in ascending order, which defaults to
+// DefaultLatencyBuckets.
+func NewMetricsRecorder(buckets ...time.Duration) *MetricsRecorder {
+	if len(buckets) == 0 {
+		buckets = DefaultLatencyBuckets
+	}
+	return &MetricsRecorder{
+		buckets:  append([]time.Duration(nil), buckets...),
+		statuses: make(map[string]uint64),
+		counts:   make([]uint64, len(buckets)),
+	}
+}
+
+// Observe records a request with the given status and latency.
+func (m *MetricsRecorder) Observe(status int, latency time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests++
+	m.statuses[strconv.Itoa(status/100)+"xx"]++
+	m.sum += latency
+	for i, le := range m.buckets {
+		if latency <= le {
+			m.counts[i]++
+		}
+	}
+}
+
+// Snapshot returns the numbers recorded so far.
+func (m *MetricsRecorder) Snapshot() MetricsSnapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := MetricsSnapshot{
+		Requests: m.requests,
+		Statuses: make(map[string]uint64, len(m.statuses)),
+		Latency: LatencyHistogram{
+			Buckets: make([]LatencyBucket, 0, len(m.buckets)+1),
+			Count:   m.requests,
+			Sum:     m.sum.Seconds(),
+		},
+	}
+	for k, v := range m.statuses {
+		s.Statuses[k] = v
+	}
+	for i, le := range m.buckets {
+		s.Latency.Buckets = append(s.Latency.Buckets, LatencyBucket{le.String(), m.counts[i]})
+	}
+	s.Latency.Buckets = append(s.Latency.Buckets, LatencyBucket{"+Inf", m.requests})
+	return s
+}
+
+// Handle responds the snapshot as JSON, so that it can be mounted as a
+// route, e.g.
+//
+//	metrics := soon.NewMetricsRecorder()
+//	router.Use(soon.Metrics(metrics))
+//	router.GET("/metrics", metrics.Handle)
+func (m *MetricsRecorder) Handle(c *Context) {
+	c.Json(m.Snapshot())
+}
+
+// Metrics is a built-in middleware function in Soon. It records the status
+// and latency of every request into m, which can be read by m.Snapshot or
+// m.Handle.
+func Metrics(m *MetricsRecorder) Handle {
+	return func(c *Context) {
+		start := time.Now()
+		c.Next()
+		m.Observe(c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package soon
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetricsRecorder_Observe(t *testing.T) {
+	m := NewMetricsRecorder(10*time.Millisecond, 100*time.Millisecond)
+	m.Observe(200, 5*time.Millisecond)
+	m.Observe(201, 50*time.Millisecond)
+	m.Observe(404, 10*time.Millisecond)
+	m.Observe(500, time.Second)
+
+	s := m.Snapshot()
+	assert.Equal(t, uint64(4), s.Requests)
+	assert.Equal(t, map[string]uint64{"2xx": 2, "4xx": 1, "5xx": 1}, s.Statuses)
+	assert.Equal(t, []LatencyBucket{{"10ms", 2}, {"100ms", 3}, {"+Inf", 4}}, s.Latency.Buckets)
+	assert.Equal(t, uint64(4), s.Latency.Count)
+	assert.InDelta(t, 1.065, s.Latency.Sum, 1e-9)
+
+	// the snapshot isn't changed by the later requests
+	m.Observe(200, time.Millisecond)
+	assert.Equal(t, uint64(2), s.Statuses["2xx"])
+	assert.Equal(t, uint64(3), m.Snapshot().Statuses["2xx"])
+
+	assert.Len(t, NewMetricsRecorder().Snapshot().Latency.Buckets, len(DefaultLatencyBuckets)+1)
+}
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetricsRecorder()
+	router := NewRouter()
+	router.Use(Metrics(metrics))
+	router.GET("/", func(c *Context) {
+		c.Send("foo")
+	})
+	router.GET("/error", func(c *Context) {
+		panic(errors.New("bar"))
+	})
+	router.GET("/metrics", metrics.Handle)
+
+	Test(router).Get("/").Do()
+	Test(router).Get("/").Do()
+	Test(router).Get("/error").Do()
+	Test(router).Get("/not-found").Do()
+
+	w := Test(router).Get("/metrics").Do()
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var s MetricsSnapshot
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &s))
+	assert.Equal(t, uint64(4), s.Requests)
+	assert.Equal(t, map[string]uint64{"2xx": 2, "4xx": 1, "5xx": 1}, s.Statuses)
+	assert.Equal(t, uint64(4), s.Latency.Count)
+	assert.Equal(t, LatencyBucket{"+Inf", 4}, s.Latency.Buckets[len(s.Latency.Buckets)-1])
+
+	// the request of metrics itself is recorded after it's responded
+	assert.Equal(t, uint64(5), metrics.Snapshot().Requests)
+}
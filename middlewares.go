@@ -5,13 +5,23 @@
 package soon
 
 import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
+	"html/template"
+	"net/http"
+	"path"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/dlclark/regexp2"
 	"github.com/fatih/color"
 
+	"github.com/soongo/soon/internal"
 	"github.com/soongo/soon/renderer"
 	"github.com/soongo/soon/util"
 
@@ -25,21 +35,37 @@ import (
 // the provided root directory. When a file is not found, instead of sending a
 // 404 response, it instead calls next() to move on to the next middleware,
 // allowing for stacking and fall-backs.
+//
+// A relative root is resolved against the directory of executable once, and
+// the requests are passed to the error handlers if it fails.
 func Static(root string, options ...renderer.FileOptions) Handle {
-	return func(c *Context) {
-		if !filepath.IsAbs(root) {
-			dirname, err := util.Dirname()
-			if err != nil {
-				panic(err)
-			}
-
+	var rootErr error
+	if !filepath.IsAbs(root) {
+		if dirname, err := util.Dirname(); err != nil {
+			rootErr = err
+		} else {
 			root = filepath.Join(dirname, root)
 		}
+	}
+	root = filepath.Clean(root)
 
-		path := strings.TrimPrefix(c.Request.Path, c.Request.BaseUrl)
-		absPath := pathToRegexp.DecodeURIComponent(filepath.Join(root, path))
+	return func(c *Context) {
+		if rootErr != nil {
+			c.Next(rootErr)
+			return
+		}
+
+		// the path is cleaned after decoding, so that the encoded dot segments,
+		// such as `/%2e%2e/`, can't escape from root
+		relPath, err := util.DecodeURIComponent(c.Request.RelativePath())
+		if err != nil {
+			c.Next()
+			return
+		}
+		relPath = path.Clean("/" + relPath)
+		absPath := filepath.Join(root, filepath.FromSlash(relPath))
 
-		if !util.IsFileExist(absPath) {
+		if !isPathInRoot(absPath, root) || !util.IsFileExist(absPath) {
 			c.Next()
 			return
 		}
@@ -48,6 +74,11 @@ func Static(root string, options ...renderer.FileOptions) Handle {
 	}
 }
 
+// isPathInRoot reports whether the cleaned absPath is root or inside it.
+func isPathInRoot(absPath, root string) bool {
+	return absPath == root || strings.HasPrefix(absPath, strings.TrimSuffix(root, string(filepath.Separator))+string(filepath.Separator))
+}
+
 // DevLog is a built-in middleware function in Soon.
 // It just print simple log for development.
 // For production environment, you can use `https://github.com/sirupsen/logrus`
@@ -71,3 +102,269 @@ func DevLog() Handle {
 		fmt.Printf("%s %s %s %s - %d\n", method, path, statusText, cost, size)
 	}
 }
+
+// RequestIDHeader is the header carrying the request ID.
+const RequestIDHeader = "X-Request-Id"
+
+// RequestIDKey is the key of locals to store the request ID.
+const RequestIDKey = "requestID"
+
+// requestIDRegexp matches the request ID accepted from client, so that the
+// logs can't be forged by the line breaks or flooded by the long ID.
+var requestIDRegexp = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// RequestID is a built-in middleware function in Soon. It reuses the request ID
+// of X-Request-Id header, or generates a random one if it's missing or invalid,
+// and then stores it in locals and sets it to the response header. The valid
+// request ID consists of at most 64 letters, digits, `.`, `_` or `-`. See
+// c.RequestID and c.Logger.
+func RequestID() Handle {
+	return func(c *Context) {
+		id := c.Request.Get(RequestIDHeader)
+		if !requestIDRegexp.MatchString(id) {
+			id = newRequestID()
+		}
+		c.SetLocal(RequestIDKey, id)
+		c.Set(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// newRequestID returns a random hex string of 32 characters.
+func newRequestID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// DefaultHealthCheckPath is the default path of HealthCheck.
+const DefaultHealthCheckPath = "/healthz"
+
+// HealthCheck is a built-in middleware function in Soon. It responds the GET
+// and HEAD requests of path, which defaults to DefaultHealthCheckPath, with
+// 200 and `{"status":"ok"}` directly, the rest of the chain isn't called.
+func HealthCheck(path string) Handle {
+	if path == "" {
+		path = DefaultHealthCheckPath
+	}
+	path = util.AddPrefixSlash(path)
+
+	return func(c *Context) {
+		method := c.Request.Method
+		if (method != http.MethodGet && method != http.MethodHead) || c.Request.RelativePath() != path {
+			c.Next()
+			return
+		}
+		c.Json(map[string]string{"status": "ok"})
+	}
+}
+
+// CacheBody is a built-in middleware function in Soon. It reads the request
+// body into the context, so that c.BindWith and its shortcuts, e.g.
+// c.BindJSON, can be called more than once by both middlewares and handlers,
+// which is the same as c.BindBodyWith. The reading error is passed to the
+// error handlers.
+func CacheBody() Handle {
+	return func(c *Context) {
+		if err := c.cacheBody(); err != nil {
+			c.Next(err)
+			return
+		}
+		c.Next()
+	}
+}
+
+// When wraps the handle, which only runs if predicate returns true, otherwise
+// the request is passed to the next handler, for example:
+//
+//	router.Use(soon.When(func(c *soon.Context) bool {
+//		return c.Request.Method != http.MethodGet
+//	}, csrf))
+func When(predicate func(*Context) bool, handle Handle) Handle {
+	return func(c *Context) {
+		if !predicate(c) {
+			c.Next()
+			return
+		}
+		handle(c)
+	}
+}
+
+// Unless wraps the handle, which is skipped if the request path matches any of
+// paths, such as the authentication exemptions. The paths are route patterns,
+// such as "/health" or "/public/:file", and are matched case-insensitively as
+// a whole, for example:
+//
+//	router.Use(soon.Unless(auth, "/health", "/login"))
+func Unless(handle Handle, paths ...string) Handle {
+	regexps := make([]*regexp2.Regexp, len(paths))
+	for i, p := range paths {
+		route := expandNamedWildcard(util.AddPrefixSlash(p))
+		regexps[i] = pathToRegexp.Must(pathToRegexp.PathToRegexp(route, nil, nil))
+	}
+
+	return When(func(c *Context) bool {
+		for _, re := range regexps {
+			if ok, err := re.MatchString(c.Request.URL.Path); err == nil && ok {
+				return false
+			}
+		}
+		return true
+	}, handle)
+}
+
+// SecureHeadersOptions contains options for SecureHeaders middleware. Every
+// header is enabled by default, set the corresponding Disable field to omit it.
+type SecureHeadersOptions struct {
+	// FrameOptions is the value of X-Frame-Options header,
+	// defaults to "SAMEORIGIN".
+	FrameOptions string
+
+	// ReferrerPolicy is the value of Referrer-Policy header,
+	// defaults to "no-referrer".
+	ReferrerPolicy string
+
+	// HSTSMaxAge is the max-age in seconds of Strict-Transport-Security
+	// header, defaults to 180 days.
+	HSTSMaxAge int
+
+	// HSTSIncludeSubDomains appends includeSubDomains directive to
+	// Strict-Transport-Security header.
+	HSTSIncludeSubDomains bool
+
+	// ContentSecurityPolicy is the value of Content-Security-Policy header,
+	// the header is omitted if it's empty.
+	ContentSecurityPolicy string
+
+	DisableNoSniff        bool
+	DisableFrameOptions   bool
+	DisableReferrerPolicy bool
+	DisableHSTS           bool
+}
+
+// SecureHeaders is a built-in middleware function in Soon. It sets common
+// security related response headers, such as X-Content-Type-Options,
+// X-Frame-Options, Referrer-Policy and Strict-Transport-Security.
+func SecureHeaders(options ...SecureHeadersOptions) Handle {
+	var opts SecureHeadersOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	if opts.FrameOptions == "" {
+		opts.FrameOptions = "SAMEORIGIN"
+	}
+	if opts.ReferrerPolicy == "" {
+		opts.ReferrerPolicy = "no-referrer"
+	}
+	if opts.HSTSMaxAge <= 0 {
+		opts.HSTSMaxAge = 15552000
+	}
+
+	hsts := "max-age=" + strconv.Itoa(opts.HSTSMaxAge)
+	if opts.HSTSIncludeSubDomains {
+		hsts += "; includeSubDomains"
+	}
+
+	return func(c *Context) {
+		header := c.Writer.Header()
+		if !opts.DisableNoSniff {
+			header.Set("X-Content-Type-Options", "nosniff")
+		}
+		if !opts.DisableFrameOptions {
+			header.Set("X-Frame-Options", opts.FrameOptions)
+		}
+		if !opts.DisableReferrerPolicy {
+			header.Set("Referrer-Policy", opts.ReferrerPolicy)
+		}
+		if !opts.DisableHSTS {
+			header.Set("Strict-Transport-Security", hsts)
+		}
+		if opts.ContentSecurityPolicy != "" {
+			header.Set("Content-Security-Policy", opts.ContentSecurityPolicy)
+		}
+		c.Next()
+	}
+}
+
+// ErrorPage contains the status code and message of an error page.
+type ErrorPage struct {
+	Status  int    `json:"status"`
+	Message string `json:"message"`
+}
+
+// ErrorPagesOptions contains options for ErrorPages middleware.
+type ErrorPagesOptions struct {
+	// Template to render HTML error pages, it's executed with an ErrorPage
+	// object. A simple built-in template will be used if it's nil.
+	Template *template.Template
+}
+
+var defaultErrorPageTemplate = template.Must(template.New("error").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Status}} {{.Message}}</title>
+</head>
+<body>
+<h1>{{.Status}}</h1>
+<p>{{.Message}}</p>
+</body>
+</html>
+`))
+
+// ErrorPages is a built-in middleware function in Soon. It returns a router
+// which responds the not found requests and errors with an HTML page for
+// browsers, or a JSON body for API clients, based on the request's Accept
+// header. Like the default error handler, the message of the panic resulting
+// in a 5xx status is the status text only outside debug mode.
+//
+// The router should be used after all other routes, so that it is able to
+// catch the unmatched requests:
+//
+//	app.Use(soon.ErrorPages(soon.ErrorPagesOptions{Template: tmpl}))
+func ErrorPages(options ...ErrorPagesOptions) *Router {
+	var opts ErrorPagesOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	tmpl := opts.Template
+	if tmpl == nil {
+		tmpl = defaultErrorPageTemplate
+	}
+
+	router := NewRouter()
+	router.Use(func(c *Context) {
+		renderErrorPage(c, tmpl, internal.ErrNotFound)
+	})
+	router.Use(func(v interface{}, c *Context) {
+		renderErrorPage(c, tmpl, v)
+	})
+	return router
+}
+
+func renderErrorPage(c *Context, tmpl *template.Template, v interface{}) {
+	if c.finished {
+		return
+	}
+
+	status, text := resolvePublicError(v, c)
+	page := ErrorPage{Status: status, Message: text}
+	sendJSON := func(c *Context) {
+		c.Json(page)
+	}
+
+	c.Status(status)
+	c.Format(map[string]Handle{
+		"application/json": sendJSON,
+		"text/html": func(c *Context) {
+			var buf bytes.Buffer
+			if err := tmpl.Execute(&buf, page); err != nil {
+				panic(err)
+			}
+			c.Html(buf.String())
+		},
+		"default": sendJSON,
+	})
+}
@@ -0,0 +1,51 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build go1.16
+// +build go1.16
+
+package soon
+
+import (
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/soongo/soon/renderer"
+	"github.com/soongo/soon/util"
+)
+
+// StaticFS is similar with Static, but it serves static files from fsys, such
+// as embed.FS, which is useful for single-binary deployments. The root is the
+// slash-separated directory in fsys, such as "public", or "." for the whole fsys.
+func StaticFS(root string, fsys fs.FS, options ...renderer.FileOptions) Handle {
+	var opts renderer.FileOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	opts.Root = path.Clean("/" + strings.TrimSpace(root))
+
+	return func(c *Context) {
+		// the path is cleaned after decoding, so that it can't escape from root
+		relPath, err := util.DecodeURIComponent(c.Request.RelativePath())
+		if err != nil {
+			c.Next()
+			return
+		}
+		relPath = path.Clean("/" + relPath)
+		name := strings.TrimPrefix(path.Join(opts.Root, relPath), "/")
+		if name == "" {
+			name = "."
+		}
+
+		if _, err := fs.Stat(fsys, name); err != nil {
+			c.Next()
+			return
+		}
+
+		fileOpts := opts
+		fileOpts.ETagDisabled = fileOpts.ETagDisabled || c.etagDisabled()
+		c.Render(&renderer.FileFS{FS: fsys, FilePath: relPath, Options: fileOpts})
+	}
+}
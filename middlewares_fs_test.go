@@ -0,0 +1,84 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build go1.16
+// +build go1.16
+
+package soon
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/soongo/soon/renderer"
+)
+
+// the module targets go1.14, which doesn't allow go:embed, so os.DirFS is
+// used as the fs.FS instead of embed.FS
+var staticTestFS = os.DirFS(".")
+
+func TestStaticFS(t *testing.T) {
+	readme, err := ioutil.ReadFile("README.md")
+	require.NoError(t, err)
+	fileGo, err := ioutil.ReadFile("renderer/file.go")
+	require.NoError(t, err)
+
+	tests := []struct {
+		route               string
+		root                string
+		options             []renderer.FileOptions
+		path                string
+		header              map[string]string
+		expectedCode        int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{"/", ".", nil, "/README.md", nil, 200, "text/markdown; charset=utf-8", string(readme)},
+		{"/", "", nil, "/renderer/file.go", nil, 200, "application/octet-stream", string(fileGo)},
+		{"/", "renderer", nil, "/file.go", nil, 200, "application/octet-stream", string(fileGo)},
+		{"/public", "renderer", nil, "/public/file.go", nil, 200, "application/octet-stream", string(fileGo)},
+		{"/", "renderer", nil, "/README.md", nil, 404, "text/plain; charset=utf-8", body404},
+		{"/", "renderer", nil, "/%2e%2e/README.md", nil, 404, "text/plain; charset=utf-8", body404},
+		{"/", ".", nil, "/not_exist.txt", nil, 404, "text/plain; charset=utf-8", body404},
+		{"/", ".", nil, "/README.md", map[string]string{"Range": "bytes=0-5"}, 206, "text/markdown; charset=utf-8", string(readme[:6])},
+		{
+			"/",
+			"renderer",
+			[]renderer.FileOptions{{DirectoryListing: true}},
+			"/",
+			nil,
+			200,
+			"text/html; charset=utf-8",
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			router := NewRouter()
+			router.Use(tt.route, StaticFS(tt.root, staticTestFS, tt.options...))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.expectedContentType, w.Header().Get("Content-Type"))
+			if tt.expectedBody != "" {
+				assert.Equal(t, strings.TrimSpace(tt.expectedBody), strings.TrimSpace(w.Body.String()))
+			} else {
+				assert.Contains(t, w.Body.String(), `<a href="/file.go">file.go</a>`)
+			}
+		})
+	}
+}
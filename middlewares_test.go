@@ -7,6 +7,7 @@ package soon
 import (
 	"errors"
 	"fmt"
+	"html/template"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -16,6 +17,8 @@ import (
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/soongo/soon/binding"
+	"github.com/soongo/soon/internal"
 	"github.com/soongo/soon/renderer"
 	"github.com/soongo/soon/util"
 
@@ -70,6 +73,19 @@ func TestStatic(t *testing.T) {
 			nil,
 			nil,
 		},
+		{
+			"",
+			pwd,
+			renderer.FileOptions{},
+			"/100%25",
+			nil,
+			404,
+			"text/plain; charset=utf-8",
+			"",
+			body404,
+			nil,
+			nil,
+		},
 		{
 			"",
 			"./",
@@ -141,7 +157,7 @@ func TestStatic(t *testing.T) {
 			renderer.FileOptions{},
 			"/README.md",
 			http.Header{"Range": []string{"bytes=10-20,21-30"}},
-			200,
+			206,
 			"text/markdown; charset=utf-8",
 			filepath.Join(pwd, "README.md"),
 			"",
@@ -245,6 +261,11 @@ func TestStatic(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run("", func(t *testing.T) {
+			defer func(err error) {
+				util.InitDirnameErr = err
+			}(util.InitDirnameErr)
+			util.InitDirnameErr = tt.initDirnameErr
+
 			router := NewRouter()
 			if tt.route == "" {
 				router.Use(Static(tt.root, tt.options))
@@ -254,8 +275,6 @@ func TestStatic(t *testing.T) {
 			server := httptest.NewServer(router)
 			defer server.Close()
 
-			util.InitDirnameErr = tt.initDirnameErr
-
 			code, header, body, err := request("GET", server.URL+tt.path, tt.header)
 			assert.Nil(t, err)
 			assert.Equal(t, tt.expectedCode, code)
@@ -270,6 +289,83 @@ func TestStatic(t *testing.T) {
 	}
 }
 
+func TestStatic_DirnameErr(t *testing.T) {
+	defer func(err error) {
+		util.InitDirnameErr = err
+	}(util.InitDirnameErr)
+	util.InitDirnameErr = initDirnameErr
+
+	var errs []interface{}
+	router := NewRouter()
+	router.Use(Static("."))
+	router.Use(func(v interface{}, c *Context) {
+		errs = append(errs, v)
+		c.Next(v)
+	})
+
+	// the root is resolved once, so the error is kept even if it's gone
+	util.InitDirnameErr = nil
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest("GET", "/README.md", nil))
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Equal(t, initDirnameErr.Error(), strings.TrimSpace(w.Body.String()))
+	}
+	assert.Equal(t, []interface{}{initDirnameErr, initDirnameErr}, errs)
+}
+
+func TestStatic_PathTraversal(t *testing.T) {
+	pwd, err := os.Getwd()
+	require.NoError(t, err)
+
+	router := NewRouter()
+	router.Use(Static(filepath.Join(pwd, "renderer")))
+
+	tests := []struct {
+		path         string
+		expectedCode int
+	}{
+		{"/file.go", 200},
+		{"/%2e%2e/README.md", 404},
+		{"/%2e%2e/%2e%2e/etc/passwd", 404},
+		{"/..%2fREADME.md", 404},
+		{"/%2E%2E%2F%2E%2E%2Fetc%2Fpasswd", 404},
+		{"/foo/%2e%2e/file.go", 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.NotContains(t, w.Body.String(), "root:")
+			assert.NotContains(t, w.Body.String(), "# Soon")
+		})
+	}
+
+	assert.True(t, isPathInRoot("/foo", "/foo"))
+	assert.True(t, isPathInRoot("/foo/bar", "/foo"))
+	assert.True(t, isPathInRoot("/foo", "/"))
+	assert.False(t, isPathInRoot("/foobar", "/foo"))
+	assert.False(t, isPathInRoot("/etc/passwd", "/foo"))
+}
+
+func TestStatic_DirectoryListing(t *testing.T) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+
+	router := NewRouter()
+	router.Use("/public", Static(pwd, renderer.FileOptions{DirectoryListing: true}))
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/public/renderer", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, htmlType, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), `<a href="/public/renderer/file.go">file.go</a>`)
+	assert.Contains(t, w.Body.String(), `<a href="/public/renderer/json.go">json.go</a>`)
+}
+
 func TestDevLog(t *testing.T) {
 	router := NewRouter()
 	router.Use(DevLog())
@@ -287,3 +383,365 @@ func TestDevLog(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, 500, code)
 }
+
+func TestRequestID(t *testing.T) {
+	router := NewRouter()
+	router.Use(RequestID())
+	router.GET("/", func(c *Context) {
+		c.Send(c.RequestID())
+	})
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	id := w.Header().Get(RequestIDHeader)
+	assert.Len(t, id, 32)
+	assert.Equal(t, id, w.Body.String())
+
+	w = httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.NotEqual(t, id, w.Header().Get(RequestIDHeader))
+
+	w = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "foo")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "foo", w.Header().Get(RequestIDHeader))
+	assert.Equal(t, "foo", w.Body.String())
+
+	// the invalid request ID is replaced
+	for _, invalid := range []string{"foo\r\nbar", "foo bar", strings.Repeat("a", 65)} {
+		w = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(RequestIDHeader, invalid)
+		router.ServeHTTP(w, req)
+		assert.Len(t, w.Header().Get(RequestIDHeader), 32)
+		assert.Equal(t, w.Header().Get(RequestIDHeader), w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(RequestIDHeader, "a.b_c-"+strings.Repeat("9", 58))
+	router.ServeHTTP(w, req)
+	assert.Equal(t, "a.b_c-"+strings.Repeat("9", 58), w.Header().Get(RequestIDHeader))
+}
+
+func TestHealthCheck(t *testing.T) {
+	router := NewRouter()
+	router.Use(HealthCheck(""))
+	router.Use(func(c *Context) {
+		c.SendStatus(http.StatusUnauthorized)
+	})
+
+	w := Test(router).Get("/healthz").Do()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, `{"status":"ok"}`, strings.TrimSpace(w.Body.String()))
+
+	w = Test(router).Head("/healthz").Do()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "", w.Body.String())
+
+	assert.Equal(t, http.StatusUnauthorized, Test(router).Post("/healthz").Do().Code)
+	assert.Equal(t, http.StatusUnauthorized, Test(router).Get("/healthz/foo").Do().Code)
+	assert.Equal(t, http.StatusUnauthorized, Test(router).Get("/").Do().Code)
+
+	router = NewRouter()
+	router.Use(HealthCheck("ping"))
+	assert.Equal(t, http.StatusOK, Test(router).Get("/ping").Do().Code)
+	assert.Equal(t, http.StatusNotFound, Test(router).Get("/healthz").Do().Code)
+}
+
+func TestCacheBody(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+	}
+
+	handle := func(c *Context) {
+		var u1, u2, u3 user
+		if err := c.BindJSON(&u1); err != nil {
+			c.Send(err.Error())
+			return
+		}
+		if err := c.BindJSON(&u2); err != nil {
+			c.Send(err.Error())
+			return
+		}
+		if err := c.BindBodyWith(&u3, binding.JSON); err != nil {
+			c.Send(err.Error())
+			return
+		}
+		c.Send(u1.Name + u2.Name + u3.Name)
+	}
+
+	router := NewRouter()
+	router.Use(CacheBody())
+	router.Use(func(c *Context) {
+		var u user
+		c.MustBindJSON(&u)
+		c.SetLocal("user", u.Name)
+		c.Next()
+	})
+	router.POST("/", handle)
+
+	w := Test(router).Post("/").Send(user{Name: "foo"}).Do()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "foofoofoo", w.Body.String())
+
+	router = NewRouter()
+	router.POST("/", handle)
+	w = Test(router).Post("/").Send(user{Name: "foo"}).Do()
+	assert.Equal(t, "EOF", w.Body.String())
+
+	router = NewRouter()
+	router.Use(CacheBody())
+	router.POST("/", handle)
+	w = Test(router).Post("/").Send(&ErrTooLargeReader{}).Do()
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestUnless(t *testing.T) {
+	auth := func(c *Context) {
+		if c.Request.Get("Authorization") == "" {
+			c.SendStatus(http.StatusUnauthorized)
+			return
+		}
+		c.Next()
+	}
+
+	router := NewRouter()
+	router.Use(Unless(auth, "/health", "public/:file", "/assets/*filepath"))
+	router.GET("/:name", func(c *Context) {
+		c.Send(c.Param("name"))
+	})
+	router.GET("/public/:file", func(c *Context) {
+		c.Send(c.Param("file"))
+	})
+	router.GET("/assets/*filepath", func(c *Context) {
+		c.Send(c.Param("filepath"))
+	})
+
+	tests := []struct {
+		path         string
+		auth         string
+		expectedCode int
+	}{
+		{"/health", "", 200},
+		{"/HEALTH", "", 200},
+		{"/health/", "", 200},
+		{"/public/foo.txt", "", 200},
+		{"/assets/css/app.css", "", 200},
+		{"/private", "", 401},
+		{"/private", "token", 200},
+		{"/healthz", "", 401},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req := Test(router).Get(tt.path)
+			if tt.auth != "" {
+				req.Set("Authorization", tt.auth)
+			}
+			assert.Equal(t, tt.expectedCode, req.Do().Code)
+		})
+	}
+}
+
+func TestWhen(t *testing.T) {
+	router := NewRouter()
+	router.Use(When(func(c *Context) bool {
+		return c.Request.Method == http.MethodPost
+	}, func(c *Context) {
+		c.Set("X-Checked", "true")
+		c.Next()
+	}))
+	router.ALL("/", func(c *Context) {
+		c.SendStatus(http.StatusNoContent)
+	})
+
+	w := Test(router).Post("/").Do()
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "true", w.Header().Get("X-Checked"))
+
+	w = Test(router).Get("/").Do()
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Equal(t, "", w.Header().Get("X-Checked"))
+}
+
+func TestSecureHeaders(t *testing.T) {
+	tests := []struct {
+		options  []SecureHeadersOptions
+		expected map[string]string
+	}{
+		{
+			nil,
+			map[string]string{
+				"X-Content-Type-Options":    "nosniff",
+				"X-Frame-Options":           "SAMEORIGIN",
+				"Referrer-Policy":           "no-referrer",
+				"Strict-Transport-Security": "max-age=15552000",
+				"Content-Security-Policy":   "",
+			},
+		},
+		{
+			[]SecureHeadersOptions{{
+				FrameOptions:          "DENY",
+				ReferrerPolicy:        "same-origin",
+				HSTSMaxAge:            60,
+				HSTSIncludeSubDomains: true,
+				ContentSecurityPolicy: "default-src 'self'",
+			}},
+			map[string]string{
+				"X-Content-Type-Options":    "nosniff",
+				"X-Frame-Options":           "DENY",
+				"Referrer-Policy":           "same-origin",
+				"Strict-Transport-Security": "max-age=60; includeSubDomains",
+				"Content-Security-Policy":   "default-src 'self'",
+			},
+		},
+		{
+			[]SecureHeadersOptions{{DisableNoSniff: true, DisableHSTS: true}},
+			map[string]string{
+				"X-Content-Type-Options":    "",
+				"X-Frame-Options":           "SAMEORIGIN",
+				"Referrer-Policy":           "no-referrer",
+				"Strict-Transport-Security": "",
+			},
+		},
+		{
+			[]SecureHeadersOptions{{DisableFrameOptions: true, DisableReferrerPolicy: true}},
+			map[string]string{
+				"X-Content-Type-Options":    "nosniff",
+				"X-Frame-Options":           "",
+				"Referrer-Policy":           "",
+				"Strict-Transport-Security": "max-age=15552000",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			router := NewRouter()
+			router.Use(SecureHeaders(tt.options...))
+			router.GET("/", func(c *Context) {
+				c.Send("ok")
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+			for k, v := range tt.expected {
+				assert.Equal(t, v, w.Header().Get(k), k)
+			}
+		})
+	}
+}
+
+func TestErrorPages(t *testing.T) {
+	tmpl := template.Must(template.New("error").Parse(
+		`<h1>Soon</h1><p>{{.Status}}: {{.Message}}</p>`))
+
+	tests := []struct {
+		path                string
+		accept              string
+		options             []ErrorPagesOptions
+		expectedCode        int
+		expectedContentType string
+		expectedBody        string
+	}{
+		{"/404", "text/html", nil, 404, htmlType, ""},
+		{
+			"/404",
+			"text/html",
+			[]ErrorPagesOptions{{Template: tmpl}},
+			404,
+			htmlType,
+			"<h1>Soon</h1><p>404: Not Found</p>",
+		},
+		{"/404", "application/json", nil, 404, jsonType, `{"status":404,"message":"Not Found"}`},
+		{"/404", "", nil, 404, jsonType, `{"status":404,"message":"Not Found"}`},
+		{"/404", "image/png", nil, 404, jsonType, `{"status":404,"message":"Not Found"}`},
+		{
+			"/500",
+			"text/html,application/xhtml+xml",
+			[]ErrorPagesOptions{{Template: tmpl}},
+			500,
+			htmlType,
+			"<h1>Soon</h1><p>500: Internal Server Error</p>",
+		},
+		{
+			"/500",
+			"application/json",
+			nil,
+			500,
+			jsonType,
+			`{"status":500,"message":"Internal Server Error"}`,
+		},
+		{
+			"/403",
+			"application/json",
+			nil,
+			403,
+			jsonType,
+			`{"status":403,"message":"Forbidden"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			router := NewRouter()
+			router.GET("/500", func(c *Context) {
+				panic(errors.New("boom"))
+			})
+			router.GET("/403", func(c *Context) {
+				c.Next(internal.ErrForbidden)
+			})
+			router.Use(ErrorPages(tt.options...))
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			h := http.Header{}
+			if tt.accept != "" {
+				h.Set("Accept", tt.accept)
+			}
+			code, header, body, err := request("GET", server.URL+tt.path, h)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, code)
+			assert.Equal(t, tt.expectedContentType, header.Get("Content-Type"))
+			assert.Equal(t, "Accept", header.Get("Vary"))
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, body)
+			} else {
+				assert.Contains(t, body, "<h1>404</h1>")
+				assert.Contains(t, body, "<p>Not Found</p>")
+			}
+		})
+	}
+}
+
+func TestErrorPages_Panic(t *testing.T) {
+	router := NewRouter()
+	router.GET("/", func(c *Context) {
+		panic(errors.New("boom"))
+	})
+	router.GET("/error", func(c *Context) {
+		c.Next(errors.New("oops"))
+	})
+	router.Use(ErrorPages())
+
+	// the panic text is hidden in release mode
+	for _, accept := range []string{"text/html", "application/json"} {
+		w := Test(router).Get("/").Set("Accept", accept).Do()
+		assert.Equal(t, 500, w.Code)
+		assert.Contains(t, w.Body.String(), "Internal Server Error")
+		assert.NotContains(t, w.Body.String(), "boom")
+	}
+
+	// the error passed to next isn't a panic
+	w := Test(router).Get("/error").Set("Accept", "application/json").Do()
+	assert.Equal(t, `{"status":500,"message":"oops"}`+"\n", w.Body.String())
+
+	SetMode(DebugMode)
+	defer SetMode(TestMode)
+	w = Test(router).Get("/").Set("Accept", "application/json").Do()
+	assert.Equal(t, `{"status":500,"message":"boom"}`+"\n", w.Body.String())
+}
@@ -10,8 +10,20 @@ import (
 	"strings"
 
 	"github.com/soongo/soon/binding"
+	"github.com/soongo/soon/internal/json"
+	"github.com/soongo/soon/util"
 )
 
+// JSONCodec is the JSON implementation used by the JSON renderers and
+// bindings, such as encoding/json, jsoniter or go-json.
+type JSONCodec = json.Codec
+
+// JSONEncoder is the encoder returned by JSONCodec.NewEncoder.
+type JSONEncoder = json.Encoder
+
+// JSONDecoder is the decoder returned by JSONCodec.NewDecoder.
+type JSONDecoder = json.Decoder
+
 // EnvSoonMode indicates environment name for soon mode.
 const EnvSoonMode = "SOON_MODE"
 
@@ -39,8 +51,11 @@ const (
 var DefaultWriter io.Writer = os.Stdout
 
 // DefaultErrorWriter is the default io.Writer used by Soon to debug errors
+// and warnings, such as overriding the status code after headers are written.
 var DefaultErrorWriter io.Writer = os.Stderr
 
+var trustProxy bool
+
 var mode = ReleaseMode
 var modeCode = releaseCode
 
@@ -87,3 +102,67 @@ func EnableJsonDecoderUseNumber() {
 func EnableJsonDecoderDisallowUnknownFields() {
 	binding.EnableDecoderDisallowUnknownFields = true
 }
+
+// EnableContentTypeSniffing sets true for binding.EnableContentTypeSniffing,
+// so that c.Bind binds the JSON body sent without Content-Type.
+func EnableContentTypeSniffing() {
+	binding.EnableContentTypeSniffing = true
+}
+
+// SetMaxMultipartMemory sets binding.MaxMultipartMemory, which is the maximum
+// bytes of memory used to parse multipart forms. It's the fallback of the
+// "max multipart memory" setting of App.
+func SetMaxMultipartMemory(size int64) {
+	binding.MaxMultipartMemory = size
+}
+
+// SetMaxRanges sets util.MaxRanges, which is the maximum count of ranges in the
+// Range header, the request with more ranges is responded with 416.
+func SetMaxRanges(n int) {
+	util.MaxRanges = n
+}
+
+// SetJSONCodec sets the JSON implementation used by the JSON renderers and
+// bindings, encoding/json is used by default, and nil restores it. It's the
+// fallback of the "json codec" setting of App.
+func SetJSONCodec(codec JSONCodec) {
+	json.SetCodec(codec)
+}
+
+// SetBindingErrorJSON sets whether the default error handler responds the
+// errors thrown by c.MustBind and the similar methods with the failing fields,
+// in the form of `{"error":"validation failed","fields":[...],"status":400}`,
+// when the request accepts JSON.
+func SetBindingErrorJSON(enabled bool) {
+	bindingErrorJSON = enabled
+}
+
+// SetCookieDefaults sets the default attributes applied by c.Cookie. It's the
+// fallback of the "cookie defaults" setting of App.
+func SetCookieDefaults(defaults CookieDefaults) {
+	cookieDefaults = defaults
+}
+
+// SetTrustProxy sets whether the X-Forwarded-* headers set by the reverse proxy
+// are trusted, such as X-Forwarded-Host for req.Hostname().
+func SetTrustProxy(trust bool) {
+	trustProxy = trust
+}
+
+// SetNotAcceptableHandler sets the handler to respond 406 “Not Acceptable” in
+// c.Format and c.Negotiate, instead of passing NotAcceptableError to the error
+// handlers. The status is set to 406 before calling it, and nil restores the
+// default behavior.
+func SetNotAcceptableHandler(h NotAcceptableHandle) {
+	notAcceptableHandler = h
+}
+
+// SetLoggerFactory sets the factory to create the logger returned by
+// c.Logger, and nil restores the default one, which writes to DefaultWriter
+// with the request ID as prefix.
+func SetLoggerFactory(factory LoggerFactory) {
+	if factory == nil {
+		factory = defaultLoggerFactory
+	}
+	loggerFactory = factory
+}
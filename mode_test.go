@@ -5,9 +5,12 @@
 package soon
 
 import (
+	"net/http"
 	"testing"
 
 	"github.com/soongo/soon/binding"
+	"github.com/soongo/soon/internal/json"
+	"github.com/soongo/soon/util"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -60,3 +63,56 @@ func TestEnableJsonDecoderDisallowUnknownFields(t *testing.T) {
 	EnableJsonDecoderDisallowUnknownFields()
 	assert.True(t, binding.EnableDecoderDisallowUnknownFields)
 }
+
+func TestEnableContentTypeSniffing(t *testing.T) {
+	assert.False(t, binding.EnableContentTypeSniffing)
+	EnableContentTypeSniffing()
+	assert.True(t, binding.EnableContentTypeSniffing)
+	binding.EnableContentTypeSniffing = false
+}
+
+func TestSetMaxMultipartMemory(t *testing.T) {
+	size := binding.MaxMultipartMemory
+	assert.Equal(t, int64(32<<20), size)
+	SetMaxMultipartMemory(1 << 10)
+	assert.Equal(t, int64(1<<10), binding.MaxMultipartMemory)
+	binding.MaxMultipartMemory = size
+}
+
+type stubJSONCodec struct {
+	JSONCodec
+}
+
+func TestSetMaxRanges(t *testing.T) {
+	assert.Equal(t, 100, util.MaxRanges)
+	SetMaxRanges(10)
+	assert.Equal(t, 10, util.MaxRanges)
+	SetMaxRanges(100)
+	assert.Equal(t, 100, util.MaxRanges)
+}
+
+func TestSetJSONCodec(t *testing.T) {
+	assert.Equal(t, json.Std, json.GetCodec())
+	codec := stubJSONCodec{json.Std}
+	SetJSONCodec(codec)
+	assert.Equal(t, codec, json.GetCodec())
+	SetJSONCodec(nil)
+	assert.Equal(t, json.Std, json.GetCodec())
+}
+
+func TestSetCookieDefaults(t *testing.T) {
+	assert.Equal(t, CookieDefaults{}, cookieDefaults)
+	defaults := CookieDefaults{Path: "/", SameSite: http.SameSiteLaxMode, Secure: true}
+	SetCookieDefaults(defaults)
+	assert.Equal(t, defaults, cookieDefaults)
+	SetCookieDefaults(CookieDefaults{})
+	assert.Equal(t, CookieDefaults{}, cookieDefaults)
+}
+
+func TestSetTrustProxy(t *testing.T) {
+	assert.False(t, trustProxy)
+	SetTrustProxy(true)
+	assert.True(t, trustProxy)
+	SetTrustProxy(false)
+	assert.False(t, trustProxy)
+}
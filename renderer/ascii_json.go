@@ -0,0 +1,79 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"bytes"
+	"net/http"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/soongo/soon/internal/json"
+)
+
+// AsciiJSON contains the given interface object, it's rendered as JSON with
+// all non-ASCII characters escaped as `\uXXXX`.
+type AsciiJSON struct {
+	Data interface{}
+
+	// Codec is the JSON implementation, the one set by soon.SetJSONCodec is
+	// used if it's nil.
+	Codec json.Codec
+}
+
+// RenderHeader writes custom headers.
+func (a *AsciiJSON) RenderHeader(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", jsonContentType)
+}
+
+// Render writes data with custom ContentType.
+func (a *AsciiJSON) Render(w http.ResponseWriter, _ *http.Request) error {
+	body, err := a.encode()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(body)
+	return err
+}
+
+// Buffered reports that the body is rendered into memory first, so that the
+// Content-Length header can be set.
+func (a *AsciiJSON) Buffered() bool {
+	return true
+}
+
+func (a *AsciiJSON) encode() ([]byte, error) {
+	bs, err := json.Or(a.Codec).Marshal(a.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	for len(bs) > 0 {
+		r, size := utf8.DecodeRune(bs)
+		switch {
+		case r < utf8.RuneSelf:
+			buf.WriteByte(bs[0])
+		case r > 0xffff:
+			// the characters out of BMP are escaped as surrogate pair
+			r1, r2 := utf16.EncodeRune(r)
+			writeUnicodeEscape(&buf, r1)
+			writeUnicodeEscape(&buf, r2)
+		default:
+			writeUnicodeEscape(&buf, r)
+		}
+		bs = bs[size:]
+	}
+	return buf.Bytes(), nil
+}
+
+func writeUnicodeEscape(buf *bytes.Buffer, r rune) {
+	const hex = "0123456789abcdef"
+	buf.WriteString(`\u`)
+	buf.WriteByte(hex[r>>12&0xf])
+	buf.WriteByte(hex[r>>8&0xf])
+	buf.WriteByte(hex[r>>4&0xf])
+	buf.WriteByte(hex[r&0xf])
+}
@@ -0,0 +1,59 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAsciiJSON_RenderHeader(t *testing.T) {
+	w := httptest.NewRecorder()
+	renderer := AsciiJSON{Data: nil}
+	renderer.RenderHeader(w, nil)
+	assert.Equal(t, jsonContentType, w.Header().Get("Content-Type"))
+}
+
+func TestAsciiJSON_Render(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+		Tag  string `json:"tag"`
+	}
+
+	tests := []struct {
+		data     interface{}
+		expected string
+	}{
+		{nil, "null"},
+		{"foo", `"foo"`},
+		{user{"中文", "<br>"}, `{"name":"\u4e2d\u6587","tag":"\u003cbr\u003e"}`},
+		{[]string{"é", "😀"}, `["\u00e9","\ud83d\ude00"]`},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		renderer := AsciiJSON{Data: tt.data}
+		assert.Nil(t, renderer.Render(w, nil))
+		body := w.Body.String()
+		assert.Equal(t, tt.expected, body)
+		assert.Equal(t, len(body), utf8.RuneCountInString(body))
+
+		// the escaped json is decoded to the same value
+		if s, ok := tt.data.(user); ok {
+			var decoded user
+			assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &decoded))
+			assert.Equal(t, s, decoded)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	renderer := AsciiJSON{Data: func() {}}
+	assert.NotNil(t, renderer.Render(w, nil))
+	assert.Equal(t, "", w.Body.String())
+}
@@ -0,0 +1,33 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import "net/http"
+
+// Data contains the given bytes and its content type.
+type Data struct {
+	ContentType string
+	Data        []byte
+}
+
+// RenderHeader writes custom headers.
+func (d *Data) RenderHeader(w http.ResponseWriter, _ *http.Request) {
+	if d.ContentType != "" {
+		w.Header().Set("Content-Type", d.ContentType)
+	} else if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", octetStreamContentType)
+	}
+}
+
+// Render writes data with custom ContentType.
+func (d *Data) Render(w http.ResponseWriter, _ *http.Request) error {
+	_, err := w.Write(d.Data)
+	return err
+}
+
+// ContentLength returns the length of body.
+func (d *Data) ContentLength() int {
+	return len(d.Data)
+}
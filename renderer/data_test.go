@@ -0,0 +1,45 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestData_RenderHeader(t *testing.T) {
+	w := httptest.NewRecorder()
+	renderer := Data{Data: []byte("hi")}
+	renderer.RenderHeader(w, nil)
+	assert.Equal(t, octetStreamContentType, w.Header().Get("Content-Type"))
+
+	w.Header().Set("Content-Type", jsonContentType)
+	renderer.RenderHeader(w, nil)
+	assert.Equal(t, jsonContentType, w.Header().Get("Content-Type"))
+
+	renderer = Data{ContentType: "image/png", Data: []byte("hi")}
+	renderer.RenderHeader(w, nil)
+	assert.Equal(t, "image/png", w.Header().Get("Content-Type"))
+}
+
+func TestData_Render(t *testing.T) {
+	tests := []struct {
+		data []byte
+	}{
+		{nil},
+		{[]byte("hi")},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		renderer := Data{Data: tt.data}
+		assert.Equal(t, len(tt.data), renderer.ContentLength())
+		err := renderer.Render(w, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, string(tt.data), w.Body.String())
+	}
+}
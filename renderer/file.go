@@ -7,10 +7,15 @@ package renderer
 import (
 	"errors"
 	"fmt"
+	"html"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -35,12 +40,18 @@ const (
 	IndexDisabled string = "IndexDisabled"
 )
 
+const defaultIndex = "index.html,index.htm"
+
 var (
 	// ErrIsDir means the file is directory
 	ErrIsDir = internal.NewStatusTextError(400, "file is directory")
 
 	// RangeNotSatisfiableError indicates the range header is not satisfiable
 	RangeNotSatisfiableError = internal.NewStatusTextError(400, "range not satisfiable")
+
+	// ErrRangeNotSatisfiable indicates none of the ranges overlaps the file,
+	// such as any range of the empty file.
+	ErrRangeNotSatisfiable = internal.NewStatusTextError(416, "range not satisfiable")
 )
 
 // FileOptions contains all options for file renderer
@@ -48,6 +59,13 @@ type FileOptions struct {
 	// Sets the max-age property of the Cache-Control header.
 	MaxAge *time.Duration
 
+	// Adds the immutable directive to the Cache-Control header, it works only
+	// with MaxAge. It's useful for the assets with fingerprinted filenames.
+	Immutable bool
+
+	// Sets the Cache-Control header as is, MaxAge and Immutable are ignored.
+	CacheControl string
+
 	// Root directory for relative filenames.
 	Root string
 
@@ -58,6 +76,11 @@ type FileOptions struct {
 	// file on the OS. Set true to disable it.
 	LastModifiedDisabled bool
 
+	// Whether sets the weak ETag header generated by the size and the last
+	// modified date of the file, unless one is given by Header. Set true to
+	// disable it.
+	ETagDisabled bool
+
 	// HTTP headers to serve with the file.
 	Header map[string]string
 
@@ -66,11 +89,21 @@ type FileOptions struct {
 	DotfilesPolicy DotfilesPolicy
 
 	// Enable or disable accepting ranged requests. Set true to disable it.
+	// A single range, or the ranges which can be combined into one, is sent
+	// as 206 Partial Content. Multiple ranges aren't sent as
+	// multipart/byteranges, the full content is sent with 200 instead.
 	AcceptRangesDisabled bool
 
-	// Index sends the specified directory index file.
+	// Index sends the specified directory index file, multiple candidates can
+	// be separated by commas, such as "index.html,default.html", the first one
+	// exists is sent. It defaults to "index.html,index.htm".
 	// Set to `IndexDisabled` to disable directory indexing.
 	Index string
+
+	// Responds an HTML listing of the entries for the directory without index
+	// file, instead of an error. Dot files are listed only if DotfilesPolicy
+	// is DotfilesPolicyAllow.
+	DirectoryListing bool
 }
 
 // File contains the given path and options for file renderer.
@@ -84,6 +117,12 @@ func (f *File) RenderHeader(_ http.ResponseWriter, _ *http.Request) {
 	// pass
 }
 
+// HandlesHead reports that the headers of HEAD request are the same as GET,
+// but the file isn't read at all.
+func (f *File) HandlesHead() bool {
+	return true
+}
+
 // Render writes data with custom ContentType.
 func (f *File) Render(w http.ResponseWriter, req *http.Request) error {
 	absPath, options := strings.TrimSpace(f.FilePath), f.Options
@@ -102,29 +141,58 @@ func (f *File) Render(w http.ResponseWriter, req *http.Request) error {
 		absPath = filepath.Join(root, absPath)
 	}
 
-	fileInfo, err := os.Stat(absPath)
+	// serve the absolute path by a file system rooted at the volume, so that
+	// the files on disk and in fs.FS are served in the same way
+	volume := filepath.VolumeName(absPath)
+	root := http.Dir(volume + string(filepath.Separator))
+	return serveFile(w, req, root, filepath.ToSlash(strings.TrimPrefix(absPath, volume)), options)
+}
+
+// serveFile writes the file `name` of fs, which is a slash-separated path.
+func serveFile(w http.ResponseWriter, req *http.Request, fs http.FileSystem, name string, options FileOptions) error {
+	file, err := fs.Open(name)
+	if err != nil {
+		return internal.ErrNotFound
+	}
+	defer file.Close()
+
+	fileInfo, err := file.Stat()
 	if err != nil {
 		return internal.ErrNotFound
 	}
 
 	if fileInfo.IsDir() {
-		if options.Index == IndexDisabled {
-			return ErrIsDir
-		}
+		dir, dirName := file, name
+		if options.Index != IndexDisabled {
+			index := strings.TrimSpace(options.Index)
+			if index == "" {
+				index = defaultIndex
+			}
 
-		index := strings.TrimSpace(options.Index)
-		if index == "" {
-			index = "index.html"
+			err = internal.ErrNotFound
+			for _, v := range strings.Split(index, ",") {
+				if v = strings.TrimSpace(v); v == "" {
+					continue
+				}
+				if name, file, fileInfo, err = openIndex(fs, dirName, v); err == nil {
+					defer file.Close()
+					break
+				}
+			}
 		}
 
-		absPath = filepath.Join(absPath, index)
-		fileInfo, err = os.Stat(absPath)
-		if err != nil {
+		if options.Index == IndexDisabled || err != nil {
+			if options.DirectoryListing {
+				return renderDirectory(w, req, dirName, dir, options)
+			}
+			if options.Index == IndexDisabled {
+				return ErrIsDir
+			}
 			return internal.ErrNotFound
 		}
 	}
 
-	if strings.HasPrefix(filepath.Base(absPath), ".") {
+	if strings.HasPrefix(path.Base(name), ".") {
 		if options.DotfilesPolicy == DotfilesPolicyIgnore {
 			return internal.ErrNotFound
 		}
@@ -137,38 +205,171 @@ func (f *File) Render(w http.ResponseWriter, req *http.Request) error {
 		util.SetHeader(w, options.Header)
 	}
 
-	if options.MaxAge != nil {
+	if options.CacheControl != "" {
+		w.Header().Set("Cache-Control", options.CacheControl)
+	} else if options.MaxAge != nil {
 		t := fmt.Sprintf("max-age=%.0f", (*options.MaxAge).Seconds())
+		if options.Immutable {
+			t = "public, " + t + ", immutable"
+		}
 		w.Header().Set("Cache-Control", t)
 	}
 
-	if !options.LastModifiedDisabled {
+	if !options.LastModifiedDisabled && !fileInfo.ModTime().IsZero() {
 		w.Header().Set("Last-Modified", fileInfo.ModTime().UTC().Format(http.TimeFormat))
 	}
 
-	file, err := os.Open(absPath)
-	if err == nil {
-		defer file.Close()
+	if !options.ETagDisabled && w.Header().Get("ETag") == "" {
+		w.Header().Set("ETag", util.ETagFromFileInfo(fileInfo, true))
+	}
 
-		util.SetContentType(w, filepath.Ext(absPath))
-		if !options.AcceptRangesDisabled {
-			rangeHeader := strings.TrimSpace(req.Header.Get("range"))
-			if rangeHeader != "" {
-				ranges, err := util.RangeParser(fileInfo.Size(), rangeHeader, true)
-				if err != nil {
-					return RangeNotSatisfiableError
-				}
-				if ranges.Type == "bytes" && len(ranges.Ranges) == 1 {
-					start, end := ranges.Ranges[0].Start, ranges.Ranges[0].End
-					file.Seek(start, 0)
-					_, err = io.CopyN(w, file, end-start+1)
+	if isFresh(req, w.Header()) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	util.SetContentType(w, path.Ext(name))
+
+	// the headers are the same as GET, but the file isn't read at all, and
+	// the range header is ignored as per RFC 7233
+	if req.Method == http.MethodHead {
+		w.Header().Set("Content-Length", strconv.FormatInt(fileInfo.Size(), 10))
+		return nil
+	}
+
+	if !options.AcceptRangesDisabled {
+		rangeHeader := strings.TrimSpace(req.Header.Get("range"))
+		if rangeHeader != "" {
+			unit, err := util.ParseRangeType(rangeHeader)
+			if err != nil {
+				return RangeNotSatisfiableError
+			}
+			// the range of unknown unit is ignored, as per RFC 7233
+			if strings.EqualFold(unit, "bytes") {
+				if ok, err := renderRange(w, file, fileInfo.Size(), rangeHeader); ok || err != nil {
 					return err
 				}
 			}
 		}
+	}
 
+	// multiple ranges are served as the full content as well
+	w.Header().Set("Content-Length", strconv.FormatInt(fileInfo.Size(), 10))
+
+	// let the writer send the file with zero-copy, e.g. sendfile
+	if rf, ok := w.(io.ReaderFrom); ok {
+		_, err = rf.ReadFrom(file)
+	} else {
 		_, err = io.Copy(w, file)
 	}
+	return err
+}
+
+// openIndex opens the index file in dir, it fails if the index is a directory.
+func openIndex(fs http.FileSystem, dir, index string) (string, http.File, os.FileInfo, error) {
+	name := path.Join(dir, index)
+	file, err := fs.Open(name)
+	if err != nil {
+		return "", nil, nil, err
+	}
 
+	fileInfo, err := file.Stat()
+	if err == nil && fileInfo.IsDir() {
+		err = internal.ErrNotFound
+	}
+	if err != nil {
+		file.Close()
+		return "", nil, nil, err
+	}
+	return name, file, fileInfo, nil
+}
+
+// renderRange writes the single byte range of file as 206 Partial Content, it
+// reports false if the full content should be sent instead, such as multiple
+// ranges.
+func renderRange(w http.ResponseWriter, file io.ReadSeeker, size int64, rangeHeader string) (bool, error) {
+	ranges, err := util.RangeParser(size, rangeHeader, true)
+	if err == util.ErrUnsatisfiableRange || err == util.ErrTooManyRanges {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		return false, ErrRangeNotSatisfiable
+	} else if err != nil {
+		return false, RangeNotSatisfiableError
+	}
+
+	if !ranges.IsBytes() || len(ranges.Ranges) != 1 {
+		return false, nil
+	}
+
+	start, end := ranges.Ranges[0].Start, ranges.Ranges[0].End
+	if _, err = file.Seek(start, io.SeekStart); err != nil {
+		return false, err
+	}
+	w.Header().Set("Content-Range", fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+	w.Header().Set("Content-Length", strconv.FormatInt(end-start+1, 10))
+	w.WriteHeader(http.StatusPartialContent)
+	_, err = io.CopyN(w, file, end-start+1)
+	return true, err
+}
+
+// isFresh reports whether the conditional GET or HEAD request is fresh, aka the
+// client cache still matches the Last-Modified and/or ETag of response.
+func isFresh(req *http.Request, header http.Header) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead {
+		return false
+	}
+	return util.Fresh(req.Header, header)
+}
+
+// renderDirectory writes a simple HTML listing of the entries in dir named
+// dirName, the links are relative to the request path.
+func renderDirectory(w http.ResponseWriter, req *http.Request, dirName string, dir http.File, options FileOptions) error {
+	if strings.HasPrefix(path.Base(dirName), ".") {
+		if options.DotfilesPolicy == DotfilesPolicyIgnore {
+			return internal.ErrNotFound
+		}
+		if options.DotfilesPolicy == DotfilesPolicyDeny {
+			return internal.ErrForbidden
+		}
+	}
+
+	entries, err := dir.Readdir(-1)
+	if err != nil {
+		return err
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Name() < entries[j].Name()
+	})
+
+	base := req.URL.EscapedPath()
+	if !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+
+	var sb strings.Builder
+	title := html.EscapeString(req.URL.Path)
+	sb.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	sb.WriteString("<title>Index of " + title + "</title>\n</head>\n<body>\n")
+	sb.WriteString("<h1>Index of " + title + "</h1>\n<ul>\n")
+	if base != "/" {
+		sb.WriteString("<li><a href=\"" + base + "../\">../</a></li>\n")
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") && options.DotfilesPolicy != DotfilesPolicyAllow {
+			continue
+		}
+		if entry.IsDir() {
+			name += "/"
+		}
+		href := base + (&url.URL{Path: name}).EscapedPath()
+		sb.WriteString("<li><a href=\"" + html.EscapeString(href) + "\">" + html.EscapeString(name) + "</a></li>\n")
+	}
+	sb.WriteString("</ul>\n</body>\n</html>\n")
+
+	if options.Header != nil {
+		util.SetHeader(w, options.Header)
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, err = io.WriteString(w, sb.String())
 	return err
 }
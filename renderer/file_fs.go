@@ -0,0 +1,58 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build go1.16
+// +build go1.16
+
+package renderer
+
+import (
+	"errors"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+)
+
+var (
+	_ Renderer     = &FileFS{}
+	_ HeadRenderer = &FileFS{}
+)
+
+// FileFS is similar with File, but it serves the file from fs.FS, such as
+// embed.FS, instead of the disk.
+type FileFS struct {
+	FS fs.FS
+
+	// The slash-separated path of file in FS. If Options.Root is set, the
+	// path is relative to it.
+	FilePath string
+	Options  FileOptions
+}
+
+// RenderHeader writes custom headers.
+func (f *FileFS) RenderHeader(_ http.ResponseWriter, _ *http.Request) {
+	// pass
+}
+
+// HandlesHead reports that the headers of HEAD request are the same as GET,
+// but the file isn't read at all.
+func (f *FileFS) HandlesHead() bool {
+	return true
+}
+
+// Render writes data with custom ContentType.
+func (f *FileFS) Render(w http.ResponseWriter, req *http.Request) error {
+	filePath := strings.TrimSpace(f.FilePath)
+	if filePath == "" {
+		return errors.New("path argument is required")
+	}
+	if f.FS == nil {
+		return errors.New("fs argument is required")
+	}
+
+	// the path can't escape from root
+	name := path.Join("/", strings.TrimSpace(f.Options.Root), path.Clean("/"+filePath))
+	return serveFile(w, req, http.FS(f.FS), name, f.Options)
+}
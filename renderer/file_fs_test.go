@@ -0,0 +1,79 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build go1.16
+// +build go1.16
+
+package renderer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/soongo/soon/internal"
+)
+
+func TestFileFS_Render(t *testing.T) {
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"hello.txt":             {Data: []byte("hello world"), ModTime: modTime},
+		"static/index.html":     {Data: []byte("<h1>index</h1>")},
+		"static/.secret":        {Data: []byte("secret")},
+		"static/css/style.css":  {Data: []byte("body{}")},
+		"static/empty/.gitkeep": {},
+	}
+
+	tests := []struct {
+		filePath             string
+		options              FileOptions
+		header               map[string]string
+		expectedErr          error
+		expectedStatus       int
+		expectedContentType  string
+		expectedLastModified string
+		expectedBody         string
+	}{
+		{"hello.txt", FileOptions{}, nil, nil, 200, "text/plain; charset=utf-8", "Thu, 02 Jan 2020 03:04:05 GMT", "hello world"},
+		{"/hello.txt", FileOptions{LastModifiedDisabled: true}, nil, nil, 200, "text/plain; charset=utf-8", "", "hello world"},
+		{"hello.txt", FileOptions{}, map[string]string{"Range": "bytes=6-"}, nil, 206, "text/plain; charset=utf-8", "Thu, 02 Jan 2020 03:04:05 GMT", "world"},
+		{"hello.txt", FileOptions{}, map[string]string{"If-Modified-Since": "Thu, 02 Jan 2020 03:04:05 GMT"}, nil, 304, "", "Thu, 02 Jan 2020 03:04:05 GMT", ""},
+		{"static", FileOptions{}, nil, nil, 200, "text/html; charset=utf-8", "", "<h1>index</h1>"},
+		{"style.css", FileOptions{Root: "static/css"}, nil, nil, 200, "text/css; charset=utf-8", "", "body{}"},
+		{"../../hello.txt", FileOptions{Root: "static"}, nil, internal.ErrNotFound, 200, "", "", ""},
+		{"static/.secret", FileOptions{}, nil, internal.ErrNotFound, 200, "", "", ""},
+		{"static/.secret", FileOptions{DotfilesPolicy: DotfilesPolicyDeny}, nil, internal.ErrForbidden, 200, "", "", ""},
+		{"static/.secret", FileOptions{DotfilesPolicy: DotfilesPolicyAllow}, nil, nil, 200, "application/octet-stream", "", "secret"},
+		{"static/empty", FileOptions{}, nil, internal.ErrNotFound, 200, "", "", ""},
+		{"static/empty", FileOptions{Index: IndexDisabled}, nil, ErrIsDir, 200, "", "", ""},
+		{"not_exist.txt", FileOptions{}, nil, internal.ErrNotFound, 200, "", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filePath, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			renderer := FileFS{FS: fsys, FilePath: tt.filePath, Options: tt.options}
+			err := renderer.Render(w, req)
+			assert.Equal(t, tt.expectedErr, err)
+			if tt.expectedErr == nil {
+				assert.Equal(t, tt.expectedStatus, w.Code)
+				assert.Equal(t, tt.expectedContentType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedLastModified, w.Header().Get("Last-Modified"))
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+
+	w, req := httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil)
+	assert.NotNil(t, (&FileFS{FS: fsys}).Render(w, req))
+	assert.NotNil(t, (&FileFS{FilePath: "hello.txt"}).Render(w, req))
+}
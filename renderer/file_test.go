@@ -7,10 +7,14 @@ package renderer
 import (
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
 	"net/http/httptest"
 	"os"
 	"path"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -178,7 +182,7 @@ func TestFile_Render(t *testing.T) {
 			FileOptions{},
 			"bytes=10-20",
 			&util.Range{Start: 10, End: 20},
-			200,
+			206,
 			"text/markdown; charset=utf-8",
 			nil,
 		},
@@ -188,7 +192,7 @@ func TestFile_Render(t *testing.T) {
 			FileOptions{},
 			"bytes=10-20,21-30",
 			&util.Range{Start: 10, End: 30},
-			200,
+			206,
 			"text/markdown; charset=utf-8",
 			nil,
 		},
@@ -212,6 +216,36 @@ func TestFile_Render(t *testing.T) {
 			"text/markdown; charset=utf-8",
 			nil,
 		},
+		{
+			"range-non-bytes",
+			path.Join(pwd, "../README.md"),
+			FileOptions{},
+			"items=0-5",
+			nil,
+			200,
+			"text/markdown; charset=utf-8",
+			nil,
+		},
+		{
+			"range-non-bytes-unsatisfiable",
+			path.Join(pwd, "../README.md"),
+			FileOptions{},
+			"items=99999999-",
+			nil,
+			200,
+			"text/markdown; charset=utf-8",
+			nil,
+		},
+		{
+			"range-malformed",
+			path.Join(pwd, "../README.md"),
+			FileOptions{},
+			"0-5",
+			nil,
+			400,
+			"text/markdown; charset=utf-8",
+			RangeNotSatisfiableError,
+		},
 		{
 			"range-error",
 			path.Join(pwd, "../README.md"),
@@ -252,6 +286,13 @@ func TestFile_Render(t *testing.T) {
 				lastModified := fileInfo.ModTime().UTC().Format(timeFormat)
 				assert.Equal(tt.expectedStatus, w.Code)
 				assert.Equal(fileContent, w.Body.String())
+				assert.Equal(fmt.Sprintf("%d", len(fileContent)), w.Header().Get("Content-Length"))
+				if tt.expectedRange != nil {
+					r := tt.expectedRange
+					assert.Equal(fmt.Sprintf("bytes %d-%d/%d", r.Start, r.End, fileInfo.Size()), w.Header().Get("Content-Range"))
+				} else {
+					assert.Equal("", w.Header().Get("Content-Range"))
+				}
 				assert.Equal(tt.expectedContentType, w.Header().Get("Content-Type"))
 				if tt.options.MaxAge != nil {
 					cc := fmt.Sprintf("max-age=%.0f", maxAge.Seconds())
@@ -272,6 +313,389 @@ func TestFile_Render(t *testing.T) {
 	}
 }
 
+func TestFile_RenderCacheControl(t *testing.T) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+
+	maxAge := 365 * 24 * time.Hour
+	tests := []struct {
+		name     string
+		options  FileOptions
+		expected string
+	}{
+		{"none", FileOptions{}, ""},
+		{"max-age", FileOptions{MaxAge: &maxAge}, "max-age=31536000"},
+		{"immutable", FileOptions{MaxAge: &maxAge, Immutable: true}, "public, max-age=31536000, immutable"},
+		{"immutable-without-max-age", FileOptions{Immutable: true}, ""},
+		{
+			"override",
+			FileOptions{MaxAge: &maxAge, Immutable: true, CacheControl: "no-cache"},
+			"no-cache",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := File{path.Join(pwd, "../README.md"), tt.options}
+			w := httptest.NewRecorder()
+			assert.NoError(t, renderer.Render(w, httptest.NewRequest("GET", "/", nil)))
+			assert.Equal(t, tt.expected, w.Header().Get("Cache-Control"))
+		})
+	}
+}
+
+func TestFile_RenderConditional(t *testing.T) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+
+	filePath := path.Join(pwd, "../README.md")
+	renderer := File{filePath, FileOptions{}}
+	w := httptest.NewRecorder()
+	assert.NoError(t, renderer.Render(w, httptest.NewRequest("GET", "/", nil)))
+	lastModified := w.Header().Get("Last-Modified")
+	assert.NotEmpty(t, lastModified)
+	assert.NotEmpty(t, w.Body.String())
+
+	tests := []struct {
+		name           string
+		method         string
+		options        FileOptions
+		header         map[string]string
+		expectedStatus int
+		expectedBody   bool
+	}{
+		{"if-modified-since", "GET", FileOptions{}, map[string]string{"If-Modified-Since": lastModified}, 304, false},
+		{"head", "HEAD", FileOptions{}, map[string]string{"If-Modified-Since": lastModified}, 304, false},
+		{
+			"modified",
+			"GET",
+			FileOptions{},
+			map[string]string{"If-Modified-Since": "Thu, 01 Jan 1970 00:00:00 GMT"},
+			200,
+			true,
+		},
+		{
+			"no-cache",
+			"GET",
+			FileOptions{},
+			map[string]string{"If-Modified-Since": lastModified, "Cache-Control": "no-cache"},
+			200,
+			true,
+		},
+		{"post", "POST", FileOptions{}, map[string]string{"If-Modified-Since": lastModified}, 200, true},
+		{
+			"last-modified-disabled",
+			"GET",
+			FileOptions{LastModifiedDisabled: true},
+			map[string]string{"If-Modified-Since": lastModified},
+			200,
+			true,
+		},
+		{
+			"if-none-match",
+			"GET",
+			FileOptions{Header: map[string]string{"ETag": `"foo"`}},
+			map[string]string{"If-None-Match": `W/"foo"`},
+			304,
+			false,
+		},
+		{
+			"if-none-match-stale",
+			"GET",
+			FileOptions{Header: map[string]string{"ETag": `"foo"`}},
+			map[string]string{"If-None-Match": `"bar"`},
+			200,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := File{filePath, tt.options}
+			w, req := httptest.NewRecorder(), httptest.NewRequest(tt.method, "/", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			assert.NoError(t, renderer.Render(w, req))
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.Equal(t, tt.expectedBody, w.Body.Len() > 0)
+		})
+	}
+}
+
+func TestFile_RenderHead(t *testing.T) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+	filePath := path.Join(pwd, "../README.md")
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name    string
+		options FileOptions
+		header  map[string]string
+		etag    string
+	}{
+		{"default", FileOptions{}, nil, util.ETagFromFileInfo(fileInfo, true)},
+		{"range", FileOptions{}, map[string]string{"Range": "bytes=0-1"}, util.ETagFromFileInfo(fileInfo, true)},
+		{"etag-disabled", FileOptions{ETagDisabled: true}, nil, ""},
+		{"etag-header", FileOptions{Header: map[string]string{"ETag": `"foo"`}}, nil, `"foo"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := File{filePath, tt.options}
+			w, req := httptest.NewRecorder(), httptest.NewRequest("HEAD", "/", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			assert.NoError(t, renderer.Render(w, req))
+			assert.Equal(t, 200, w.Code)
+			assert.Equal(t, fmt.Sprintf("%d", fileInfo.Size()), w.Header().Get("Content-Length"))
+			assert.Equal(t, "text/markdown; charset=utf-8", w.Header().Get("Content-Type"))
+			assert.Equal(t, fileInfo.ModTime().UTC().Format("Mon, 02 Jan 2006 15:04:05 GMT"), w.Header().Get("Last-Modified"))
+			assert.Equal(t, tt.etag, w.Header().Get("ETag"))
+			assert.Equal(t, "", w.Header().Get("Content-Range"))
+			assert.Equal(t, "", w.Body.String())
+		})
+	}
+
+	t.Run("if-none-match", func(t *testing.T) {
+		renderer := File{filePath, FileOptions{}}
+		w, req := httptest.NewRecorder(), httptest.NewRequest("HEAD", "/", nil)
+		req.Header.Set("If-None-Match", util.ETagFromFileInfo(fileInfo, true))
+		assert.NoError(t, renderer.Render(w, req))
+		assert.Equal(t, 304, w.Code)
+		assert.Equal(t, "", w.Body.String())
+	})
+}
+
+type readerFromRecorder struct {
+	*httptest.ResponseRecorder
+
+	calls int
+}
+
+func (w *readerFromRecorder) ReadFrom(src io.Reader) (int64, error) {
+	w.calls++
+	return io.Copy(w.ResponseRecorder, src)
+}
+
+func TestFile_RenderReaderFrom(t *testing.T) {
+	pwd, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+	filePath := path.Join(pwd, "../README.md")
+	_, fileContent := getFileContent(filePath, nil)
+
+	w := &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	renderer := File{filePath, FileOptions{}}
+	assert.NoError(t, renderer.Render(w, httptest.NewRequest("GET", "/", nil)))
+	assert.Equal(t, 1, w.calls)
+	assert.Equal(t, fileContent, w.Body.String())
+
+	w = &readerFromRecorder{ResponseRecorder: httptest.NewRecorder()}
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Range", "bytes=10-20")
+	assert.NoError(t, renderer.Render(w, req))
+	assert.Equal(t, fileContent[10:21], w.Body.String())
+}
+
+func TestFile_RenderUnsatisfiableRange(t *testing.T) {
+	f, err := ioutil.TempFile("", "soon-empty-*.txt")
+	if err != nil {
+		panic(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	pwd, err := os.Getwd()
+	if err != nil {
+		panic(err)
+	}
+	readme := path.Join(pwd, "../README.md")
+	fileInfo, err := os.Stat(readme)
+	if err != nil {
+		panic(err)
+	}
+
+	tests := []struct {
+		name                 string
+		filePath             string
+		rangeHeader          string
+		expectedContentRange string
+	}{
+		{"empty-file", f.Name(), "bytes=0-", "bytes */0"},
+		{"empty-file-suffix", f.Name(), "bytes=-1", "bytes */0"},
+		{"out-of-size", readme, "bytes=99999999-", fmt.Sprintf("bytes */%d", fileInfo.Size())},
+		{"too-many-ranges", readme, "bytes=" + strings.Repeat("0-1,", util.MaxRanges) + "0-1", fmt.Sprintf("bytes */%d", fileInfo.Size())},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := File{tt.filePath, FileOptions{}}
+			w, req := httptest.NewRecorder(), httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Range", tt.rangeHeader)
+			err := renderer.Render(w, req)
+			assert.Equal(t, ErrRangeNotSatisfiable, err)
+			assert.Equal(t, 416, ErrRangeNotSatisfiable.Status())
+			assert.Equal(t, tt.expectedContentRange, w.Header().Get("Content-Range"))
+			assert.Equal(t, "", w.Body.String())
+		})
+	}
+
+	t.Run("empty-file-without-range", func(t *testing.T) {
+		renderer := File{f.Name(), FileOptions{}}
+		w := httptest.NewRecorder()
+		assert.NoError(t, renderer.Render(w, httptest.NewRequest("GET", "/", nil)))
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "", w.Body.String())
+	})
+}
+
+func TestFile_RenderDirectoryListing(t *testing.T) {
+	dir, err := ioutil.TempDir("", "soon-listing")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	for _, name := range []string{"a.txt", "b c.txt", ".hidden", "sub/d.txt"} {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			panic(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(name), 0644); err != nil {
+			panic(err)
+		}
+	}
+
+	tests := []struct {
+		name             string
+		options          FileOptions
+		url              string
+		expectedError    error
+		expectedContains []string
+		expectedExcludes []string
+	}{
+		{
+			"listing",
+			FileOptions{DirectoryListing: true},
+			"/files",
+			nil,
+			[]string{
+				`<a href="/files/a.txt">a.txt</a>`,
+				`<a href="/files/b%20c.txt">b c.txt</a>`,
+				`<a href="/files/sub/">sub/</a>`,
+				`<a href="/files/../">../</a>`,
+			},
+			[]string{".hidden"},
+		},
+		{
+			"listing-index-disabled",
+			FileOptions{DirectoryListing: true, Index: IndexDisabled},
+			"/",
+			nil,
+			[]string{`<a href="/a.txt">a.txt</a>`},
+			[]string{".hidden", "../"},
+		},
+		{
+			"listing-dotfiles-allow",
+			FileOptions{DirectoryListing: true, DotfilesPolicy: DotfilesPolicyAllow},
+			"/files/",
+			nil,
+			[]string{`<a href="/files/.hidden">.hidden</a>`},
+			nil,
+		},
+		{"listing-disabled", FileOptions{}, "/", internal.ErrNotFound, nil, nil},
+		{"listing-disabled-index-disabled", FileOptions{Index: IndexDisabled}, "/", ErrIsDir, nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			renderer := File{dir, tt.options}
+			w := httptest.NewRecorder()
+			err := renderer.Render(w, httptest.NewRequest("GET", tt.url, nil))
+			if tt.expectedError != nil {
+				assert.Equal(t, tt.expectedError, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, 200, w.Code)
+			assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+			for _, v := range tt.expectedContains {
+				assert.Contains(t, w.Body.String(), v)
+			}
+			for _, v := range tt.expectedExcludes {
+				assert.NotContains(t, w.Body.String(), v)
+			}
+		})
+	}
+}
+
+func TestFile_RenderIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "soon-index")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+	files := []string{
+		"htm/index.htm",
+		"both/index.html",
+		"both/index.htm",
+		"custom/default.html",
+		"custom/index.htm",
+		"nested/index.html/foo.txt",
+	}
+	for _, name := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			panic(err)
+		}
+		if err := ioutil.WriteFile(p, []byte(name), 0644); err != nil {
+			panic(err)
+		}
+	}
+
+	tests := []struct {
+		dir           string
+		index         string
+		expectedError error
+		expectedBody  string
+	}{
+		{"htm", "", nil, "htm/index.htm"},
+		{"both", "", nil, "both/index.html"},
+		{"custom", "", nil, "custom/index.htm"},
+		{"custom", "default.html", nil, "custom/default.html"},
+		{"custom", "home.html, default.html,index.htm", nil, "custom/default.html"},
+		{"both", "index.htm,index.html", nil, "both/index.htm"},
+		{"custom", "home.html", internal.ErrNotFound, ""},
+		{"custom", " , ", internal.ErrNotFound, ""},
+		{"nested", "", internal.ErrNotFound, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir+":"+tt.index, func(t *testing.T) {
+			renderer := File{FilePath: filepath.Join(dir, tt.dir), Options: FileOptions{Index: tt.index}}
+			w := httptest.NewRecorder()
+			err := renderer.Render(w, httptest.NewRequest("GET", "/", nil))
+			assert.Equal(t, tt.expectedError, err)
+			if tt.expectedError == nil {
+				assert.Equal(t, "text/html; charset=utf-8", w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedBody, w.Body.String())
+			}
+		})
+	}
+}
+
 func getFileContent(p string, r *util.Range) (os.FileInfo, string) {
 	f, err := os.Open(p)
 	if err != nil {
@@ -297,3 +721,55 @@ func getFileContent(p string, r *util.Range) (os.FileInfo, string) {
 
 	return fileInfo, string(bts)
 }
+
+// copyResponseWriter hides the io.ReaderFrom of http.ResponseWriter, so that
+// the file is always copied through a buffer.
+type copyResponseWriter struct {
+	w http.ResponseWriter
+}
+
+func (w copyResponseWriter) Header() http.Header         { return w.w.Header() }
+func (w copyResponseWriter) Write(b []byte) (int, error) { return w.w.Write(b) }
+func (w copyResponseWriter) WriteHeader(statusCode int)  { w.w.WriteHeader(statusCode) }
+
+func BenchmarkFile_Render(b *testing.B) {
+	f, err := ioutil.TempFile("", "soon-bench-*.bin")
+	if err != nil {
+		panic(err)
+	}
+	defer os.Remove(f.Name())
+	if _, err = f.Write(make([]byte, 4<<20)); err != nil {
+		panic(err)
+	}
+	f.Close()
+
+	handlers := []struct {
+		name    string
+		handler http.HandlerFunc
+	}{
+		{"sendfile", func(w http.ResponseWriter, req *http.Request) {
+			(&File{f.Name(), FileOptions{}}).Render(w, req)
+		}},
+		{"copy", func(w http.ResponseWriter, req *http.Request) {
+			(&File{f.Name(), FileOptions{}}).Render(copyResponseWriter{w}, req)
+		}},
+	}
+
+	for _, h := range handlers {
+		b.Run(h.name, func(b *testing.B) {
+			server := httptest.NewServer(h.handler)
+			defer server.Close()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				resp, err := http.Get(server.URL)
+				if err != nil {
+					b.Fatal(err)
+				}
+				io.Copy(ioutil.Discard, resp.Body)
+				resp.Body.Close()
+			}
+		})
+	}
+}
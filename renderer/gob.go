@@ -0,0 +1,29 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"encoding/gob"
+	"net/http"
+)
+
+// GOB contains the given interface object, which is encoded by encoding/gob.
+type GOB struct {
+	Data interface{}
+}
+
+const gobContentType = "application/x-gob"
+
+// RenderHeader writes custom headers.
+func (g *GOB) RenderHeader(w http.ResponseWriter, _ *http.Request) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", gobContentType)
+	}
+}
+
+// Render writes data with custom ContentType.
+func (g *GOB) Render(w http.ResponseWriter, _ *http.Request) error {
+	return gob.NewEncoder(w).Encode(g.Data)
+}
@@ -0,0 +1,42 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"encoding/gob"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGOB_RenderHeader(t *testing.T) {
+	w := httptest.NewRecorder()
+	renderer := GOB{nil}
+	renderer.RenderHeader(w, nil)
+	assert.Equal(t, gobContentType, w.Header().Get("Content-Type"))
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	renderer.RenderHeader(w, nil)
+	assert.Equal(t, "application/octet-stream", w.Header().Get("Content-Type"))
+}
+
+func TestGOB_Render(t *testing.T) {
+	type book struct {
+		Name      string
+		PageTotal uint16
+	}
+
+	w := httptest.NewRecorder()
+	renderer := GOB{[]book{{"foo", 50}, {"bar", 20}}}
+	assert.Nil(t, renderer.Render(w, nil))
+
+	var books []book
+	assert.Nil(t, gob.NewDecoder(w.Body).Decode(&books))
+	assert.Equal(t, []book{{"foo", 50}, {"bar", 20}}, books)
+
+	renderer = GOB{make(chan int)}
+	assert.NotNil(t, renderer.Render(httptest.NewRecorder(), nil))
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"io"
+	"net/http"
+)
+
+// HTML contains the template, the name of template to execute and the data.
+type HTML struct {
+	Template *template.Template
+	Name     string
+	Data     interface{}
+
+	// Layout is the name of the associated template which wraps the output
+	// of the template to execute, it's executed with LayoutData. No layout
+	// is used if it's empty.
+	Layout string
+}
+
+// LayoutData is the data to execute the layout template, the page is
+// available as `{{.Content}}` and its data as `{{.Data}}`.
+type LayoutData struct {
+	Content template.HTML
+	Data    interface{}
+}
+
+const htmlContentType = "text/html; charset=utf-8"
+
+// RenderHeader writes custom headers.
+func (h *HTML) RenderHeader(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", htmlContentType)
+}
+
+// Render executes the template with data. If name is empty, the template
+// itself is executed, otherwise the associated template with the name.
+func (h *HTML) Render(w http.ResponseWriter, _ *http.Request) error {
+	if h.Template == nil {
+		return errors.New("template is required")
+	}
+	if h.Layout == "" {
+		return h.execute(w)
+	}
+
+	// the page is rendered into buffer, so that nothing is written if it fails
+	var buf bytes.Buffer
+	if err := h.execute(&buf); err != nil {
+		return err
+	}
+	return h.Template.ExecuteTemplate(w, h.Layout, LayoutData{
+		Content: template.HTML(buf.String()),
+		Data:    h.Data,
+	})
+}
+
+func (h *HTML) execute(w io.Writer) error {
+	if h.Name == "" {
+		return h.Template.Execute(w, h.Data)
+	}
+	return h.Template.ExecuteTemplate(w, h.Name, h.Data)
+}
@@ -0,0 +1,74 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"html/template"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTML_RenderHeader(t *testing.T) {
+	w := httptest.NewRecorder()
+	renderer := HTML{}
+	renderer.RenderHeader(w, nil)
+	assert.Equal(t, htmlContentType, w.Header().Get("Content-Type"))
+}
+
+func TestHTML_Render(t *testing.T) {
+	tmpl := template.Must(template.New("index").Parse(`<p>{{.}}</p>`))
+	template.Must(tmpl.New("title").Parse(`<h1>{{.}}</h1>`))
+
+	tests := []struct {
+		template *template.Template
+		name     string
+		data     interface{}
+		expected string
+		hasError bool
+	}{
+		{tmpl, "", "foo", "<p>foo</p>", false},
+		{tmpl, "index", "<b>", "<p>&lt;b&gt;</p>", false},
+		{tmpl, "title", "foo", "<h1>foo</h1>", false},
+		{tmpl, "not-exist", "foo", "", true},
+		{nil, "", "foo", "", true},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		renderer := HTML{Template: tt.template, Name: tt.name, Data: tt.data}
+		err := renderer.Render(w, nil)
+		if tt.hasError {
+			assert.NotNil(t, err)
+		} else {
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, w.Body.String())
+		}
+	}
+}
+
+func TestHTML_RenderLayout(t *testing.T) {
+	tmpl := template.Must(template.New("layout").Parse(
+		`<title>{{.Data.Title}}</title><main>{{.Content}}</main>`))
+	template.Must(tmpl.New("page").Parse(`<p>{{.Body}}</p>{{template "partial" .}}`))
+	template.Must(tmpl.New("partial").Parse(`<i>{{.Title}}</i>`))
+	template.Must(tmpl.New("broken").Parse(`{{.Missing.Field}}`))
+	data := map[string]string{"Title": "foo", "Body": "<b>"}
+
+	w := httptest.NewRecorder()
+	renderer := HTML{Template: tmpl, Name: "page", Data: data, Layout: "layout"}
+	assert.Nil(t, renderer.Render(w, nil))
+	assert.Equal(t, "<title>foo</title><main><p>&lt;b&gt;</p><i>foo</i></main>", w.Body.String())
+
+	w = httptest.NewRecorder()
+	renderer = HTML{Template: tmpl, Name: "not-exist", Data: data, Layout: "layout"}
+	assert.NotNil(t, renderer.Render(w, nil))
+	assert.Equal(t, "", w.Body.String())
+
+	w = httptest.NewRecorder()
+	renderer = HTML{Template: tmpl, Name: "page", Data: data, Layout: "not-exist"}
+	assert.NotNil(t, renderer.Render(w, nil))
+}
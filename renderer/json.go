@@ -5,13 +5,23 @@
 package renderer
 
 import (
-	"encoding/json"
+	"io"
 	"net/http"
+
+	"github.com/soongo/soon/internal/json"
 )
 
 // JSON contains the given interface object.
 type JSON struct {
 	Data interface{}
+
+	// Indent is the indentation of each level, the output is compact if it's
+	// empty.
+	Indent string
+
+	// Codec is the JSON implementation, the one set by soon.SetJSONCodec is
+	// used if it's nil.
+	Codec json.Codec
 }
 
 const jsonContentType = "application/json; charset=utf-8"
@@ -23,5 +33,19 @@ func (j *JSON) RenderHeader(w http.ResponseWriter, _ *http.Request) {
 
 // Render writes data with custom ContentType.
 func (j *JSON) Render(w http.ResponseWriter, _ *http.Request) error {
-	return json.NewEncoder(w).Encode(j.Data)
+	return j.encode(w)
+}
+
+func (j *JSON) encode(w io.Writer) error {
+	encoder := json.Or(j.Codec).NewEncoder(w)
+	if j.Indent != "" {
+		encoder.SetIndent("", j.Indent)
+	}
+	return encoder.Encode(j.Data)
+}
+
+// Buffered reports that the body is rendered into memory first, so that the
+// Content-Length header can be set.
+func (j *JSON) Buffered() bool {
+	return true
 }
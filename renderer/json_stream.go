@@ -0,0 +1,71 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"io"
+	"net/http"
+
+	"github.com/soongo/soon/internal/json"
+)
+
+// JSONStream contains the channel of items to write as a JSON array.
+type JSONStream struct {
+	Items <-chan interface{}
+
+	// Codec is the JSON implementation, the one set by soon.SetJSONCodec is
+	// used if it's nil.
+	Codec json.Codec
+}
+
+// RenderHeader writes custom headers.
+func (j *JSONStream) RenderHeader(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", jsonContentType)
+}
+
+// Render writes the items received from channel as a JSON array until the
+// channel is closed, each item is encoded and flushed one by one, so that
+// the memory usage is flat. The remaining items are drained if any error
+// occurs.
+func (j *JSONStream) Render(w http.ResponseWriter, _ *http.Request) (err error) {
+	defer func() {
+		if err != nil {
+			j.Discard()
+		}
+	}()
+
+	flusher, _ := w.(http.Flusher)
+	if _, err = io.WriteString(w, "["); err != nil {
+		return err
+	}
+
+	first := true
+	for item := range j.Items {
+		data, err := json.Or(j.Codec).Marshal(item)
+		if err != nil {
+			return err
+		}
+		if !first {
+			data = append([]byte{','}, data...)
+		}
+		first = false
+		if _, err = w.Write(data); err != nil {
+			return err
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	_, err = io.WriteString(w, "]")
+	return err
+}
+
+// Discard drains the remaining items of channel until it's closed, so that
+// the producer isn't blocked when the body isn't rendered.
+func (j *JSONStream) Discard() {
+	for range j.Items {
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type flushCountingRecorder struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (r *flushCountingRecorder) Flush() {
+	r.flushes++
+	r.ResponseRecorder.Flush()
+}
+
+func streamItems(items ...interface{}) <-chan interface{} {
+	ch := make(chan interface{})
+	go func() {
+		defer close(ch)
+		for _, item := range items {
+			ch <- item
+		}
+	}()
+	return ch
+}
+
+func TestJSONStream_RenderHeader(t *testing.T) {
+	w := httptest.NewRecorder()
+	renderer := JSONStream{}
+	renderer.RenderHeader(w, nil)
+	assert.Equal(t, jsonContentType, w.Header().Get("Content-Type"))
+}
+
+func TestJSONStream_Render(t *testing.T) {
+	tests := []struct {
+		items    []interface{}
+		expected string
+	}{
+		{nil, "[]"},
+		{[]interface{}{1}, "[1]"},
+		{[]interface{}{"foo", 1, nil, map[string]int{"bar": 2}}, `["foo",1,null,{"bar":2}]`},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		renderer := JSONStream{Items: streamItems(tt.items...)}
+		assert.NoError(t, renderer.Render(w, nil))
+		assert.Equal(t, tt.expected, w.Body.String())
+	}
+}
+
+func TestJSONStream_RenderLarge(t *testing.T) {
+	items := make([]interface{}, 1000)
+	for i := range items {
+		items[i] = map[string]int{"id": i}
+	}
+
+	w := &flushCountingRecorder{ResponseRecorder: httptest.NewRecorder()}
+	renderer := JSONStream{Items: streamItems(items...)}
+	assert.NoError(t, renderer.Render(w, nil))
+
+	var result []map[string]int
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	assert.Len(t, result, 1000)
+	assert.Equal(t, 999, result[999]["id"])
+	assert.Greater(t, w.flushes, 1)
+}
+
+func TestJSONStream_RenderError(t *testing.T) {
+	w := httptest.NewRecorder()
+	renderer := JSONStream{Items: streamItems(1, make(chan int), 3)}
+	assert.Error(t, renderer.Render(w, nil))
+	assert.Equal(t, "[1", w.Body.String())
+}
@@ -5,16 +5,71 @@
 package renderer
 
 import (
+	"bytes"
+	stdjson "encoding/json"
 	"errors"
+	"io"
 	"net/http/httptest"
+	"sync"
 	"testing"
 
+	"github.com/soongo/soon/internal/json"
 	"github.com/stretchr/testify/assert"
 )
 
+// pooledCodec is a custom Codec which marshals into the pooled buffers, so
+// that the JSON renderers are tested and benchmarked against a codec other
+// than Std.
+type pooledCodec struct {
+	pool  sync.Pool
+	calls int
+}
+
+func newPooledCodec() *pooledCodec {
+	return &pooledCodec{pool: sync.Pool{New: func() interface{} { return new(bytes.Buffer) }}}
+}
+
+func (c *pooledCodec) Marshal(v interface{}) ([]byte, error) {
+	c.calls++
+	buf := c.pool.Get().(*bytes.Buffer)
+	defer c.pool.Put(buf)
+	buf.Reset()
+	if err := stdjson.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), bytes.TrimSuffix(buf.Bytes(), []byte("\n"))...), nil
+}
+
+func (c *pooledCodec) Unmarshal(data []byte, v interface{}) error {
+	return stdjson.Unmarshal(data, v)
+}
+
+func (c *pooledCodec) NewEncoder(w io.Writer) json.Encoder {
+	c.calls++
+	return stdjson.NewEncoder(w)
+}
+
+func (c *pooledCodec) NewDecoder(r io.Reader) json.Decoder {
+	return stdjson.NewDecoder(r)
+}
+
+// jsonCodecs returns the codecs which the JSON renderers are run against.
+func jsonCodecs() []struct {
+	name  string
+	codec json.Codec
+} {
+	return []struct {
+		name  string
+		codec json.Codec
+	}{
+		{"std", json.Std},
+		{"pooled", newPooledCodec()},
+	}
+}
+
 func TestJSON_RenderHeader(t *testing.T) {
 	w := httptest.NewRecorder()
-	renderer := JSON{nil}
+	renderer := JSON{Data: nil}
 	renderer.RenderHeader(w, nil)
 	assert.Equal(t, jsonContentType, w.Header().Get("Content-Type"))
 }
@@ -49,7 +104,7 @@ func TestJSON_Render(t *testing.T) {
 	assert := assert.New(t)
 	for _, tt := range tests {
 		w := httptest.NewRecorder()
-		renderer := JSON{tt.data}
+		renderer := JSON{Data: tt.data}
 		err := renderer.Render(w, nil)
 		if tt.err != nil {
 			assert.NotNil(err)
@@ -60,3 +115,76 @@ func TestJSON_Render(t *testing.T) {
 		}
 	}
 }
+
+func TestJSON_RenderError(t *testing.T) {
+	w := httptest.NewRecorder()
+	renderer := JSON{Data: func() {}}
+	assert.NotNil(t, renderer.Render(w, nil))
+	assert.Equal(t, "", w.Body.String())
+}
+
+func TestJSON_RenderIndent(t *testing.T) {
+	w := httptest.NewRecorder()
+	renderer := JSON{Data: map[string]int{"foo": 1}, Indent: "\t"}
+	assert.Nil(t, renderer.Render(w, nil))
+	assert.Equal(t, "{\n\t\"foo\": 1\n}\n", w.Body.String())
+}
+
+func TestJSON_Codec(t *testing.T) {
+	tests := []struct {
+		name string
+		test func(t *testing.T)
+	}{
+		{"JSON", TestJSON_Render},
+		{"JSONError", TestJSON_RenderError},
+		{"JSONIndent", TestJSON_RenderIndent},
+		{"JSONP", TestJSONP_Render},
+		{"JSONPIndent", TestJSONP_RenderIndent},
+		{"PureJSON", TestPureJSON_Render},
+		{"AsciiJSON", TestAsciiJSON_Render},
+		{"JSONStream", TestJSONStream_Render},
+		{"JSONStreamError", TestJSONStream_RenderError},
+	}
+
+	for _, c := range jsonCodecs() {
+		for _, tt := range tests {
+			t.Run(c.name+"/"+tt.name, func(t *testing.T) {
+				json.SetCodec(c.codec)
+				defer json.SetCodec(nil)
+				tt.test(t)
+			})
+		}
+		if p, ok := c.codec.(*pooledCodec); ok {
+			assert.Greater(t, p.calls, len(tests))
+		}
+	}
+
+	// the codec of renderer takes precedence over the global one
+	codec := newPooledCodec()
+	w := httptest.NewRecorder()
+	assert.NoError(t, (&JSON{Data: 1, Codec: codec}).Render(w, nil))
+	assert.Equal(t, "1\n", w.Body.String())
+	assert.Equal(t, 1, codec.calls)
+}
+
+func BenchmarkJSON_Codec(b *testing.B) {
+	data := []struct {
+		Name      string `json:"name"`
+		PageTotal uint16 `json:"pageTotal"`
+	}{{"foo", 50}, {"bar", 20}}
+
+	for _, c := range jsonCodecs() {
+		b.Run(c.name, func(b *testing.B) {
+			renderers := []Renderer{
+				&JSON{Data: data, Codec: c.codec},
+				&AsciiJSON{Data: data, Codec: c.codec},
+				&JSONP{Data: data, Codec: c.codec},
+			}
+			b.ReportAllocs()
+			b.SetBytes(int64(len(`[{"name":"foo","pageTotal":50},{"name":"bar","pageTotal":20}]`)))
+			for i := 0; i < b.N; i++ {
+				renderers[i%len(renderers)].Render(httptest.NewRecorder(), nil)
+			}
+		})
+	}
+}
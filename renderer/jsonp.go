@@ -5,10 +5,12 @@
 package renderer
 
 import (
-	"encoding/json"
+	"bytes"
 	"io"
 	"net/http"
 	"strings"
+
+	"github.com/soongo/soon/internal/json"
 )
 
 const (
@@ -19,6 +21,14 @@ const (
 // JSONP contains the given interface object.
 type JSONP struct {
 	Data interface{}
+
+	// Indent is the indentation of each level, the output is compact if it's
+	// empty.
+	Indent string
+
+	// Codec is the JSON implementation, the one set by soon.SetJSONCodec is
+	// used if it's nil.
+	Codec json.Codec
 }
 
 // RenderHeader writes custom headers.
@@ -29,7 +39,7 @@ func (j *JSONP) RenderHeader(w http.ResponseWriter, _ *http.Request) {
 
 // Render writes data with custom ContentType.
 func (j *JSONP) Render(w http.ResponseWriter, req *http.Request) error {
-	bs, err := json.Marshal(j.Data)
+	bs, err := j.marshal()
 	if err != nil {
 		return err
 	}
@@ -50,3 +60,17 @@ func (j *JSONP) Render(w http.ResponseWriter, req *http.Request) error {
 	_, err = io.WriteString(w, body)
 	return err
 }
+
+func (j *JSONP) marshal() ([]byte, error) {
+	if j.Indent == "" {
+		return json.Or(j.Codec).Marshal(j.Data)
+	}
+
+	var buf bytes.Buffer
+	encoder := json.Or(j.Codec).NewEncoder(&buf)
+	encoder.SetIndent("", j.Indent)
+	if err := encoder.Encode(j.Data); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
@@ -15,7 +15,7 @@ import (
 
 func TestJSONP_RenderHeader(t *testing.T) {
 	w := httptest.NewRecorder()
-	renderer := JSONP{nil}
+	renderer := JSONP{Data: nil}
 	renderer.RenderHeader(w, nil)
 	assert.Equal(t, jsonpContentType, w.Header().Get("Content-Type"))
 }
@@ -72,7 +72,7 @@ func TestJSONP_Render(t *testing.T) {
 	assert := assert.New(t)
 	for _, tt := range tests {
 		w := httptest.NewRecorder()
-		renderer := JSONP{tt.data}
+		renderer := JSONP{Data: tt.data}
 		err := renderer.Render(w, tt.request)
 		if tt.err != nil {
 			assert.NotNil(err)
@@ -83,3 +83,13 @@ func TestJSONP_Render(t *testing.T) {
 		}
 	}
 }
+
+func TestJSONP_RenderIndent(t *testing.T) {
+	w := httptest.NewRecorder()
+	renderer := JSONP{Data: map[string]int{"foo": 1}, Indent: "  "}
+	assert.Nil(t, renderer.Render(w, httptest.NewRequest("GET", "/?callback=cb", nil)))
+	assert.Equal(t, "/**/ typeof cb === 'function' && cb({\n  \"foo\": 1\n});", w.Body.String())
+
+	renderer = JSONP{Data: func() {}, Indent: "  "}
+	assert.NotNil(t, renderer.Render(httptest.NewRecorder(), nil))
+}
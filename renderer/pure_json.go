@@ -0,0 +1,39 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"net/http"
+
+	"github.com/soongo/soon/internal/json"
+)
+
+// PureJSON contains the given interface object, it's rendered as JSON without
+// escaping the HTML characters `<`, `>` and `&`.
+type PureJSON struct {
+	Data interface{}
+
+	// Codec is the JSON implementation, the one set by soon.SetJSONCodec is
+	// used if it's nil.
+	Codec json.Codec
+}
+
+// RenderHeader writes custom headers.
+func (p *PureJSON) RenderHeader(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", jsonContentType)
+}
+
+// Render writes data with custom ContentType.
+func (p *PureJSON) Render(w http.ResponseWriter, _ *http.Request) error {
+	encoder := json.Or(p.Codec).NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(p.Data)
+}
+
+// Buffered reports that the body is rendered into memory first, so that the
+// Content-Length header can be set.
+func (p *PureJSON) Buffered() bool {
+	return true
+}
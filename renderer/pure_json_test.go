@@ -0,0 +1,47 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPureJSON_RenderHeader(t *testing.T) {
+	w := httptest.NewRecorder()
+	renderer := PureJSON{Data: nil}
+	renderer.RenderHeader(w, nil)
+	assert.Equal(t, jsonContentType, w.Header().Get("Content-Type"))
+}
+
+func TestPureJSON_Render(t *testing.T) {
+	tests := []struct {
+		data     interface{}
+		expected string
+	}{
+		{nil, "null"},
+		{"<b>foo</b>", `"<b>foo</b>"`},
+		{map[string]string{"html": "<a href=\"/?a=1&b=2\">"}, `{"html":"<a href=\"/?a=1&b=2\">"}`},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		renderer := PureJSON{Data: tt.data}
+		assert.Nil(t, renderer.Render(w, nil))
+		assert.Equal(t, tt.expected+"\n", w.Body.String())
+
+		w = httptest.NewRecorder()
+		renderer = PureJSON{Data: tt.data}
+		assert.Nil(t, renderer.Render(w, nil))
+		assert.Equal(t, tt.expected+"\n", w.Body.String())
+	}
+
+	w := httptest.NewRecorder()
+	renderer := PureJSON{Data: func() {}}
+	assert.NotNil(t, renderer.Render(w, nil))
+	assert.Equal(t, "", w.Body.String())
+}
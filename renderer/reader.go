@@ -0,0 +1,30 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"io"
+	"net/http"
+)
+
+// Reader contains the reader whose content will be copied to response.
+type Reader struct {
+	Reader io.Reader
+}
+
+const octetStreamContentType = "application/octet-stream"
+
+// RenderHeader writes custom headers.
+func (r *Reader) RenderHeader(w http.ResponseWriter, _ *http.Request) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", octetStreamContentType)
+	}
+}
+
+// Render copies data from the reader to response.
+func (r *Reader) Render(w http.ResponseWriter, _ *http.Request) error {
+	_, err := io.Copy(w, r.Reader)
+	return err
+}
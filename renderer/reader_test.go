@@ -0,0 +1,42 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReader_RenderHeader(t *testing.T) {
+	w := httptest.NewRecorder()
+	renderer := Reader{strings.NewReader("hi")}
+	renderer.RenderHeader(w, nil)
+	assert.Equal(t, octetStreamContentType, w.Header().Get("Content-Type"))
+
+	w.Header().Set("Content-Type", jsonContentType)
+	renderer.RenderHeader(w, nil)
+	assert.Equal(t, jsonContentType, w.Header().Get("Content-Type"))
+}
+
+func TestReader_Render(t *testing.T) {
+	tests := []struct {
+		s string
+	}{
+		{""},
+		{"hi"},
+		{strings.Repeat("hello world\n", 1000)},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		renderer := Reader{strings.NewReader(tt.s)}
+		err := renderer.Render(w, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, tt.s, w.Body.String())
+	}
+}
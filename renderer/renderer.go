@@ -15,10 +15,70 @@ type Renderer interface {
 	Render(http.ResponseWriter, *http.Request) error
 }
 
+// MeasurableRenderer is a Renderer which knows the length of body before
+// rendering, so that the Content-Length header can be set instead of using
+// the chunked transfer encoding.
+type MeasurableRenderer interface {
+	Renderer
+
+	// ContentLength returns the length of body, or -1 if it's unknown.
+	ContentLength() int
+}
+
+// BufferedRenderer is a Renderer whose body is encoded on the fly, so the
+// length is unknown until it's rendered, such as JSON. The body of it is
+// rendered into memory first by the caller, so that the Content-Length
+// header can be set, and nothing is sent if the encoding fails.
+type BufferedRenderer interface {
+	Renderer
+
+	// Buffered reports whether the body should be rendered into memory first.
+	Buffered() bool
+}
+
+// HeadRenderer is a Renderer which handles the HEAD request by itself, it
+// writes the same headers as GET without the body, such as File. The other
+// renderers aren't rendered at all for the HEAD request.
+type HeadRenderer interface {
+	Renderer
+
+	// HandlesHead reports whether Render handles the HEAD request.
+	HandlesHead() bool
+}
+
+// DiscardableRenderer is a Renderer which holds the resources to be released
+// if it isn't rendered, such as the HEAD request, or the status without body.
+// For example, JSONStream drains the channel so that the producer isn't
+// blocked forever.
+type DiscardableRenderer interface {
+	Renderer
+
+	// Discard releases the resources without rendering the body.
+	Discard()
+}
+
 var (
 	_ Renderer = &String{}
 	_ Renderer = &JSON{}
 	_ Renderer = &File{}
 	_ Renderer = &JSONP{}
 	_ Renderer = &Redirect{}
+	_ Renderer = &Reader{}
+	_ Renderer = &XML{}
+	_ Renderer = &HTML{}
+	_ Renderer = &JSONStream{}
+	_ Renderer = &Data{}
+	_ Renderer = &AsciiJSON{}
+	_ Renderer = &PureJSON{}
+
+	_ MeasurableRenderer = &String{}
+	_ MeasurableRenderer = &Data{}
+
+	_ BufferedRenderer = &JSON{}
+	_ BufferedRenderer = &AsciiJSON{}
+	_ BufferedRenderer = &PureJSON{}
+
+	_ HeadRenderer = &File{}
+
+	_ DiscardableRenderer = &JSONStream{}
 )
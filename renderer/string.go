@@ -28,3 +28,8 @@ func (s *String) Render(w http.ResponseWriter, _ *http.Request) error {
 	_, err := io.WriteString(w, s.Data)
 	return err
 }
+
+// ContentLength returns the length of body.
+func (s *String) ContentLength() int {
+	return len(s.Data)
+}
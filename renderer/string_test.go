@@ -38,6 +38,7 @@ func TestString_Render(t *testing.T) {
 	for _, tt := range tests {
 		w := httptest.NewRecorder()
 		renderer := String{tt.s}
+		assert.Equal(t, len(tt.s), renderer.ContentLength())
 		renderer.Render(w, nil)
 		assert.Equal(t, tt.s, w.Body.String())
 	}
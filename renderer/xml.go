@@ -0,0 +1,29 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"encoding/xml"
+	"net/http"
+)
+
+// XML contains the given interface object.
+type XML struct {
+	Data interface{}
+}
+
+const xmlContentType = "application/xml; charset=utf-8"
+
+// RenderHeader writes custom headers.
+func (x *XML) RenderHeader(w http.ResponseWriter, _ *http.Request) {
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", xmlContentType)
+	}
+}
+
+// Render writes data with custom ContentType.
+func (x *XML) Render(w http.ResponseWriter, _ *http.Request) error {
+	return xml.NewEncoder(w).Encode(x.Data)
+}
@@ -0,0 +1,55 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package renderer
+
+import (
+	"encoding/xml"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXML_RenderHeader(t *testing.T) {
+	w := httptest.NewRecorder()
+	renderer := XML{nil}
+	renderer.RenderHeader(w, nil)
+	assert.Equal(t, xmlContentType, w.Header().Get("Content-Type"))
+
+	w.Header().Set("Content-Type", "text/xml; charset=utf-8")
+	renderer.RenderHeader(w, nil)
+	assert.Equal(t, "text/xml; charset=utf-8", w.Header().Get("Content-Type"))
+}
+
+func TestXML_Render(t *testing.T) {
+	type book struct {
+		XMLName   xml.Name `xml:"book"`
+		Name      string   `xml:"name"`
+		PageTotal uint16   `xml:"pageTotal,attr"`
+	}
+
+	tests := []struct {
+		data     interface{}
+		expected string
+		hasError bool
+	}{
+		{nil, "", false},
+		{book{Name: "foo", PageTotal: 50}, `<book pageTotal="50"><name>foo</name></book>`, false},
+		{[]book{{Name: "foo"}, {Name: "bar"}}, `<book pageTotal="0"><name>foo</name></book><book pageTotal="0"><name>bar</name></book>`, false},
+		{map[string]string{"foo": "bar"}, "", true},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		renderer := XML{tt.data}
+		err := renderer.Render(w, nil)
+		if tt.hasError {
+			assert.NotNil(t, err)
+		} else {
+			assert.Nil(t, err)
+			assert.Equal(t, tt.expected, w.Body.String())
+		}
+	}
+}
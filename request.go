@@ -7,8 +7,10 @@ package soon
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 
 	"github.com/soongo/negotiator"
@@ -23,6 +25,30 @@ func (p Params) Get(k interface{}) string {
 	return p[k]
 }
 
+// GetDefault returns one param by key, or def if the param doesn't exist
+func (p Params) GetDefault(k interface{}, def string) string {
+	if v, ok := p[k]; ok {
+		return v
+	}
+	return def
+}
+
+// Int returns one param by key parsed as int
+func (p Params) Int(k interface{}) (int, error) {
+	return strconv.Atoi(p[k])
+}
+
+// Int64 returns one param by key parsed as int64
+func (p Params) Int64(k interface{}) (int64, error) {
+	return strconv.ParseInt(p[k], 10, 64)
+}
+
+// Bool returns one param by key parsed as bool,
+// see strconv.ParseBool for the accepted values.
+func (p Params) Bool(k interface{}) (bool, error) {
+	return strconv.ParseBool(p[k])
+}
+
 // Set one param with key
 func (p Params) Set(k interface{}, v string) {
 	p[k] = v
@@ -50,7 +76,9 @@ type Request struct {
 	// the baseUrl property returns the matched string, not the pattern(s).
 	BaseUrl string
 
-	Hostname string
+	// OriginalUrl is the full request URI, including the query string.
+	OriginalUrl string
+
 	Protocol string
 	Path     string
 	Query    url.Values
@@ -58,18 +86,21 @@ type Request struct {
 	Xhr      bool
 
 	writer ResponseWriter
+
+	// the app serving the request, if any
+	app *App
 }
 
 // NewRequest returns an instance of Request object
 func NewRequest(req *http.Request) *Request {
 	r := &Request{
-		Request:  req,
-		Params:   make(Params, 0),
-		Hostname: req.URL.Host,
-		Protocol: req.URL.Scheme,
-		Path:     req.URL.EscapedPath(),
-		Query:    req.URL.Query(),
-		Secure:   req.URL.Scheme == "https",
+		Request:     req,
+		Params:      make(Params, 0),
+		OriginalUrl: req.URL.RequestURI(),
+		Protocol:    req.URL.Scheme,
+		Path:        req.URL.EscapedPath(),
+		Query:       req.URL.Query(),
+		Secure:      req.URL.Scheme == "https",
 	}
 
 	r.Xhr = strings.ToLower(r.Get("X-Requested-With")) == "xmlhttprequest"
@@ -77,11 +108,126 @@ func NewRequest(req *http.Request) *Request {
 	return r
 }
 
+// Hostname returns the hostname derived from the Host HTTP header, without the
+// port. When the trust proxy setting is enabled, the X-Forwarded-Host header
+// is preferred. The brackets of IPv6 literal, such as `[::1]`, are kept.
+func (r *Request) Hostname() string {
+	var host string
+	if r.isProxyTrusted() {
+		host = strings.TrimSpace(strings.Split(r.Get("X-Forwarded-Host"), ",")[0])
+	}
+	if host == "" {
+		host = r.Host
+	}
+	if host == "" {
+		host = r.URL.Host
+	}
+
+	// IPv6 literal may contain colons in the brackets, the host with an
+	// unclosed bracket is returned unchanged
+	offset := 0
+	if strings.HasPrefix(host, "[") {
+		i := strings.Index(host, "]")
+		if i == -1 {
+			return host
+		}
+		offset = i + 1
+	}
+	if i := strings.Index(host[offset:], ":"); i != -1 {
+		return host[:offset+i]
+	}
+	return host
+}
+
+// SignedCookie returns the value of the named cookie signed by c.SignedCookie
+// with secret. It reports false if the cookie is missing or tampered.
+func (r *Request) SignedCookie(name string, secret []byte) (string, bool) {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return "", false
+	}
+	return util.UnsignCookie(cookie.Value, secret)
+}
+
+// SignedCookies returns the values of all cookies signed by c.SignedCookie
+// with secret, the unsigned and tampered cookies are dropped silently. The
+// first one is used if there are cookies of the same name.
+func (r *Request) SignedCookies(secret []byte) map[string]string {
+	cookies := make(map[string]string)
+	for _, cookie := range r.Cookies() {
+		if _, exists := cookies[cookie.Name]; exists {
+			continue
+		}
+		if value, ok := util.UnsignCookie(cookie.Value, secret); ok {
+			cookies[cookie.Name] = value
+		}
+	}
+	return cookies
+}
+
+// IP returns the remote IP address of the request. When the trust proxy setting
+// is enabled, the left-most entry of the X-Forwarded-For header is preferred,
+// which is the address of the original client.
+func (r *Request) IP() string {
+	if r.isProxyTrusted() {
+		if ip := strings.TrimSpace(strings.Split(r.Get("X-Forwarded-For"), ",")[0]); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// isProxyTrusted reports whether the X-Forwarded-* headers are trusted, by the
+// "trust proxy" setting of the app, or SetTrustProxy if it's not set.
+func (r *Request) isProxyTrusted() bool {
+	if r.app != nil {
+		if v, ok := r.app.setting("trust proxy"); ok {
+			trust, _ := v.(bool)
+			return trust
+		}
+	}
+	return trustProxy
+}
+
 // Get returns the specified HTTP request header field (case-insensitive match).
 func (r *Request) Get(key string) string {
 	return r.Header.Get(key)
 }
 
+// RelativePath returns the path of the request relative to the mount point
+// of the router, aka Path without the BaseUrl prefix.
+func (r *Request) RelativePath() string {
+	return strings.TrimPrefix(r.Path, r.BaseUrl)
+}
+
+// RawQuery returns the current encoded query string of the request url,
+// without the leading '?'.
+func (r *Request) RawQuery() string {
+	return r.URL.RawQuery
+}
+
+// ReparseQuery parses the current query string of the request url into Query
+// again, and returns it. Query is parsed once when the request is created, so
+// it should be called after the url is rewritten, such as by a middleware.
+// The query bindings always read the current url.
+func (r *Request) ReparseQuery() url.Values {
+	r.Query = r.URL.Query()
+	return r.Query
+}
+
+// Links parses the Link HTTP header of request into a map from the relation
+// types to the urls, it's the inverse of c.Links.
+//
+//	Link: <http://api/?page=2>; rel="next", <http://api/?page=5>; rel="last"
+//	req.Links() == map[string]string{"next": "http://api/?page=2", "last": "http://api/?page=5"}
+func (r *Request) Links() map[string]string {
+	return util.ParseLinks(strings.Join(util.GetHeaderValues(r.Header, "Link"), ", "))
+}
+
 // ContentType returns the Content-Type HTTP header of request
 func (r *Request) ContentType() string {
 	contentType := strings.TrimSpace(r.Get("Content-Type"))
@@ -175,6 +321,53 @@ func (r *Request) AcceptsLanguages(languages ...string) []string {
 	return nil
 }
 
+// AcceptedTypes returns all acceptable media types of the request's Accept
+// HTTP header field, ordered by quality, and then by the order in the header
+// for the same quality. The types with quality 0 are excluded.
+func (r *Request) AcceptedTypes() []string {
+	return negotiator.New(r.Header).MediaTypes()
+}
+
+// AcceptedEncodings returns all acceptable encodings of the request's
+// Accept-Encoding HTTP header field, ordered as AcceptedTypes.
+func (r *Request) AcceptedEncodings() []string {
+	return negotiator.New(r.Header).Encodings()
+}
+
+// AcceptedCharsets returns all acceptable charsets of the request's
+// Accept-Charset HTTP header field, ordered as AcceptedTypes.
+func (r *Request) AcceptedCharsets() []string {
+	return negotiator.New(r.Header).Charsets()
+}
+
+// AcceptedLanguages returns all acceptable languages of the request's
+// Accept-Language HTTP header field, ordered as AcceptedTypes.
+func (r *Request) AcceptedLanguages() []string {
+	return negotiator.New(r.Header).Languages()
+}
+
+// Language returns the best language of `available` based on the request’s
+// Accept-Language HTTP header field, or `def` if none of them is acceptable.
+func (r *Request) Language(available []string, def string) string {
+	if len(available) > 0 {
+		if accepts := r.AcceptsLanguages(available...); len(accepts) > 0 {
+			return accepts[0]
+		}
+	}
+	return def
+}
+
+// Charset returns the best charset of `available` based on the request’s
+// Accept-Charset HTTP header field, or `def` if none of them is acceptable.
+func (r *Request) Charset(available []string, def string) string {
+	if len(available) > 0 {
+		if accepts := r.AcceptsCharsets(available...); len(accepts) > 0 {
+			return accepts[0]
+		}
+	}
+	return def
+}
+
 // Fresh checks if the request is fresh, aka Last-Modified and/or the ETag still match.
 func (r *Request) Fresh() bool {
 	// GET or HEAD for weak freshness validation only
@@ -203,6 +396,9 @@ func (r *Request) Is(types ...string) string {
 //
 // The "combine" argument can be set to `true` and overlapping & adjacent ranges
 // * will be combined into a single range.
+//
+// util.ErrTooManyRanges is returned if there are more ranges than
+// util.MaxRanges, see SetMaxRanges.
 func (r *Request) Range(size int64, combine bool) (util.Ranges, error) {
 	return util.RangeParser(size, r.Get("Range"), combine)
 }
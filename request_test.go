@@ -33,6 +33,101 @@ func TestParams_Get(t *testing.T) {
 	}
 }
 
+func TestParams_GetDefault(t *testing.T) {
+	tests := []struct {
+		p        Params
+		k        interface{}
+		def      string
+		expected string
+	}{
+		{Params{"name": "foo", 0: "bar"}, "name", "baz", "foo"},
+		{Params{"name": "foo", 0: "bar"}, 0, "baz", "bar"},
+		{Params{"name": "foo", 0: "bar"}, 1, "baz", "baz"},
+		{Params{"name": ""}, "name", "baz", ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tt.p.GetDefault(tt.k, tt.def))
+	}
+}
+
+func TestParams_Int(t *testing.T) {
+	tests := []struct {
+		path        string
+		expected    int
+		expectedErr bool
+	}{
+		{"/42", 42, false},
+		{"/-7", -7, false},
+		{"/abc", 0, true},
+		{"/4.2", 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			var (
+				id   int
+				id64 int64
+				err  error
+			)
+			router := NewRouter()
+			router.GET("/:id", func(c *Context) {
+				id, err = c.Request.Params.Int("id")
+				id64, _ = c.Request.Params.Int64("id")
+			})
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if tt.expectedErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expected, id)
+			assert.Equal(t, int64(tt.expected), id64)
+		})
+	}
+
+	_, err := Params{}.Int("id")
+	assert.Error(t, err)
+}
+
+func TestParams_Int64(t *testing.T) {
+	tests := []struct {
+		p           Params
+		expected    int64
+		expectedErr bool
+	}{
+		{Params{"id": "9007199254740993"}, 9007199254740993, false},
+		{Params{"id": "abc"}, 0, true},
+		{Params{}, 0, true},
+	}
+
+	for _, tt := range tests {
+		v, err := tt.p.Int64("id")
+		assert.Equal(t, tt.expectedErr, err != nil)
+		assert.Equal(t, tt.expected, v)
+	}
+}
+
+func TestParams_Bool(t *testing.T) {
+	tests := []struct {
+		p           Params
+		expected    bool
+		expectedErr bool
+	}{
+		{Params{"ok": "true"}, true, false},
+		{Params{"ok": "1"}, true, false},
+		{Params{"ok": "false"}, false, false},
+		{Params{"ok": "yes"}, false, true},
+		{Params{}, false, true},
+	}
+
+	for _, tt := range tests {
+		v, err := tt.p.Bool("ok")
+		assert.Equal(t, tt.expectedErr, err != nil)
+		assert.Equal(t, tt.expected, v)
+	}
+}
+
 func TestParams_Set(t *testing.T) {
 	tests := []struct {
 		p        Params
@@ -94,6 +189,155 @@ func TestRequest_Get(t *testing.T) {
 	}
 }
 
+func TestRequest_Hostname(t *testing.T) {
+	tests := []struct {
+		host          string
+		forwardedHost string
+		trustProxy    bool
+		expected      string
+	}{
+		{"example.com", "", false, "example.com"},
+		{"example.com:443", "", false, "example.com"},
+		{"[::1]:8080", "", false, "[::1]"},
+		{"[::1]", "", false, "[::1]"},
+		{"[::1", "", false, "[::1"},
+		{"[::1:8080", "", false, "[::1:8080"},
+		{"127.0.0.1:3000", "", false, "127.0.0.1"},
+		{"example.com:443", "proxy.example.com", false, "example.com"},
+		{"example.com:443", "proxy.example.com:8443", true, "proxy.example.com"},
+		{"example.com:443", "[2001:db8::1]:8443, other.example.com", true, "[2001:db8::1]"},
+		{"example.com:443", "", true, "example.com"},
+	}
+
+	defer SetTrustProxy(false)
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			SetTrustProxy(tt.trustProxy)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.Host = tt.host
+			if tt.forwardedHost != "" {
+				req.Header.Set("X-Forwarded-Host", tt.forwardedHost)
+			}
+			assert.Equal(t, tt.expected, NewRequest(req).Hostname())
+		})
+	}
+}
+
+func TestRequest_IP(t *testing.T) {
+	tests := []struct {
+		remoteAddr   string
+		forwardedFor string
+		trustProxy   bool
+		expected     string
+	}{
+		{"192.0.2.1:1234", "", false, "192.0.2.1"},
+		{"[::1]:1234", "", false, "::1"},
+		{"192.0.2.1", "", false, "192.0.2.1"},
+		{"192.0.2.1:1234", "203.0.113.1", false, "192.0.2.1"},
+		{"192.0.2.1:1234", "203.0.113.1, 198.51.100.1", true, "203.0.113.1"},
+		{"192.0.2.1:1234", "", true, "192.0.2.1"},
+	}
+
+	defer SetTrustProxy(false)
+	for _, tt := range tests {
+		t.Run(tt.remoteAddr, func(t *testing.T) {
+			SetTrustProxy(tt.trustProxy)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			if tt.forwardedFor != "" {
+				req.Header.Set("X-Forwarded-For", tt.forwardedFor)
+			}
+			assert.Equal(t, tt.expected, NewRequest(req).IP())
+		})
+	}
+}
+
+func TestRequest_SignedCookies(t *testing.T) {
+	secret := []byte("secret")
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "user", Value: util.SignCookie("foo", secret)})
+	req.AddCookie(&http.Cookie{Name: "cart", Value: util.SignCookie("1.2", secret)})
+	req.AddCookie(&http.Cookie{Name: "plain", Value: "bar"})
+	req.AddCookie(&http.Cookie{Name: "tampered", Value: "admin." + util.SignCookie("foo", secret)[4:]})
+	req.AddCookie(&http.Cookie{Name: "other", Value: util.SignCookie("baz", []byte("other"))})
+	r := NewRequest(req)
+
+	assert.Equal(t, map[string]string{"user": "foo", "cart": "1.2"}, r.SignedCookies(secret))
+
+	value, ok := r.SignedCookie("user", secret)
+	assert.True(t, ok)
+	assert.Equal(t, "foo", value)
+	_, ok = r.SignedCookie("tampered", secret)
+	assert.False(t, ok)
+	_, ok = r.SignedCookie("missing", secret)
+	assert.False(t, ok)
+}
+
+func TestRequest_Links(t *testing.T) {
+	tests := []struct {
+		links    []string
+		expected map[string]string
+	}{
+		{nil, map[string]string{}},
+		{
+			[]string{`<http://api.example.com/users?page=2>; rel="next", <http://api.example.com/users?page=5>; rel="last"`},
+			map[string]string{
+				"next": "http://api.example.com/users?page=2",
+				"last": "http://api.example.com/users?page=5",
+			},
+		},
+		{
+			[]string{`<http://api.example.com/users?page=1>; rel="prev"`, `<http://api.example.com/users?page=3>; rel="next"`},
+			map[string]string{
+				"prev": "http://api.example.com/users?page=1",
+				"next": "http://api.example.com/users?page=3",
+			},
+		},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/", nil)
+		for _, link := range tt.links {
+			req.Header.Add("Link", link)
+		}
+		assert.Equal(t, tt.expected, NewRequest(req).Links())
+	}
+
+	// the inverse of c.Links
+	w := httptest.NewRecorder()
+	links := map[string]string{"next": "http://api.example.com/users?page=2", "last": "http://api.example.com/users?page=5"}
+	NewContext(emptyRequest, w).Links(links)
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("Link", w.Header().Get("Link"))
+	assert.Equal(t, links, NewRequest(req).Links())
+}
+
+func TestRequest_ReparseQuery(t *testing.T) {
+	router := NewRouter()
+	router.Use(func(c *Context) {
+		assert.Equal(t, "a=1", c.Request.RawQuery())
+		c.Request.URL.RawQuery += "&b=2"
+		assert.Equal(t, "a=1&b=2", c.Request.RawQuery())
+		assert.Equal(t, "", c.Query("b"))
+		c.Next()
+	})
+	router.GET("/", func(c *Context) {
+		var obj struct {
+			B string `form:"b"`
+		}
+		require.NoError(t, c.BindQuery(&obj))
+		assert.Equal(t, "2", obj.B)
+
+		query := c.Request.ReparseQuery()
+		assert.Equal(t, "2", query.Get("b"))
+		assert.Equal(t, "1", c.Query("a"))
+		assert.Equal(t, "2", c.Query("b"))
+		c.SendStatus(http.StatusNoContent)
+	})
+
+	w := Test(router).Get("/?a=1").Do()
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
 func TestRequest_ContentType(t *testing.T) {
 	tests := []struct {
 		contentType string
@@ -222,6 +466,90 @@ func TestRequest_AcceptsLanguages(t *testing.T) {
 	}
 }
 
+func TestRequest_AcceptedTypes(t *testing.T) {
+	tests := []struct {
+		accept   string
+		expected []string
+	}{
+		{"text/*;q=0.5, image/png", []string{"image/png", "text/*"}},
+		{"text/html, application/json;q=0.9, text/plain;q=0.9, */*;q=0", []string{"text/html", "application/json", "text/plain"}},
+		{"application/json;q=0.2, application/xml;q=0.8, text/html", []string{"text/html", "application/xml", "application/json"}},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Accept", tt.accept)
+		assert.Equal(t, tt.expected, NewRequest(req).AcceptedTypes())
+	}
+}
+
+func TestRequest_AcceptedEncodings(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Encoding", "deflate;q=0.5, gzip, br;q=0.8")
+	assert.Equal(t, []string{"gzip", "br", "deflate", "identity"}, NewRequest(req).AcceptedEncodings())
+}
+
+func TestRequest_AcceptedCharsets(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Charset", "iso-8859-1;q=0.5, utf-8")
+	assert.Equal(t, []string{"utf-8", "iso-8859-1"}, NewRequest(req).AcceptedCharsets())
+}
+
+func TestRequest_AcceptedLanguages(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept-Language", "en;q=0.8, zh-CN, fr;q=0.8")
+	assert.Equal(t, []string{"zh-CN", "en", "fr"}, NewRequest(req).AcceptedLanguages())
+}
+
+func TestRequest_Language(t *testing.T) {
+	tests := []struct {
+		accept    string
+		available []string
+		def       string
+		expected  string
+	}{
+		{"", []string{"en", "zh"}, "fr", "en"},
+		{"zh", []string{"en", "zh"}, "en", "zh"},
+		{"zh-CN, en", []string{"en", "zh-CN"}, "fr", "zh-CN"},
+		{"en;q=0.5, zh;q=0.8", []string{"en", "zh"}, "fr", "zh"},
+		{"en;q=0.8, zh;q=0.8", []string{"zh", "en"}, "fr", "en"},
+		{"de, ja", []string{"en", "zh"}, "en", "en"},
+		{"en, zh;q=0", []string{"zh"}, "fr", "fr"},
+		{"en", nil, "fr", "fr"},
+	}
+
+	for _, tt := range tests {
+		req := NewRequest(httptest.NewRequest(http.MethodGet, "/", nil))
+		if tt.accept != "" {
+			req.Header.Set(negotiator.HeaderAcceptLanguage, tt.accept)
+		}
+		assert.Equal(t, tt.expected, req.Language(tt.available, tt.def))
+	}
+}
+
+func TestRequest_Charset(t *testing.T) {
+	tests := []struct {
+		accept    string
+		available []string
+		def       string
+		expected  string
+	}{
+		{"", []string{"utf-8", "iso-8859-1"}, "utf-8", "utf-8"},
+		{"iso-8859-1", []string{"utf-8", "iso-8859-1"}, "utf-8", "iso-8859-1"},
+		{"utf-8;q=0.5, iso-8859-1;q=0.8", []string{"utf-8", "iso-8859-1"}, "utf-8", "iso-8859-1"},
+		{"gbk", []string{"utf-8", "iso-8859-1"}, "utf-8", "utf-8"},
+		{"utf-8", nil, "gbk", "gbk"},
+	}
+
+	for _, tt := range tests {
+		req := NewRequest(httptest.NewRequest(http.MethodGet, "/", nil))
+		if tt.accept != "" {
+			req.Header.Set(negotiator.HeaderAcceptCharset, tt.accept)
+		}
+		assert.Equal(t, tt.expected, req.Charset(tt.available, tt.def))
+	}
+}
+
 func TestRequest_ResetParams(t *testing.T) {
 	tests := []struct {
 		p        Params
@@ -353,6 +681,38 @@ func TestRequest_BaseUrl(t *testing.T) {
 	})
 }
 
+func TestRequest_RelativePath(t *testing.T) {
+	tests := []struct {
+		mount                string
+		route                string
+		url                  string
+		expectedRelativePath string
+		expectedOriginalUrl  string
+	}{
+		{"/api", "/users", "/api/users", "/users", "/api/users"},
+		{"/api", "/users", "/api/users?page=2&size=10", "/users", "/api/users?page=2&size=10"},
+		{"/:version", "/users/:id", "/v1/users/1?fields=name", "/users/1", "/v1/users/1?fields=name"},
+		{"/", "/users", "/users?page=2", "/users", "/users?page=2"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			var relativePath, originalUrl string
+			sub := NewRouter()
+			sub.GET(tt.route, func(c *Context) {
+				relativePath, originalUrl = c.Request.RelativePath(), c.Request.OriginalUrl
+			})
+			router := NewRouter()
+			router.Use(tt.mount, sub)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expectedRelativePath, relativePath)
+			assert.Equal(t, tt.expectedOriginalUrl, originalUrl)
+		})
+	}
+}
+
 func TestRequest_Fresh(t *testing.T) {
 	t.Run("should return true when the resource is not modified", func(t *testing.T) {
 		router, etag := NewRouter(), `"12345"`
@@ -8,6 +8,7 @@ package soon
 
 import (
 	"bufio"
+	"bytes"
 	"io"
 	"net"
 	"net/http"
@@ -76,7 +77,7 @@ func (r *response) HeaderWritten() bool {
 func (r *response) WriteHeader(statusCode int) {
 	if statusCode > 0 && r.status != statusCode {
 		if r.Written() {
-			debugPrint("[WARNING] Headers were already written. Wanted to "+
+			debugPrintWARNING("Headers were already written. Wanted to "+
 				"override status code %d with %d", r.status, statusCode)
 		}
 		r.status = statusCode
@@ -109,6 +110,19 @@ func (r *response) WriteString(s string) (n int, err error) {
 	return
 }
 
+// ReadFrom implements the io.ReaderFrom interface, so that the underlying
+// http.ResponseWriter can send a file with sendfile if it's supported.
+func (r *response) ReadFrom(src io.Reader) (n int64, err error) {
+	r.WriteHeaderNow()
+	if rf, ok := r.ResponseWriter.(io.ReaderFrom); ok {
+		n, err = rf.ReadFrom(src)
+	} else {
+		n, err = io.Copy(r.ResponseWriter, src)
+	}
+	r.size += int(n)
+	return
+}
+
 // Hijack implements the http.Hijacker interface.
 func (r *response) Hijack() (net.Conn, *bufio.ReadWriter, error) {
 	if r.size < 0 {
@@ -138,3 +152,16 @@ func (r *response) Size() int {
 func (r *response) Written() bool {
 	return r.size != noWritten
 }
+
+// bufferedResponseWriter is a http.ResponseWriter which writes the body into
+// memory, so that the length of body is known before it's sent.
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+
+	buf bytes.Buffer
+}
+
+// Write writes the data into buffer.
+func (w *bufferedResponseWriter) Write(data []byte) (int, error) {
+	return w.buf.Write(data)
+}
@@ -5,6 +5,9 @@
 package soon
 
 import (
+	"bytes"
+	"io"
+	"net/http"
 	"net/http/httptest"
 	"testing"
 
@@ -43,6 +46,22 @@ func TestResponse_WriteHeader(t *testing.T) {
 			"Wanted to override status code 100 with 200\n"
 		assert.Equal(t, expected, got)
 	})
+
+	t.Run("error-writer", func(t *testing.T) {
+		var out, errOut bytes.Buffer
+		stdout, stderr := captureWriters(&out, &errOut)
+		defer restoreWriters(stdout, stderr)
+
+		SetMode(DebugMode)
+		r := newResponse(httptest.NewRecorder())
+		_, _ = r.WriteString("hello")
+		r.WriteHeader(500)
+		SetMode(TestMode)
+
+		assert.Equal(t, "", out.String())
+		assert.Equal(t, "[SOON-debug] [WARNING] Headers were already written. "+
+			"Wanted to override status code 200 with 500\n", errOut.String())
+	})
 }
 
 func TestResponse_WriteHeaderNow(t *testing.T) {
@@ -117,6 +136,40 @@ func TestResponse_WriteString(t *testing.T) {
 	}
 }
 
+type testReaderFrom struct {
+	*httptest.ResponseRecorder
+
+	calls int
+}
+
+func (w *testReaderFrom) ReadFrom(src io.Reader) (int64, error) {
+	w.calls++
+	return io.Copy(w.ResponseRecorder, src)
+}
+
+func TestResponse_ReadFrom(t *testing.T) {
+	data := "hello world"
+
+	r := newResponse(httptest.NewRecorder())
+	recorder := r.ResponseWriter.(*httptest.ResponseRecorder)
+	r.WriteHeader(302)
+	n, err := r.ReadFrom(bytes.NewBufferString(data))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(data)), n)
+	assert.Equal(t, len(data), r.Size())
+	assert.Equal(t, 302, recorder.Code)
+	assert.Equal(t, data, recorder.Body.String())
+
+	w := &testReaderFrom{ResponseRecorder: httptest.NewRecorder()}
+	r = newResponse(w)
+	n, err = r.ReadFrom(bytes.NewBufferString(data))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(data)), n)
+	assert.Equal(t, len(data), r.Size())
+	assert.Equal(t, 1, w.calls)
+	assert.Equal(t, data, w.Body.String())
+}
+
 func TestResponse_Hijack(t *testing.T) {
 	router := NewRouter()
 	router.Use(func(c *Context) {
@@ -158,6 +211,17 @@ func TestResponse_Flush(t *testing.T) {
 	}
 }
 
+type testPusher struct {
+	*httptest.ResponseRecorder
+
+	targets []string
+}
+
+func (p *testPusher) Push(target string, opts *http.PushOptions) error {
+	p.targets = append(p.targets, target)
+	return nil
+}
+
 func TestResponse_Status(t *testing.T) {
 	tests := []struct {
 		code         int
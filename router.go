@@ -5,8 +5,15 @@
 package soon
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"path"
+	"regexp"
+	"runtime/debug"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/dlclark/regexp2"
 	"github.com/soongo/soon/internal"
@@ -19,10 +26,34 @@ import (
 // http request, and dispatch a context object into the handler.
 type Handle func(*Context)
 
+// HandleE is the handler function which returns an error instead of panicking,
+// use E to adapt it to Handle.
+type HandleE func(*Context) error
+
+// E adapts the given HandleE to Handle. A non-nil error returned by the handler
+// is dispatched to the error handlers, exactly as a panic would be, for example:
+//
+//	router.GET("/", soon.E(func(c *soon.Context) error {
+//		return soon.NewError(400, "bad request")
+//	}))
+func E(h HandleE) Handle {
+	return func(c *Context) {
+		if err := h(c); err != nil {
+			c.Next(err)
+		}
+	}
+}
+
 type paramHandle func(*Context, string)
 
 // ErrorHandle handles the error generated in route handler, and dispatch error
 // and context objects into the error handler.
+//
+// The error handlers matching the request path run in the order of
+// registration. In an error handler, c.Next(err) passes the error to the next
+// matching error handler, or the one set by SetErrorHandler at the end, while
+// c.Next() clears the error, and resumes the normal handlers registered after
+// it, skipping the remaining error handlers.
 type ErrorHandle func(interface{}, *Context)
 
 type node struct {
@@ -38,59 +69,219 @@ type node struct {
 	tokens         []pathToRegexp.Token
 	originalTokens []pathToRegexp.Token
 	router         *Router
+	mergeParams    *bool
+
+	// the regexp matching the mount points of route mounted at non-root
+	// path, the regexp of originalRoute is shared with the source node to
+	// match the remainder of path in this case. It's nil if the regexp
+	// matches the whole route.
+	mountRegexp *regexp2.Regexp
+
+	// the case-insensitive regexp and the parsed route used by fixPath,
+	// they're compiled only if the router holding the node redirects the
+	// fixed path.
+	fixRegexp *regexp2.Regexp
+	fixTokens []interface{}
+
+	// the route registered by Handle, it's shared by the copies of mounting,
+	// and nil for middlewares and error handlers.
+	meta *Route
+
+	// numbers of params captured by tokens and originalTokens, excluding
+	// the appended wildcard, the regexp match is skipped if it's zero.
+	nParams         int
+	nOriginalParams int
+
+	// numeric indexes of the named wildcards in tokens and originalTokens,
+	// such as `*filepath`, whose params are also set at the indexes.
+	wildcards         map[interface{}]int
+	originalWildcards map[interface{}]int
+
+	// the options which the regexps are compiled with
+	sensitive bool
+	strict    bool
+}
+
+// baseUrlKey identifies the compiled baseUrlRegexp and mountRegexp of nodes.
+type baseUrlKey struct {
+	route     string
+	sensitive bool
+	strict    bool
+	wildcard  bool
+	mount     bool
 }
 
 func (n *node) initRegexp() {
+	n.initRegexpFrom(nil, nil)
+}
+
+// initRegexpFrom compiles the regexps of node, reusing the compiled ones of
+// `src` node which it's copied from, as long as they are built from the same
+// route and options. The route mounted at non-root path shares the regexp
+// of originalRoute with `src`, only the mount points are compiled. The
+// baseUrlRegexps caches the compiled baseUrlRegexp and mountRegexp shared
+// by nodes, it may be nil.
+func (n *node) initRegexpFrom(src *node, baseUrlRegexps map[baseUrlKey]*regexp2.Regexp) {
 	var options *pathToRegexp.Options
 	if n.router.routerOption != nil {
 		options = n.router.routerOption.toPathToRegexpOption()
 	}
-	n.regexp = pathToRegexp.Must(pathToRegexp.PathToRegexp(n.route, &n.tokens, options))
-	pathToRegexp.Must(pathToRegexp.PathToRegexp(n.originalRoute, &n.originalTokens, options))
+	n.sensitive = options != nil && options.Sensitive
+	n.strict = options != nil && options.Strict
+
+	reusable := src != nil && src.sensitive == n.sensitive && src.strict == n.strict &&
+		src.originalRoute == n.originalRoute && src.isMiddleware == n.isMiddleware &&
+		src.appendWildcard == n.appendWildcard
+	if reusable && src.route == n.route {
+		n.regexp, n.tokens, n.originalTokens = src.regexp, src.tokens, src.originalTokens
+		n.baseUrlRegexp, n.mountRegexp = src.baseUrlRegexp, src.mountRegexp
+		n.nParams, n.nOriginalParams = src.nParams, src.nOriginalParams
+		n.wildcards, n.originalWildcards = src.wildcards, src.originalWildcards
+		return
+	}
 
-	n.baseUrlRegexp = nil
 	baseUrlRoute := strings.TrimSuffix(n.route, n.originalRoute)
+	n.mountRegexp = nil
+	if reusable && src.matchesOriginalRoute() && !n.isMiddleware && !n.isErrorHandler() &&
+		n.originalRoute != "/" && baseUrlRoute != "" && strings.HasSuffix(n.route, n.originalRoute) {
+		n.regexp, n.originalTokens = src.regexp, src.originalTokens
+		n.tokens = parseTokens(n.route, options)
+		key := baseUrlKey{route: baseUrlRoute, sensitive: n.sensitive, strict: n.strict, mount: true}
+		if n.mountRegexp = baseUrlRegexps[key]; n.mountRegexp == nil {
+			end := false
+			n.mountRegexp = compileRoute(baseUrlRoute, nil, &pathToRegexp.Options{
+				Sensitive: n.sensitive,
+				Strict:    n.strict,
+				End:       &end,
+			})
+			if baseUrlRegexps != nil {
+				baseUrlRegexps[key] = n.mountRegexp
+			}
+		}
+	} else {
+		n.tokens = nil
+		n.regexp = compileRoute(n.route, &n.tokens, options)
+		if reusable {
+			n.originalTokens = src.originalTokens
+		} else {
+			n.originalTokens = nil
+			compileRoute(n.originalRoute, &n.originalTokens, options)
+		}
+	}
+	n.nParams, n.nOriginalParams = len(n.tokens), len(n.originalTokens)
+	if n.appendWildcard {
+		n.nParams--
+		n.nOriginalParams--
+	}
+	n.wildcards = wildcardIndexes(n.tokens[:n.nParams])
+	n.originalWildcards = wildcardIndexes(n.originalTokens[:n.nOriginalParams])
+
+	n.baseUrlRegexp = nil
 	if n.isMiddleware {
 		baseUrlRoute = n.route
 		if n.appendWildcard {
 			baseUrlRoute = strings.TrimSuffix(n.route, "/(.*)")
 		}
 	}
-	if baseUrlRoute != "" {
-		n.baseUrlRegexp = pathToRegexp.Must(pathToRegexp.PathToRegexp(baseUrlRoute, nil, options))
-		ro := regexp2.None
-		if options == nil || !options.Sensitive {
-			ro = regexp2.IgnoreCase
+	if baseUrlRoute == "" {
+		return
+	}
+
+	key := baseUrlKey{route: baseUrlRoute, sensitive: n.sensitive, strict: n.strict,
+		wildcard: !n.isMiddleware || n.appendWildcard}
+	if re, ok := baseUrlRegexps[key]; ok {
+		n.baseUrlRegexp = re
+		return
+	}
+
+	ro := regexp2.None
+	if !n.sensitive {
+		ro = regexp2.IgnoreCase
+	}
+	route := "(" + strings.TrimSuffix(compileRoute(baseUrlRoute, nil, options).String(), "$") + ")"
+	if key.wildcard {
+		route += "/(.*)"
+	}
+	n.baseUrlRegexp = regexp2.MustCompile(route, ro)
+	if baseUrlRegexps != nil {
+		baseUrlRegexps[key] = n.baseUrlRegexp
+	}
+}
+
+// matchesOriginalRoute reports whether the regexp of node matches the
+// originalRoute, so that it can be shared by the nodes mounting it.
+func (n *node) matchesOriginalRoute() bool {
+	return n.route == n.originalRoute || n.mountRegexp != nil
+}
+
+// parseTokens returns the tokens of route without compiling the regexp.
+func parseTokens(route string, options *pathToRegexp.Options) []pathToRegexp.Token {
+	var tokens []pathToRegexp.Token
+	for _, v := range pathToRegexp.Parse(route, options) {
+		if t, ok := v.(pathToRegexp.Token); ok {
+			tokens = append(tokens, t)
+		}
+	}
+	return tokens
+}
+
+// routeCompilations counts the routes compiled by compileRoute, so that the
+// tests can assert the regexps are shared instead of recompiled.
+var routeCompilations int64
+
+func compileRoute(route string, tokens *[]pathToRegexp.Token, options *pathToRegexp.Options) *regexp2.Regexp {
+	atomic.AddInt64(&routeCompilations, 1)
+	return pathToRegexp.Must(pathToRegexp.PathToRegexp(route, tokens, options))
+}
+
+// wildcardIndexes returns the numeric indexes of the named wildcards in
+// tokens, which capture the remainder of path, such as `*filepath` or
+// `:filepath*`. The indexes follow the ones of the unnamed params, so that
+// they are numbered as the unnamed wildcard `(.*)`.
+func wildcardIndexes(tokens []pathToRegexp.Token) map[interface{}]int {
+	var indexes map[interface{}]int
+	next := 0
+	for _, t := range tokens {
+		if _, ok := t.Name.(string); !ok {
+			next++
 		}
-		route := "(" + strings.TrimSuffix(n.baseUrlRegexp.String(), "$") + ")"
-		if !n.isMiddleware || n.appendWildcard {
-			route += "/(.*)"
+	}
+	for _, t := range tokens {
+		if _, ok := t.Name.(string); ok && (t.Pattern == ".*" || t.Repeat) {
+			if indexes == nil {
+				indexes = make(map[interface{}]int)
+			}
+			indexes[t.Name] = next
+			next++
 		}
-		n.baseUrlRegexp = regexp2.MustCompile(route, ro)
 	}
+	return indexes
 }
 
-func (n *node) buildRequestProperties(c *Context, urlPath string) {
-	match, err := n.regexp.FindStringMatch(urlPath)
+// setParam sets the param of name, and also of the numeric index if it's a
+// named wildcard.
+func setParam(c *Context, wildcards map[interface{}]int, name interface{}, value string) {
+	c.Request.Params.Set(name, value)
+	if index, ok := wildcards[name]; ok {
+		c.Request.Params.Set(index, value)
+	}
+}
 
-	if n.router.routerOption != nil && n.router.routerOption.MergeParams {
-		if err == nil && match != nil && len(n.tokens) > 0 {
-			nGroup := match.GroupCount()
-			for i, g := range match.Groups() {
-				if i > 0 && (!n.appendWildcard || i < nGroup-1) {
-					c.Request.Params.Set(n.tokens[i-1].Name, g.String())
-				}
+func (n *node) buildRequestProperties(c *Context, urlPath string) {
+	if n.shouldMergeParams() {
+		values := n.findParams(urlPath, n.nParams)
+		for i, v := range values {
+			if !n.appendWildcard || i < len(values)-1 {
+				setParam(c, n.wildcards, n.tokens[i].Name, v)
 			}
 		}
 	} else {
 		c.Request.resetParams()
-		nToken := len(n.originalTokens)
-		if err == nil && match != nil && nToken > 0 {
-			nGroup := match.GroupCount()
-			for i, g := range match.Groups() {
-				if i > 0 && (!n.appendWildcard || i < nGroup-1) && i >= nGroup-nToken {
-					c.Request.Params.Set(n.originalTokens[i-nGroup+nToken].Name, g.String())
-				}
+		values := n.findParams(urlPath, n.nOriginalParams)
+		nValue, nToken := len(values), len(n.originalTokens)
+		for i, v := range values {
+			if (!n.appendWildcard || i < nValue-1) && i >= nValue-nToken {
+				setParam(c, n.originalWildcards, n.originalTokens[i-nValue+nToken].Name, v)
 			}
 		}
 	}
@@ -104,7 +295,57 @@ func (n *node) buildRequestProperties(c *Context, urlPath string) {
 	}
 }
 
+// findParams matches urlPath against the route regexps to capture the values
+// of tokens in order, the match is skipped for the static route which has
+// no params.
+func (n *node) findParams(urlPath string, nParams int) []string {
+	if nParams <= 0 {
+		return nil
+	}
+
+	var values []string
+	if n.mountRegexp != nil {
+		match, err := n.mountRegexp.FindStringMatch(urlPath)
+		if err != nil || match == nil {
+			return nil
+		}
+		values = groupValues(match)
+		urlPath = urlPath[match.Length:]
+	}
+	match, err := n.regexp.FindStringMatch(urlPath)
+	if err != nil || match == nil {
+		return nil
+	}
+	return append(values, groupValues(match)...)
+}
+
+// groupValues returns the values of the capturing groups of match.
+func groupValues(match *regexp2.Match) []string {
+	groups := match.Groups()
+	values := make([]string, 0, len(groups)-1)
+	for _, g := range groups[1:] {
+		values = append(values, g.String())
+	}
+	return values
+}
+
+// shouldMergeParams reports whether the params of parent router should be
+// preserved, the mount option takes precedence over the router option.
+func (n *node) shouldMergeParams() bool {
+	if n.mergeParams != nil {
+		return *n.mergeParams
+	}
+	return n.router.routerOption != nil && n.router.routerOption.MergeParams
+}
+
 func (n *node) match(path string) bool {
+	if n.mountRegexp != nil {
+		match, err := n.mountRegexp.FindStringMatch(path)
+		if err != nil || match == nil {
+			return false
+		}
+		path = path[match.Length:]
+	}
 	m, err := n.regexp.MatchString(path)
 	return err == nil && m
 }
@@ -113,6 +354,141 @@ func (n *node) isErrorHandler() bool {
 	return n.errorHandle != nil
 }
 
+// matchMethod reports whether the node handles the given http method.
+func (n *node) matchMethod(method string) bool {
+	if n.method == HTTPMethodAll || n.method == method {
+		return true
+	}
+	o := n.router.routerOption
+	return method == http.MethodHead && n.method == http.MethodGet && o != nil && o.AutoHead
+}
+
+// initFixPath compiles the regexp and parses the route used by fixPath, only
+// if the router `r` holding the node redirects the fixed path.
+func (n *node) initFixPath(r *Router) {
+	if r.routerOption == nil || !r.routerOption.RedirectFixedPath ||
+		n.isMiddleware || n.isErrorHandler() || n.fixRegexp != nil {
+		return
+	}
+
+	if n.sensitive || n.mountRegexp != nil {
+		n.fixRegexp = compileRoute(n.route, nil, &pathToRegexp.Options{Strict: n.strict})
+	} else {
+		n.fixRegexp = n.regexp
+	}
+	n.fixTokens = pathToRegexp.Parse(n.route, nil)
+}
+
+// fixPath matches the given path case-insensitively, and returns the path
+// rebuilt with the casing of the registered route. It returns an error if
+// the matching fails, or a required param isn't captured.
+func (n *node) fixPath(urlPath string) (string, bool, error) {
+	if n.fixRegexp == nil {
+		return "", false, nil
+	}
+
+	match, err := n.fixRegexp.FindStringMatch(urlPath)
+	if err != nil || match == nil {
+		return "", false, err
+	}
+
+	var b strings.Builder
+	groups, i := match.Groups(), 0
+	for _, v := range n.fixTokens {
+		t, ok := v.(pathToRegexp.Token)
+		if !ok {
+			b.WriteString(v.(string))
+			continue
+		}
+		if i++; i < len(groups) && len(groups[i].Captures) > 0 {
+			b.WriteString(t.Prefix + groups[i].String())
+		} else if !t.Optional {
+			return "", false, fmt.Errorf("param %v of route %s isn't captured", t.Name, n.route)
+		}
+	}
+	return b.String(), true, nil
+}
+
+// path returns the route path of node without the wildcard appended to
+// middleware and error handler.
+func (n *node) path() string {
+	p := n.route
+	if n.appendWildcard {
+		p = strings.TrimSuffix(p, "/(.*)")
+	}
+	return util.AddPrefixSlash(p)
+}
+
+// RouteInfo represents a route registered on router.
+type RouteInfo struct {
+	// The http method of route, it's empty for middleware and error handler.
+	Method string
+
+	// The full route path, including the mount point of sub-router.
+	Path string
+
+	// Indicates the route is a middleware.
+	IsMiddleware bool
+
+	// Indicates the route is an error handler.
+	IsErrorHandler bool
+
+	// The metadata attached by Route.WithMeta.
+	Meta map[string]interface{}
+}
+
+// Route is a route registered by router.Handle or its shortcuts, such as
+// router.GET, which can carry arbitrary metadata for the middlewares, e.g.
+//
+//	router.GET("/admin", handle).WithMeta("scope", "admin")
+//
+// and the middleware can read it by c.Route().Meta("scope").
+type Route struct {
+	mu   sync.RWMutex
+	meta map[string]interface{}
+}
+
+// WithMeta attaches the value of key to the route, and returns the route
+// for chaining.
+func (r *Route) WithMeta(key string, value interface{}) *Route {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.meta == nil {
+		r.meta = make(map[string]interface{})
+	}
+	r.meta[key] = value
+	return r
+}
+
+// Meta returns the value of key attached by WithMeta, ie: (value, true).
+// If the value does not exists or r is nil it returns (nil, false).
+func (r *Route) Meta(key string) (value interface{}, exists bool) {
+	if r == nil {
+		return nil, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	value, exists = r.meta[key]
+	return
+}
+
+// metaCopy returns a copy of the metadata, or nil if there is none.
+func (r *Route) metaCopy() map[string]interface{} {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.meta) == 0 {
+		return nil
+	}
+	m := make(map[string]interface{}, len(r.meta))
+	for k, v := range r.meta {
+		m[k] = v
+	}
+	return m
+}
+
 // RouterOption contains options for router, such as `Sensitive` and `Strict`
 type RouterOption struct {
 	// When true the regexp will be case sensitive. (default: false)
@@ -124,6 +500,32 @@ type RouterOption struct {
 
 	// When true the regexp won't allow an optional trailing delimiter to match. (default: false)
 	Strict bool
+
+	// When true the Sensitive and Strict are inherited from the parent router
+	// when it's mounted, the other options are kept. Note that a router without
+	// options inherits all options of the parent router. (default: false)
+	Inherit bool
+
+	// When true the routes registered for GET also match HEAD requests, the
+	// response body is discarded while the headers are kept. (default: false)
+	AutoHead bool
+
+	// When true, an OPTIONS request which matches no OPTIONS route is responded
+	// with the Allow header listing the methods of the routes matching the path,
+	// such as "GET,POST". (default: false)
+	AutoOptions bool
+
+	// When true, a request which matches no route will be retried with a cleaned
+	// and case-insensitive path, and redirected to the registered route if found.
+	// GET and HEAD requests are redirected with 301, others with 308. (default: false)
+	RedirectFixedPath bool
+}
+
+// MountOptions contains options for mounting a sub-router, see Router.Mount.
+type MountOptions struct {
+	// Preserve the req.params values from the parent router for the routes
+	// of the mounted router, it overrides the MergeParams of RouterOption.
+	MergeParams bool
 }
 
 func (o *RouterOption) toPathToRegexpOption() *pathToRegexp.Options {
@@ -138,6 +540,22 @@ type Router struct {
 	routerOption *RouterOption
 
 	paramHandles map[string][]paramHandle
+
+	errorHandler ErrorHandle
+
+	errorStatuses []errorStatus
+
+	notFoundHandler Handle
+
+	// the app which the router belongs to, it's nil for the router created
+	// by NewRouter
+	app *App
+}
+
+// errorStatus maps the errors matching target by errors.Is to status.
+type errorStatus struct {
+	target error
+	status int
 }
 
 const (
@@ -147,25 +565,69 @@ const (
 
 var _ http.Handler = NewRouter()
 
+var namedWildcardRegexp = regexp.MustCompile(`(^|/)\*(\w+)`)
+
+// expandNamedWildcard transforms the named wildcard segment in route, such as
+// `/files/*filepath`, to a named parameter which matches the remainder of path,
+// such as `/files/:filepath(.*)`.
+func expandNamedWildcard(route string) string {
+	return namedWildcardRegexp.ReplaceAllString(route, "$1:$2(.*)")
+}
+
 // Function to handle error when no other error handlers.
+// It responds JSON if the client accepts it, otherwise plain text. The panic
+// resulting in a 5xx status is responded with the status text only, so that
+// the internal details aren't exposed, but in debug mode it's responded with
+// its stack trace as JSON or HTML.
 func defaultErrorHandler(v interface{}, c *Context) {
 	if !c.finished {
-		status := http.StatusInternalServerError
-		text := http.StatusText(status)
-		switch err := v.(type) {
-		case HttpError:
-			text, status = err.Error(), err.Status()
-		case error:
-			text = err.Error()
-		case string:
-			text = err
-		}
-
-		http.Error(c.Writer, text, status)
+		status, text := resolvePublicError(v, c)
+		accepts := c.Request.Accepts("text/plain", "application/json")
+		if IsDebugging() && c.panicStack != nil && status >= 500 {
+			accept := ""
+			if len(accepts) > 0 {
+				accept = accepts[0]
+			}
+			renderPanic(c, status, text, accept, v)
+		} else if len(accepts) > 0 && accepts[0] == "application/json" {
+			if e, ok := v.(*BindingError); ok && bindingErrorJSON {
+				renderBindingError(c, e)
+			} else {
+				c.JSONError(status, text)
+			}
+		} else {
+			http.Error(c.Writer, text, status)
+		}
 		c.finished = true
 	}
 }
 
+// resolvePublicError is similar with resolveError, but the text of the panic
+// resulting in a 5xx status is replaced with the status text outside debug
+// mode, so that the internal details aren't exposed to the client.
+func resolvePublicError(v interface{}, c *Context) (status int, text string) {
+	status, text = resolveError(v)
+	if c.panicked && status >= 500 && !IsDebugging() {
+		text = http.StatusText(status)
+	}
+	return
+}
+
+// resolveError returns the http status code and text of the given error value.
+func resolveError(v interface{}) (status int, text string) {
+	status = http.StatusInternalServerError
+	text = http.StatusText(status)
+	switch err := v.(type) {
+	case HttpError:
+		text, status = err.Error(), err.Status()
+	case error:
+		text = err.Error()
+	case string:
+		text = err
+	}
+	return
+}
+
 // NewRouter returns a new initialized Router with default configuration.
 // Sensitive and Strict is false by default.
 func NewRouter(options ...*RouterOption) *Router {
@@ -176,14 +638,82 @@ func NewRouter(options ...*RouterOption) *Router {
 	return router
 }
 
+// handleError handles the error reaching the end of chain by the error handler
+// set by SetErrorHandler, or defaultErrorHandler if it's not set or panics.
+func (r *Router) handleError(v interface{}, c *Context) {
+	defer c.clearPanic()
+
+	v = r.mapError(v)
+	if r.errorHandler == nil {
+		defaultErrorHandler(v, c)
+		return
+	}
+
+	defer func() {
+		if rcv := recover(); rcv != nil {
+			c.setPanic()
+			defaultErrorHandler(rcv, c)
+		}
+	}()
+	r.errorHandler(v, c)
+}
+
+// mapError converts the error without status to HttpError with the status
+// mapped by App.MapError, the first matched mapping wins.
+func (r *Router) mapError(v interface{}) interface{} {
+	err, ok := v.(error)
+	if !ok || len(r.errorStatuses) == 0 {
+		return v
+	}
+	if _, ok := err.(HttpError); ok {
+		return v
+	}
+
+	for _, m := range r.errorStatuses {
+		if errors.Is(err, m.target) {
+			return internal.NewStatusError(m.status, err)
+		}
+	}
+	return v
+}
+
+// handleNotFound handles the request which matches no route by the handler
+// set by NotFound, or as the not found error.
+func (r *Router) handleNotFound(c *Context) {
+	if r.notFoundHandler == nil {
+		r.handleError(internal.ErrNotFound, c)
+		return
+	}
+
+	c.Status(http.StatusNotFound)
+	r.notFoundHandler(c)
+}
+
 func (r *Router) recv(c *Context) {
 	if rcv := recover(); rcv != nil {
+		c.setPanic()
 		c.next(rcv)
 	}
 }
 
+// setPanic records the panic being recovered, the stack trace is only captured
+// in debug mode.
+func (c *Context) setPanic() {
+	c.panicked = true
+	if IsDebugging() {
+		c.panicStack = debug.Stack()
+	}
+}
+
+// clearPanic clears the recovered panic after its error is handled, so that
+// it isn't mistaken for the later errors of the same request.
+func (c *Context) clearPanic() {
+	c.panicked, c.panicStack = false, nil
+}
+
 // Use the given middleware, or error handler, or mount another router,
-// with optional path, defaulting to "/".
+// with optional path, defaulting to "/". The middleware may also return
+// an error, see HandleE.
 func (r *Router) Use(params ...interface{}) {
 	length := len(params)
 	if length > 2 || length == 0 {
@@ -210,10 +740,16 @@ func (r *Router) Use(params ...interface{}) {
 	}
 
 	if h, ok := handle.(func(*Context)); ok {
-		r.useMiddleware(route, h)
+		r.useMiddleware("", route, h)
 		return
 	} else if h, ok := handle.(Handle); ok {
-		r.useMiddleware(route, h)
+		r.useMiddleware("", route, h)
+		return
+	} else if h, ok := handle.(func(*Context) error); ok {
+		r.useMiddleware("", route, E(h))
+		return
+	} else if h, ok := handle.(HandleE); ok {
+		r.useMiddleware("", route, E(h))
 		return
 	}
 
@@ -232,13 +768,23 @@ func (r *Router) Use(params ...interface{}) {
 	panic(msg)
 }
 
-func (r *Router) useMiddleware(route string, h Handle) {
+// UseMethod is like Use, but the middleware only runs for the requests of
+// the given method, such as parsing body or checking CSRF token only for
+// the POST requests:
+//
+//	router.UseMethod(http.MethodPost, "/api", csrf)
+func (r *Router) UseMethod(method, route string, handle Handle) {
+	r.useMiddleware(method, util.AddPrefixSlash(route), handle)
+}
+
+func (r *Router) useMiddleware(method, route string, h Handle) {
 	appendWildcard := false
 	if !strings.HasSuffix(route, "/(.*)") && !strings.HasSuffix(route, "/(.*)/") {
 		route = util.RouteJoin(route, "/(.*)")
 		appendWildcard = true
 	}
 	node := &node{
+		method:         method,
 		route:          route,
 		originalRoute:  route,
 		isMiddleware:   true,
@@ -267,11 +813,40 @@ func (r *Router) useErrorHandle(route string, h ErrorHandle) {
 	r.routes = append(r.routes, node)
 }
 
-func (r *Router) mount(mountPoint string, router *Router) {
+// Mount the given router on the path with options. Unlike Use, the options
+// only apply to this mount point, so that the same router can be mounted
+// multiple times with different behaviors.
+func (r *Router) Mount(route string, router *Router, options *MountOptions) {
+	r.mount(util.AddPrefixSlash(route), router, options)
+}
+
+// Option returns the effective options of router, the zero value is returned
+// if the router has no options.
+func (r *Router) Option() RouterOption {
+	if r.routerOption == nil {
+		return RouterOption{}
+	}
+	return *r.routerOption
+}
+
+func (r *Router) mount(mountPoint string, router *Router, options ...*MountOptions) {
 	if router.routerOption == nil {
 		router.routerOption = r.routerOption
+	} else if router.routerOption.Inherit {
+		option, parent := *router.routerOption, r.Option()
+		option.Sensitive, option.Strict = parent.Sensitive, parent.Strict
+		router.routerOption = &option
+	}
+	for _, n := range router.routes {
+		n.initFixPath(router)
 	}
 
+	var opts *MountOptions
+	if len(options) > 0 {
+		opts = options[0]
+	}
+
+	baseUrlRegexps := make(map[baseUrlKey]*regexp2.Regexp)
 	for _, v := range router.routes {
 		route := util.RouteJoin(mountPoint, v.route)
 		route = strings.TrimSuffix(route, "/")
@@ -284,66 +859,108 @@ func (r *Router) mount(mountPoint string, router *Router) {
 			handle:         v.handle,
 			errorHandle:    v.errorHandle,
 			router:         v.router,
+			mergeParams:    v.mergeParams,
+			meta:           v.meta,
 		}
-		node.initRegexp()
+		if opts != nil {
+			mergeParams := opts.MergeParams
+			node.mergeParams = &mergeParams
+		}
+		node.initRegexpFrom(v, baseUrlRegexps)
+		node.initFixPath(r)
 		r.routes = append(r.routes, node)
 	}
 }
 
 // GET is a shortcut for router.Handle(http.MethodGet, route, handle)
-func (r *Router) GET(route string, handle Handle) {
-	r.Handle(http.MethodGet, route, handle)
+func (r *Router) GET(route string, handle Handle) *Route {
+	return r.Handle(http.MethodGet, route, handle)
 }
 
 // HEAD is a shortcut for router.Handle(http.MethodHead, route, handle)
-func (r *Router) HEAD(route string, handle Handle) {
-	r.Handle(http.MethodHead, route, handle)
+func (r *Router) HEAD(route string, handle Handle) *Route {
+	return r.Handle(http.MethodHead, route, handle)
 }
 
 // POST is a shortcut for router.Handle(http.MethodPost, route, handle)
-func (r *Router) POST(route string, handle Handle) {
-	r.Handle(http.MethodPost, route, handle)
+func (r *Router) POST(route string, handle Handle) *Route {
+	return r.Handle(http.MethodPost, route, handle)
 }
 
 // PUT is a shortcut for router.Handle(http.MethodPut, route, handle)
-func (r *Router) PUT(route string, handle Handle) {
-	r.Handle(http.MethodPut, route, handle)
+func (r *Router) PUT(route string, handle Handle) *Route {
+	return r.Handle(http.MethodPut, route, handle)
 }
 
 // PATCH is a shortcut for router.Handle(http.MethodPatch, route, handle)
-func (r *Router) PATCH(route string, handle Handle) {
-	r.Handle(http.MethodPatch, route, handle)
+func (r *Router) PATCH(route string, handle Handle) *Route {
+	return r.Handle(http.MethodPatch, route, handle)
 }
 
 // DELETE is a shortcut for router.Handle(http.MethodDelete, route, handle)
-func (r *Router) DELETE(route string, handle Handle) {
-	r.Handle(http.MethodDelete, route, handle)
+func (r *Router) DELETE(route string, handle Handle) *Route {
+	return r.Handle(http.MethodDelete, route, handle)
 }
 
 // OPTIONS is a shortcut for router.Handle(http.MethodOptions, route, handle)
-func (r *Router) OPTIONS(route string, handle Handle) {
-	r.Handle(http.MethodOptions, route, handle)
+func (r *Router) OPTIONS(route string, handle Handle) *Route {
+	return r.Handle(http.MethodOptions, route, handle)
 }
 
 // ALL means any http method, so this is a shortcut for
 // router.Handle(http.MethodAny, route, handle)
-func (r *Router) ALL(route string, handle Handle) {
-	r.Handle(HTTPMethodAll, route, handle)
+func (r *Router) ALL(route string, handle Handle) *Route {
+	return r.Handle(HTTPMethodAll, route, handle)
 }
 
 // Handle registers the handler for the http request which matched the method
 // and route, and dispatch a context object into the handler.
-func (r *Router) Handle(method, route string, handle Handle) {
+//
+// The remainder of path can be captured by a named wildcard, such as
+// `/files/*filepath` or `/files/:filepath*`, and is available as
+// c.Param("filepath"), as well as c.Request.Params[0] like the unnamed
+// wildcard.
+//
+// The returned Route can carry the metadata for middlewares, see Route.
+func (r *Router) Handle(method, route string, handle Handle) *Route {
 	route = util.AddPrefixSlash(strings.TrimSuffix(route, "/"))
+	route = expandNamedWildcard(route)
 	node := &node{
 		method:        method,
 		route:         route,
 		originalRoute: route,
 		handle:        handle,
 		router:        r,
+		meta:          &Route{},
 	}
 	node.initRegexp()
+	node.initFixPath(r)
 	r.routes = append(r.routes, node)
+	return node.meta
+}
+
+// Routes returns the information of all registered routes in order,
+// including middlewares, error handlers and routes of mounted routers.
+func (r *Router) Routes() []RouteInfo {
+	routes := make([]RouteInfo, 0, len(r.routes))
+	for _, n := range r.routes {
+		routes = append(routes, RouteInfo{
+			Method:         n.method,
+			Path:           n.path(),
+			IsMiddleware:   n.isMiddleware,
+			IsErrorHandler: n.isErrorHandler(),
+			Meta:           n.meta.metaCopy(),
+		})
+	}
+	return routes
+}
+
+// NotFound sets the handler which is called when no route matches the request
+// and no error occurs, such as rendering a custom 404 page. The response status
+// is 404 by default, and can be overridden by the handler. Only the handler
+// of the router serving the request is used, not of the mounted routers.
+func (r *Router) NotFound(handle Handle) {
+	r.notFoundHandler = handle
 }
 
 // Param registers a handler on router, and the handler will be triggered
@@ -362,15 +979,33 @@ func (r *Router) Param(name string, handle paramHandle) {
 // Router implements the interface http.Handler.
 func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	c, i, paramCalled := NewContext(req, w), -1, make(map[string]string)
+	c.Request.app = r.app
+	// the index of route found by c.Route() or selected by dispatching, it's
+	// reused until the dispatching passes it
+	matched := -1
+	c.matchRoute = func() *Route {
+		if matched < 0 || matched < i {
+			matched = r.matchRoute(i, req)
+		}
+		if matched < len(r.routes) {
+			return r.routes[matched].meta
+		}
+		return nil
+	}
 
 	c.next = func(v ...interface{}) {
 		defer r.recv(c)
 
+		// the error of panic is cleared, e.g. by c.Next() of error handler
+		if c.panicked && (len(v) == 0 || v[0] == nil) {
+			c.clearPanic()
+		}
+
 		if i++; i >= len(r.routes) {
 			if len(v) > 0 && v[0] != nil {
-				defaultErrorHandler(v[0], c)
-			} else {
-				defaultErrorHandler(internal.ErrNotFound, c)
+				r.handleError(v[0], c)
+			} else if !r.respondOptions(c) && !r.redirectFixedPath(c) {
+				r.handleNotFound(c)
 			}
 			return
 		}
@@ -393,7 +1028,7 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 				return
 			}
 
-			if node.isMiddleware || node.method == HTTPMethodAll || node.method == req.Method {
+			if (node.isMiddleware && node.method == "") || node.matchMethod(req.Method) {
 				node.buildRequestProperties(c, urlPath)
 
 				if len(node.router.paramHandles) > 0 {
@@ -409,6 +1044,9 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 					}
 				}
 
+				if node.meta != nil {
+					matched = i
+				}
 				node.handle(c)
 				return
 			}
@@ -420,6 +1058,102 @@ func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	c.next()
 }
 
+// matchRoute returns the index of the first route from index i which matches
+// the path and method of req, or len(r.routes) if there is none.
+func (r *Router) matchRoute(i int, req *http.Request) int {
+	if i < 0 {
+		i = 0
+	}
+	for ; i < len(r.routes); i++ {
+		n := r.routes[i]
+		if n.meta != nil && n.matchMethod(req.Method) && n.match(req.URL.Path) {
+			return i
+		}
+	}
+	return i
+}
+
+// respondOptions responds the OPTIONS request with the Allow header, which
+// lists the methods of routes matching the request path without duplicates.
+func (r *Router) respondOptions(c *Context) bool {
+	if r.routerOption == nil || !r.routerOption.AutoOptions || c.finished ||
+		c.Request.Method != http.MethodOptions {
+		return false
+	}
+
+	methods := r.allowedMethods(c.Request.URL.Path)
+	if len(methods) == 0 {
+		return false
+	}
+
+	allow := strings.Join(methods, ",")
+	c.Set("Allow", allow)
+	c.Status(http.StatusOK).Send(allow)
+	return true
+}
+
+// allowedMethods returns the methods of routes matching urlPath in the order
+// of registration, the routes of same path registered separately, such as by
+// routerProxy, are merged. HEAD is implied by GET if AutoHead is enabled.
+func (r *Router) allowedMethods(urlPath string) []string {
+	var methods []string
+	seen := make(map[string]bool)
+	add := func(method string) {
+		if !seen[method] {
+			seen[method] = true
+			methods = append(methods, method)
+		}
+	}
+
+	for _, n := range r.routes {
+		if n.isMiddleware || n.isErrorHandler() || n.method == HTTPMethodAll || !n.match(urlPath) {
+			continue
+		}
+		add(n.method)
+		if o := n.router.routerOption; n.method == http.MethodGet && o != nil && o.AutoHead {
+			add(http.MethodHead)
+		}
+	}
+	return methods
+}
+
+// redirectFixedPath looks up a route matching the cleaned and case-insensitive
+// request path, and redirects to it if found.
+func (r *Router) redirectFixedPath(c *Context) bool {
+	if r.routerOption == nil || !r.routerOption.RedirectFixedPath || c.finished {
+		return false
+	}
+
+	req := c.Request
+	urlPath := path.Clean(util.AddPrefixSlash(req.URL.Path))
+	for _, n := range r.routes {
+		if n.isMiddleware || n.isErrorHandler() || !n.matchMethod(req.Method) {
+			continue
+		}
+
+		fixedPath, ok, err := n.fixPath(urlPath)
+		if err != nil {
+			r.handleError(err, c)
+			return true
+		}
+		if !ok || fixedPath == req.URL.Path {
+			continue
+		}
+
+		status := http.StatusPermanentRedirect
+		if req.Method == http.MethodGet || req.Method == http.MethodHead {
+			status = http.StatusMovedPermanently
+		}
+		if req.URL.RawQuery != "" {
+			fixedPath += "?" + req.URL.RawQuery
+		}
+		c.Redirect(status, fixedPath)
+		return true
+	}
+
+	return false
+}
+
 // Route returns an instance of a single route which you can then use to handle
 // HTTP verbs with optional middleware.
 // Use router.route() to avoid duplicate route naming and thus typing errors.
@@ -430,6 +1164,26 @@ func (r *Router) Route(route string) *routerProxy {
 type routerProxy struct {
 	router *Router
 	route  string
+
+	// the routes registered by the proxy, and the metadata attached to them
+	// by WithMeta
+	routes []*Route
+	meta   map[string]interface{}
+}
+
+// WithMeta attaches the value of key to all the routes registered by the
+// proxy, including the ones registered later, e.g.
+//
+//	router.Route("/admin").GET(show).POST(update).WithMeta("scope", "admin")
+func (r *routerProxy) WithMeta(key string, value interface{}) *routerProxy {
+	if r.meta == nil {
+		r.meta = make(map[string]interface{})
+	}
+	r.meta[key] = value
+	for _, route := range r.routes {
+		route.WithMeta(key, value)
+	}
+	return r
 }
 
 // GET is a shortcut for Handle("GET", handle)
@@ -474,6 +1228,10 @@ func (r *routerProxy) ALL(h Handle) *routerProxy {
 
 // Handle registers the handler for matched method
 func (r *routerProxy) Handle(method string, h Handle) *routerProxy {
-	r.router.Handle(method, r.route, h)
+	route := r.router.Handle(method, r.route, h)
+	for k, v := range r.meta {
+		route.WithMeta(k, v)
+	}
+	r.routes = append(r.routes, route)
 	return r
 }
@@ -5,14 +5,22 @@
 package soon
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync/atomic"
 	"testing"
 
+	"github.com/dlclark/regexp2"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	pathToRegexp "github.com/soongo/path-to-regexp"
+	"github.com/soongo/soon/util"
 )
 
 type header map[string]string
@@ -325,6 +333,7 @@ func TestRouterMiddleware(t *testing.T) {
 			route:      "/",
 			path:       "/",
 			statusCode: 500,
+			body:       http.StatusText(500),
 			middleware: func(c *Context) {
 				panic(body200)
 			},
@@ -341,6 +350,7 @@ func TestRouterMiddleware(t *testing.T) {
 			route:      "/",
 			path:       "/",
 			statusCode: 500,
+			body:       http.StatusText(500),
 			err:        body200,
 		},
 		{
@@ -376,6 +386,7 @@ func TestRouterMiddleware(t *testing.T) {
 			path:              "/foo/foo1/bar/bar1",
 			errorHandlerRoute: "/foo/bar/:foo",
 			statusCode:        500,
+			body:              http.StatusText(500),
 			err:               errors.New(body200),
 			errorHandle: func(v interface{}, c *Context) {
 				c.Send(v.(error).Error())
@@ -385,6 +396,7 @@ func TestRouterMiddleware(t *testing.T) {
 			route:      "/",
 			path:       "/",
 			statusCode: 500,
+			body:       http.StatusText(500),
 			err:        errors.New(body200),
 			errorHandle: func(v interface{}, c *Context) {
 				panic(v)
@@ -394,6 +406,7 @@ func TestRouterMiddleware(t *testing.T) {
 			route:      "/",
 			path:       "/",
 			statusCode: 500,
+			body:       http.StatusText(500),
 			err:        errors.New(body200),
 			errorHandle: func(v interface{}, c *Context) {
 				c.Next(v)
@@ -418,7 +431,7 @@ func TestRouterMiddleware(t *testing.T) {
 			statusCode, _, body, err := request("GET", server.URL+tt.path, nil)
 			assert.Nil(err)
 			assert.Equal(tt.statusCode, statusCode)
-			assert.Equal(body200, body)
+			assert.Equal(stringOr(tt.body, body200), body)
 		})
 	}
 }
@@ -616,6 +629,272 @@ func TestRouter_Params(t *testing.T) {
 	})
 }
 
+func TestRouter_Option(t *testing.T) {
+	assert.Equal(t, RouterOption{}, NewRouter().Option())
+	assert.Equal(t, RouterOption{Strict: true}, NewRouter(&RouterOption{Strict: true}).Option())
+
+	tests := []struct {
+		name           string
+		option         *RouterOption
+		expectedOption RouterOption
+		expectedStatus map[string]int
+	}{
+		{
+			"inherit-without-option",
+			nil,
+			RouterOption{Sensitive: true, Strict: true},
+			map[string]int{"/sub/foo": 200, "/sub/FOO": 404, "/sub/foo/": 404},
+		},
+		{
+			"explicit-option",
+			&RouterOption{MergeParams: true},
+			RouterOption{MergeParams: true},
+			map[string]int{"/sub/foo": 200, "/sub/FOO": 200, "/sub/foo/": 200},
+		},
+		{
+			"inherit-sentinel",
+			&RouterOption{MergeParams: true, Inherit: true},
+			RouterOption{Sensitive: true, Strict: true, MergeParams: true, Inherit: true},
+			map[string]int{"/sub/foo": 200, "/sub/FOO": 404, "/sub/foo/": 404},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := NewRouter(&RouterOption{Sensitive: true, Strict: true})
+			child := NewRouter(tt.option)
+			child.GET("/foo", func(c *Context) {
+				c.String(body200)
+			})
+			parent.Use("/sub", child)
+			assert.Equal(t, tt.expectedOption, child.Option())
+			for p, status := range tt.expectedStatus {
+				w := httptest.NewRecorder()
+				parent.ServeHTTP(w, httptest.NewRequest(http.MethodGet, p, nil))
+				assert.Equal(t, status, w.Code, p)
+			}
+		})
+	}
+
+	option := &RouterOption{Inherit: true}
+	NewRouter(&RouterOption{Sensitive: true}).Use(NewRouter(option))
+	assert.Equal(t, RouterOption{Inherit: true}, *option)
+}
+
+func TestRouter_Mount(t *testing.T) {
+	sub := NewRouter()
+	sub.GET("/users/:id", func(c *Context) {
+		c.Json(c.Request.Params)
+	})
+
+	router := NewRouter()
+	router.Mount("/merged/:org", sub, &MountOptions{MergeParams: true})
+	router.Mount("/isolated/:org", sub, &MountOptions{MergeParams: false})
+	router.Mount("default/:org", sub, nil)
+
+	tests := []struct {
+		path         string
+		expectedBody string
+	}{
+		{"/merged/soongo/users/1", `{"id":"1","org":"soongo"}`},
+		{"/isolated/soongo/users/1", `{"id":"1"}`},
+		{"/default/soongo/users/1", `{"id":"1"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expectedBody, strings.TrimSpace(w.Body.String()))
+		})
+	}
+
+	t.Run("overrides router option", func(t *testing.T) {
+		sub := NewRouter(&RouterOption{MergeParams: true})
+		sub.GET("/users/:id", func(c *Context) {
+			c.Json(c.Request.Params)
+		})
+		router := NewRouter()
+		router.Use("/merged/:org", sub)
+		router.Mount("/isolated/:org", sub, &MountOptions{})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/merged/soongo/users/1", nil))
+		assert.Equal(t, `{"id":"1","org":"soongo"}`, strings.TrimSpace(w.Body.String()))
+
+		w = httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/isolated/soongo/users/1", nil))
+		assert.Equal(t, `{"id":"1"}`, strings.TrimSpace(w.Body.String()))
+	})
+}
+
+func newLargeRouter(n int, options ...*RouterOption) *Router {
+	router := NewRouter(options...)
+	router.Use(func(c *Context) {
+		c.Next()
+	})
+	for i := 0; i < n; i++ {
+		path := fmt.Sprintf("/r%d/:id", i)
+		router.GET(path, func(c *Context) {
+			c.Send(path + ":" + c.Param("id"))
+		})
+	}
+	return router
+}
+
+func TestRouter_MountMatch(t *testing.T) {
+	routes := []string{"/users", "/users/:id", "/users/:id?", "/files/*filepath", "/(\\d+)"}
+	paths := []string{
+		"/soongo/api", "/soongo/api/", "/soongo/api/users", "/soongo/api/users/",
+		"/soongo/api/users/1", "/soongo/api/Users/1/", "/soongo/api/files/a/b",
+		"/soongo/api/1", "/soongo/apis/users", "/soongo/users",
+	}
+	options := []*RouterOption{nil, {Strict: true}, {Sensitive: true}}
+
+	handle := func(c *Context) {
+		params, _ := json.Marshal(c.Request.Params)
+		c.Send(string(params))
+	}
+	for _, option := range options {
+		for _, route := range routes {
+			subOption := RouterOption{MergeParams: true}
+			if option != nil {
+				subOption.Sensitive, subOption.Strict = option.Sensitive, option.Strict
+			}
+			mounted, sub := NewRouter(option), NewRouter(&subOption)
+			sub.GET(route, handle)
+			mounted.Use("/:org/api", sub)
+			full := NewRouter(option)
+			full.GET("/:org/api"+route, handle)
+			require.True(t, mounted.routes[0].regexp == sub.routes[0].regexp)
+
+			for _, path := range paths {
+				w1, w2 := httptest.NewRecorder(), httptest.NewRecorder()
+				mounted.ServeHTTP(w1, httptest.NewRequest(http.MethodGet, path, nil))
+				full.ServeHTTP(w2, httptest.NewRequest(http.MethodGet, path, nil))
+				assert.Equal(t, w2.Code, w1.Code, "%v %s %s", option, route, path)
+				assert.Equal(t, w2.Body.String(), w1.Body.String(), "%v %s %s", option, route, path)
+			}
+		}
+	}
+}
+
+func TestRouter_MountReuseRegexp(t *testing.T) {
+	tests := []struct {
+		mountPoints []string
+		option      *RouterOption
+		sub         *Router
+		reused      bool
+
+		// the maximum count of routes compiled by mounting
+		compilations int64
+	}{
+		// the routes are unchanged
+		{[]string{"/"}, nil, newLargeRouter(200), true, 0},
+		// only the mount point is compiled, it's shared by the routes, so the
+		// compilations don't grow with the count of routes
+		{[]string{"/api"}, nil, newLargeRouter(200), true, 3},
+		{[]string{"/v1", "/:org"}, nil, newLargeRouter(200), true, 6},
+		// the options are changed, so everything is recompiled
+		{
+			[]string{"/api"},
+			&RouterOption{Sensitive: true},
+			newLargeRouter(200, &RouterOption{Inherit: true}),
+			false,
+			400,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.mountPoints, ""), func(t *testing.T) {
+			router := tt.sub
+			compilations := atomic.LoadInt64(&routeCompilations)
+			for _, mountPoint := range tt.mountPoints {
+				parent := NewRouter(tt.option)
+				parent.Use(mountPoint, router)
+				router = parent
+			}
+			compilations = atomic.LoadInt64(&routeCompilations) - compilations
+			if tt.reused {
+				assert.LessOrEqual(t, compilations, tt.compilations)
+			} else {
+				assert.GreaterOrEqual(t, compilations, tt.compilations)
+			}
+
+			var mountRegexp *regexp2.Regexp
+			for i, n := range router.routes {
+				src := tt.sub.routes[i]
+				if n.isMiddleware {
+					continue
+				}
+				assert.Equal(t, tt.reused, n.regexp == src.regexp)
+				if tt.mountPoints[0] != "/" && tt.reused {
+					require.NotNil(t, n.mountRegexp)
+					if mountRegexp != nil {
+						assert.True(t, mountRegexp == n.mountRegexp)
+					}
+					mountRegexp = n.mountRegexp
+				}
+			}
+
+			path := util.RouteJoin(append(tt.mountPoints, "/r100/1")...)
+			if len(tt.mountPoints) > 1 {
+				path = "/soongo/v1/r100/1"
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, "/r100/:id:1", w.Body.String())
+		})
+	}
+}
+
+func TestRouter_NamedWildcard(t *testing.T) {
+	tests := []struct {
+		mountPoint string
+		route      string
+		path       string
+		name       string
+		expected   string
+
+		// the remainder of path is also available at the numeric index
+		index interface{}
+	}{
+		{"", "/files/*filepath", "/files/a/b/c.txt", "filepath", "a/b/c.txt", 0},
+		{"", "/files/*filepath", "/files/", "filepath", "", 0},
+		{"", "/files/:filepath*", "/files/a/b/c.txt", "filepath", "a/b/c.txt", 0},
+		{"", "/files/:filepath(.*)", "/files/a/b/c.txt", "filepath", "a/b/c.txt", 0},
+		{"", "/:user/*rest", "/foo/a/b", "user", "foo", nil},
+		{"", "/:user/*rest", "/foo/a/b", "rest", "a/b", 0},
+		{"", "/(\\d+)/*rest", "/1/a/b", "rest", "a/b", 1},
+		{"/static", "/*filepath", "/static/css/main.css", "filepath", "css/main.css", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			var params Params
+			router := NewRouter()
+			router.GET(tt.route, func(c *Context) {
+				params = c.Request.Params
+			})
+			if tt.mountPoint != "" {
+				parent := NewRouter()
+				parent.Use(tt.mountPoint, router)
+				router = parent
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			assert.Equal(t, 200, w.Code)
+			assert.Equal(t, tt.expected, params.Get(tt.name))
+			if tt.index != nil {
+				assert.Equal(t, tt.expected, params.Get(tt.index))
+			}
+		})
+	}
+}
+
 func TestRouterMethods(t *testing.T) {
 	tt := test{route: "/", path: "/", body: body200}
 	check := func(method, url string) {
@@ -725,6 +1004,80 @@ func TestRouter_Handle(t *testing.T) {
 	}
 }
 
+func TestRouter_AutoHead(t *testing.T) {
+	tests := []struct {
+		routerOption *RouterOption
+		handle       Handle
+		statusCode   int
+		header       header
+	}{
+		{nil, func(c *Context) { c.Send(body200) }, 404, nil},
+		{&RouterOption{}, func(c *Context) { c.Send(body200) }, 404, nil},
+		{
+			&RouterOption{AutoHead: true},
+			func(c *Context) { c.Send(body200) },
+			200,
+			header{"Content-Type": "text/plain; charset=utf-8", "Content-Length": "15"},
+		},
+		{
+			&RouterOption{AutoHead: true},
+			func(c *Context) { c.Json(map[string]int{"foo": 1}) },
+			200,
+			header{"Content-Type": "application/json; charset=utf-8", "Content-Length": "10"},
+		},
+		{
+			&RouterOption{AutoHead: true},
+			func(c *Context) { c.Status(201); c.Set("X-Foo", "bar"); c.Send(body200) },
+			201,
+			header{"X-Foo": "bar", "Content-Length": "15"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert := assert.New(t)
+			router := NewRouter(tt.routerOption)
+			router.GET("/", tt.handle)
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			statusCode, h, body, err := request(http.MethodHead, server.URL, nil)
+			assert.Nil(err)
+			assert.Equal(tt.statusCode, statusCode)
+			assert.Equal("", body)
+			for k, v := range tt.header {
+				assert.Equal(v, h.Get(k))
+			}
+		})
+	}
+
+	t.Run("mounted", func(t *testing.T) {
+		router, child := NewRouter(&RouterOption{AutoHead: true}), NewRouter()
+		child.GET("/foo", func(c *Context) { c.Send(body200) })
+		router.Use("/child", child)
+		server := httptest.NewServer(router)
+		defer server.Close()
+
+		statusCode, _, body, err := request(http.MethodHead, server.URL+"/child/foo", nil)
+		assert.Nil(t, err)
+		assert.Equal(t, 200, statusCode)
+		assert.Equal(t, "", body)
+	})
+
+	t.Run("explicit-head", func(t *testing.T) {
+		router := NewRouter(&RouterOption{AutoHead: true})
+		router.HEAD("/", func(c *Context) { c.Set("X-Head", "true"); c.End() })
+		router.GET("/", func(c *Context) { c.Send(body200) })
+		server := httptest.NewServer(router)
+		defer server.Close()
+
+		statusCode, h, _, err := request(http.MethodHead, server.URL, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, 200, statusCode)
+		assert.Equal(t, "true", h.Get("X-Head"))
+	})
+}
+
 func TestRouter_ALL(t *testing.T) {
 	tt := test{route: "/", path: "/", body: body200}
 	router := NewRouter()
@@ -938,6 +1291,497 @@ func TestRouter_Param(t *testing.T) {
 	})
 }
 
+func TestRouter_RedirectFixedPath(t *testing.T) {
+	tests := []struct {
+		method       string
+		mountPoint   string
+		route        string
+		routerOption *RouterOption
+		path         string
+		statusCode   int
+		location     string
+	}{
+		{"GET", "", "/Foo/Bar", &RouterOption{Sensitive: true, RedirectFixedPath: true}, "/foo/bar", 301, "/Foo/Bar"},
+		{"GET", "", "/Foo/Bar", &RouterOption{Sensitive: true, RedirectFixedPath: true}, "/FOO/bar/", 301, "/Foo/Bar"},
+		{"GET", "", "/Foo/Bar", &RouterOption{Sensitive: true, RedirectFixedPath: true}, "//foo/./baz/../bar", 301, "/Foo/Bar"},
+		{"GET", "", "/Foo/Bar", &RouterOption{Sensitive: true, RedirectFixedPath: true}, "/foo/bar?a=1", 301, "/Foo/Bar?a=1"},
+		{"HEAD", "", "/Foo/Bar", &RouterOption{Sensitive: true, RedirectFixedPath: true}, "/foo/bar", 301, "/Foo/Bar"},
+		{"POST", "", "/Foo/Bar", &RouterOption{Sensitive: true, RedirectFixedPath: true}, "/foo/bar", 308, "/Foo/Bar"},
+		{"GET", "", "/Users/:id", &RouterOption{Sensitive: true, RedirectFixedPath: true}, "/users/Abc", 301, "/Users/Abc"},
+		{"GET", "", "/Foo/Bar", &RouterOption{RedirectFixedPath: true}, "/foo//bar", 301, "/Foo/Bar"},
+		{"GET", "", "/Foo/Bar", &RouterOption{Sensitive: true, RedirectFixedPath: true}, "/foo/baz", 404, ""},
+		{"GET", "", "/Foo/Bar", &RouterOption{Sensitive: true}, "/foo/bar", 404, ""},
+		{"GET", "", "/Foo/Bar", nil, "/foo//bar", 404, ""},
+		{"GET", "/api", "/Foo", &RouterOption{Sensitive: true, RedirectFixedPath: true}, "/api/foo", 301, "/api/Foo"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			router := NewRouter(tt.routerOption)
+			sub := router
+			if tt.mountPoint != "" {
+				// the sub router inherits the options when it's mounted
+				sub = NewRouter()
+			}
+			sub.Handle(tt.method, tt.route, func(c *Context) {
+				c.String(body200)
+			})
+			if tt.mountPoint != "" {
+				router.Use(tt.mountPoint, sub)
+			}
+
+			// the regexp of fixed path is only compiled if it's enabled
+			n := router.routes[len(router.routes)-1]
+			redirect := tt.routerOption != nil && tt.routerOption.RedirectFixedPath
+			assert.Equal(t, redirect, n.fixRegexp != nil)
+
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			assert.Equal(t, tt.statusCode, w.Code)
+			assert.Equal(t, tt.location, w.Header().Get("Location"))
+		})
+	}
+}
+
+func TestNode_FixPath(t *testing.T) {
+	n := &node{route: "/Users/:id"}
+	fixedPath, ok, err := n.fixPath("/users/1")
+	assert.Equal(t, "", fixedPath)
+	assert.False(t, ok)
+	assert.NoError(t, err)
+
+	n.fixRegexp = regexp2.MustCompile(`^/users/([^/]+)?$`, regexp2.IgnoreCase)
+	n.fixTokens = pathToRegexp.Parse(n.route, nil)
+	fixedPath, ok, err = n.fixPath("/users/1")
+	assert.Equal(t, "/Users/1", fixedPath)
+	assert.True(t, ok)
+	assert.NoError(t, err)
+
+	// the required param isn't captured, which is a bug rather than not found
+	_, ok, err = n.fixPath("/users/")
+	assert.False(t, ok)
+	assert.EqualError(t, err, "param id of route /Users/:id isn't captured")
+}
+
+func TestRouter_ErrorHandlerChain(t *testing.T) {
+	t.Run("order", func(t *testing.T) {
+		var calls []string
+		router := NewRouter()
+		router.GET("/users/:id", func(c *Context) {
+			panic(NewError(400, "bad id"))
+		})
+		router.Use(func(err interface{}, c *Context) {
+			calls = append(calls, "root-1")
+			c.Next(err)
+		})
+		router.Use("/posts", func(err interface{}, c *Context) {
+			calls = append(calls, "posts")
+			c.Next(err)
+		})
+		router.Use("/users", func(err interface{}, c *Context) {
+			calls = append(calls, "users")
+			c.Next(err)
+		})
+		router.Use(func(c *Context) {
+			calls = append(calls, "middleware")
+			c.Next()
+		})
+		router.Use(func(err interface{}, c *Context) {
+			calls = append(calls, "root-2")
+			c.Next(err)
+		})
+
+		w := Test(router).Get("/users/1").Do()
+		assert.Equal(t, []string{"root-1", "users", "root-2"}, calls)
+		assert.Equal(t, 400, w.Code)
+		assert.Equal(t, "bad id", strings.TrimSpace(w.Body.String()))
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		var calls []string
+		router := NewRouter()
+		router.GET("/", func(c *Context) {
+			calls = append(calls, "handler")
+			c.Next(errors.New("oops"))
+		})
+		router.Use(func(err interface{}, c *Context) {
+			calls = append(calls, "recover")
+			c.Next()
+		})
+		router.Use(func(err interface{}, c *Context) {
+			calls = append(calls, "skipped")
+			c.Next(err)
+		})
+		router.GET("/", func(c *Context) {
+			calls = append(calls, "fallback")
+			c.Send("ok")
+		})
+
+		w := Test(router).Get("/").Do()
+		assert.Equal(t, []string{"handler", "recover", "fallback"}, calls)
+		assert.Equal(t, 200, w.Code)
+		assert.Equal(t, "ok", w.Body.String())
+	})
+}
+
+func TestRouter_DefaultErrorHandler(t *testing.T) {
+	tests := []struct {
+		handle      Handle
+		accept      string
+		statusCode  int
+		contentType string
+		body        string
+	}{
+		{
+			func(c *Context) { panic(NewError(403, "forbidden")) },
+			"application/json",
+			403,
+			jsonType,
+			`{"error":"forbidden","status":403}`,
+		},
+		{
+			func(c *Context) { panic(NewError(403, "forbidden")) },
+			"",
+			403,
+			plainType,
+			"forbidden",
+		},
+		{
+			func(c *Context) { panic(NewError(403, "forbidden")) },
+			"text/html",
+			403,
+			plainType,
+			"forbidden",
+		},
+		{
+			func(c *Context) { c.Next(NewError(422, "")) },
+			"application/json, text/plain;q=0.5",
+			422,
+			jsonType,
+			`{"error":"Unprocessable Entity","status":422}`,
+		},
+		{
+			func(c *Context) { c.Next() },
+			"application/json",
+			404,
+			jsonType,
+			`{"error":"Not Found","status":404}`,
+		},
+		{
+			func(c *Context) { panic(errors.New("oops")) },
+			"*/*",
+			500,
+			plainType,
+			http.StatusText(500),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			router := NewRouter()
+			router.GET("/", tt.handle)
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			h := http.Header{}
+			if tt.accept != "" {
+				h.Set("Accept", tt.accept)
+			}
+			statusCode, header, body, err := request(http.MethodGet, server.URL, h)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.statusCode, statusCode)
+			assert.Equal(t, tt.contentType, header.Get("Content-Type"))
+			assert.Equal(t, tt.body, body)
+		})
+	}
+}
+
+func TestRouter_DefaultErrorHandlerPanic(t *testing.T) {
+	router := NewRouter()
+	router.GET("/panic", func(c *Context) { panic("oops<script>") })
+	router.GET("/error", func(c *Context) { c.Next(errors.New("oops")) })
+	router.GET("/bad", func(c *Context) { panic(NewError(400, "bad name")) })
+
+	t.Run("debug", func(t *testing.T) {
+		SetMode(DebugMode)
+		defer SetMode(TestMode)
+
+		w := Test(router).Get("/panic").Set("Accept", "application/json").Do()
+		assert.Equal(t, 500, w.Code)
+		assert.Equal(t, jsonType, w.Header().Get("Content-Type"))
+		var body jsonPanicError
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, "oops<script>", body.Error)
+		assert.Equal(t, 500, body.Status)
+		assert.Equal(t, "oops<script>", body.Panic)
+		assert.Contains(t, body.Stack, "goroutine")
+		assert.Contains(t, body.Stack, "router_test.go")
+
+		w = Test(router).Get("/panic").Set("Accept", "text/html").Do()
+		assert.Equal(t, 500, w.Code)
+		assert.Equal(t, htmlType, w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Body.String(), "<h1>panic: oops&lt;script&gt;</h1>")
+		assert.Contains(t, w.Body.String(), "router_test.go")
+
+		// the errors passed to next and the client errors have no details
+		w = Test(router).Get("/error").Do()
+		assert.Equal(t, 500, w.Code)
+		assert.Equal(t, "oops\n", w.Body.String())
+		w = Test(router).Get("/bad").Do()
+		assert.Equal(t, 400, w.Code)
+		assert.Equal(t, "bad name\n", w.Body.String())
+	})
+
+	t.Run("release", func(t *testing.T) {
+		SetMode(ReleaseMode)
+		defer SetMode(TestMode)
+
+		w := Test(router).Get("/panic").Set("Accept", "application/json").Do()
+		assert.Equal(t, 500, w.Code)
+		assert.Equal(t, `{"error":"Internal Server Error","status":500}`, strings.TrimSpace(w.Body.String()))
+
+		w = Test(router).Get("/panic").Set("Accept", "text/html").Do()
+		assert.Equal(t, 500, w.Code)
+		assert.Equal(t, plainType, w.Header().Get("Content-Type"))
+		assert.Equal(t, "Internal Server Error\n", w.Body.String())
+
+		// only the panics are hidden
+		w = Test(router).Get("/error").Do()
+		assert.Equal(t, 500, w.Code)
+		assert.Equal(t, "oops\n", w.Body.String())
+		w = Test(router).Get("/bad").Do()
+		assert.Equal(t, 400, w.Code)
+		assert.Equal(t, "bad name\n", w.Body.String())
+	})
+
+	t.Run("handled", func(t *testing.T) {
+		SetMode(DebugMode)
+		defer SetMode(TestMode)
+
+		// the panic resumed by the error handler doesn't affect the later error
+		router := NewRouter()
+		router.GET("/", func(c *Context) { panic("oops") })
+		router.Use(func(v interface{}, c *Context) { c.Next() })
+		router.GET("/", func(c *Context) { c.Next(errors.New("later")) })
+
+		w := Test(router).Get("/").Set("Accept", "text/html").Do()
+		assert.Equal(t, 500, w.Code)
+		assert.Equal(t, plainType, w.Header().Get("Content-Type"))
+		assert.Equal(t, "later\n", w.Body.String())
+	})
+}
+
+func TestE(t *testing.T) {
+	tests := []struct {
+		handle     HandleE
+		statusCode int
+		body       string
+	}{
+		{func(c *Context) error { c.Send(body200); return nil }, 200, body200},
+		{func(c *Context) error { return NewError(400, "bad name") }, 400, "400: bad name"},
+		{func(c *Context) error { return errors.New("oops") }, 500, "500: oops"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			router := NewRouter()
+			router.GET("/", E(tt.handle))
+			router.Use(func(v interface{}, c *Context) {
+				status, text := resolveError(v)
+				c.Status(status).Send(fmt.Sprintf("%d: %s", status, text))
+			})
+			server := httptest.NewServer(router)
+			defer server.Close()
+
+			statusCode, _, body, err := request(http.MethodGet, server.URL, nil)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.statusCode, statusCode)
+			assert.Equal(t, tt.body, body)
+		})
+	}
+
+	t.Run("middleware", func(t *testing.T) {
+		router := NewRouter()
+		router.Use(func(c *Context) error {
+			return NewError(401, "")
+		})
+		router.Use(HandleE(func(c *Context) error {
+			return nil
+		}))
+		router.GET("/", func(c *Context) { c.Send(body200) })
+		server := httptest.NewServer(router)
+		defer server.Close()
+
+		statusCode, _, body, err := request(http.MethodGet, server.URL, nil)
+		assert.Nil(t, err)
+		assert.Equal(t, 401, statusCode)
+		assert.Equal(t, http.StatusText(401), body)
+	})
+}
+
+func TestRouter_NotFound(t *testing.T) {
+	tests := []struct {
+		path        string
+		handle      Handle
+		statusCode  int
+		contentType string
+		body        string
+	}{
+		{"/foo", nil, 404, plainType, body404},
+		{"/", nil, 200, plainType, body200},
+		{"/foo", func(c *Context) { c.Json(map[string]string{"error": "no such route"}) }, 404, jsonType, `{"error":"no such route"}`},
+		{"/foo", func(c *Context) { c.Status(410).Send("gone") }, 410, plainType, "gone"},
+		{"/", func(c *Context) { c.Send("not found") }, 200, plainType, body200},
+		{"/error", func(c *Context) { c.Send("not found") }, 500, plainType, http.StatusText(500)},
+		{"/foo", func(c *Context) { panic(NewError(503, "unavailable")) }, 503, plainType, http.StatusText(503)},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			router := NewRouter()
+			router.GET("/", func(c *Context) { c.Send(body200) })
+			router.GET("/error", func(c *Context) { panic("oops") })
+			if tt.handle != nil {
+				router.NotFound(tt.handle)
+			}
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			assert.Equal(t, tt.statusCode, w.Code)
+			assert.Equal(t, tt.contentType, w.Header().Get("Content-Type"))
+			assert.Equal(t, tt.body, strings.TrimSpace(w.Body.String()))
+		})
+	}
+}
+
+func TestRouter_Routes(t *testing.T) {
+	handle := func(c *Context) {}
+	errorHandle := func(v interface{}, c *Context) {}
+
+	userRouter := NewRouter()
+	userRouter.Use(handle)
+	userRouter.GET("/", handle)
+	userRouter.POST("/:id", handle)
+
+	router := NewRouter()
+	router.Use(handle)
+	router.GET("/", handle)
+	router.Route("/foo").GET(handle).PUT(handle)
+	router.Use("/users", userRouter)
+	router.ALL("/bar", handle)
+	router.Use(errorHandle)
+
+	expected := []RouteInfo{
+		{Path: "/", IsMiddleware: true},
+		{Method: http.MethodGet, Path: "/"},
+		{Method: http.MethodGet, Path: "/foo"},
+		{Method: http.MethodPut, Path: "/foo"},
+		{Path: "/users", IsMiddleware: true},
+		{Method: http.MethodGet, Path: "/users"},
+		{Method: http.MethodPost, Path: "/users/:id"},
+		{Method: HTTPMethodAll, Path: "/bar"},
+		{Path: "/", IsErrorHandler: true},
+	}
+	assert.Equal(t, expected, router.Routes())
+	assert.Equal(t, []RouteInfo{}, NewRouter().Routes())
+}
+
+func TestRouter_RouteMeta(t *testing.T) {
+	requireScope := func(c *Context) {
+		if scope, ok := c.Route().Meta("scope"); ok && c.Request.Get("X-Scope") != scope {
+			c.SendStatus(http.StatusForbidden)
+			return
+		}
+		c.Next()
+	}
+	handle := func(c *Context) {
+		c.Send(c.Request.Path)
+	}
+
+	userRouter := NewRouter()
+	userRouter.GET("/", handle)
+	adminRoute := userRouter.DELETE("/:id", handle)
+
+	router := NewRouter(&RouterOption{AutoHead: true})
+	router.Use(requireScope)
+	router.GET("/", handle)
+	router.GET("/admin", func(c *Context) {
+		v, _ := c.Route().Meta("scope")
+		c.Send(v.(string))
+	}).WithMeta("scope", "admin").WithMeta("doc", "the admin page")
+	router.Use("/users", userRouter)
+	// the metadata attached after mounting is shared
+	adminRoute.WithMeta("scope", "admin")
+	// the metadata of proxy applies to the routes registered before and after
+	router.Route("/settings").GET(handle).WithMeta("scope", "admin").PUT(handle)
+
+	tests := []struct {
+		method       string
+		path         string
+		scope        string
+		expectedCode int
+		expectedBody string
+	}{
+		{"GET", "/", "", 200, "/"},
+		{"GET", "/admin", "", 403, "Forbidden"},
+		{"GET", "/admin", "user", 403, "Forbidden"},
+		{"GET", "/admin", "admin", 200, "admin"},
+		{"HEAD", "/admin", "", 403, ""},
+		{"GET", "/users", "", 200, "/users"},
+		{"DELETE", "/users/1", "", 403, "Forbidden"},
+		{"DELETE", "/users/1", "admin", 200, "/users/1"},
+		{"GET", "/not-found", "", 404, body404 + "\n"},
+		{"GET", "/settings", "", 403, "Forbidden"},
+		{"PUT", "/settings", "", 403, "Forbidden"},
+		{"PUT", "/settings", "admin", 200, "/settings"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+tt.path, func(t *testing.T) {
+			req := Test(router).Request(tt.method, tt.path)
+			if tt.scope != "" {
+				req.Set("X-Scope", tt.scope)
+			}
+			w := req.Do()
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.expectedBody, w.Body.String())
+		})
+	}
+
+	var meta []map[string]interface{}
+	for _, info := range router.Routes() {
+		meta = append(meta, info.Meta)
+	}
+	assert.Equal(t, []map[string]interface{}{
+		nil,
+		nil,
+		{"scope": "admin", "doc": "the admin page"},
+		nil,
+		{"scope": "admin"},
+		{"scope": "admin"},
+		{"scope": "admin"},
+	}, meta)
+
+	// the route found by the middleware is passed by the next route
+	router = NewRouter()
+	router.Use(func(c *Context) {
+		c.Route()
+		c.Next()
+	})
+	router.GET("/next", func(c *Context) { c.Next() }).WithMeta("name", "first")
+	router.GET("/next", func(c *Context) {
+		v, _ := c.Route().Meta("name")
+		c.Send(v.(string))
+	}).WithMeta("name", "second")
+	assert.Equal(t, "second", Test(router).Get("/next").Do().Body.String())
+
+	var route *Route
+	_, ok := route.Meta("scope")
+	assert.False(t, ok)
+	assert.Nil(t, NewContext(emptyRequest, httptest.NewRecorder()).Route())
+}
+
 func TestRouter_Use(t *testing.T) {
 	deferFn := func() {
 		assert.NotNil(t, recover())
@@ -959,6 +1803,7 @@ func TestRouter_Use(t *testing.T) {
 		{[]interface{}{"/foo", func(c *Context) {}}, nil},
 		{[]interface{}{func(c *Context) {}}, nil},
 		{[]interface{}{func(v interface{}, c *Context) {}}, nil},
+		{[]interface{}{"/foo", func(c *Context) error { return nil }}, nil},
 		{[]interface{}{"/foo", func(v interface{}, c *Context) {}}, nil},
 		{[]interface{}{childRouter}, nil},
 		{[]interface{}{"/foo", childRouter}, nil},
@@ -977,6 +1822,102 @@ func TestRouter_Use(t *testing.T) {
 	}
 }
 
+func TestRouter_UseMethod(t *testing.T) {
+	router := NewRouter()
+	router.UseMethod(http.MethodPost, "/api", func(c *Context) {
+		c.Set("X-Checked", "true")
+		c.Next()
+	})
+	router.GET("/api/foo", func(c *Context) {
+		c.Send("get")
+	})
+	router.POST("/api/foo", func(c *Context) {
+		c.Send("post")
+	})
+	router.POST("/foo", func(c *Context) {
+		c.Send("post")
+	})
+
+	tests := []struct {
+		method   string
+		path     string
+		expected string
+	}{
+		{http.MethodGet, "/api/foo", ""},
+		{http.MethodPost, "/api/foo", "true"},
+		{http.MethodPost, "/foo", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+			assert.Equal(t, tt.expected, w.Header().Get("X-Checked"))
+		})
+	}
+
+	assert.Equal(t, RouteInfo{
+		Method:       http.MethodPost,
+		Path:         "/api",
+		IsMiddleware: true,
+	}, router.Routes()[0])
+}
+
+func TestRouter_AutoOptions(t *testing.T) {
+	h := func(c *Context) {
+		c.Send(c.Request.Method)
+	}
+
+	sub := NewRouter()
+	sub.PUT("/", h)
+
+	router := NewRouter(&RouterOption{AutoOptions: true, AutoHead: true})
+	router.Route("/users").GET(h).POST(h)
+	router.GET("/users", h)
+	router.Route("/users/:id").GET(h).DELETE(h)
+	router.Use("/users", sub)
+	router.Route("/custom").GET(h).OPTIONS(func(c *Context) {
+		c.Set("Allow", "GET")
+		c.SendStatus(http.StatusNoContent)
+	})
+	router.ALL("/all", h)
+
+	tests := []struct {
+		path          string
+		expectedCode  int
+		expectedAllow string
+	}{
+		{"/users", 200, "GET,HEAD,POST,PUT"},
+		{"/users/", 200, "GET,HEAD,POST,PUT"},
+		{"/users/1", 200, "GET,HEAD,DELETE"},
+		{"/custom", 204, "GET"},
+		{"/all", 200, ""},
+		{"/not-found", 404, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, tt.path, nil))
+			assert.Equal(t, tt.expectedCode, w.Code)
+			assert.Equal(t, tt.expectedAllow, w.Header().Get("Allow"))
+			if tt.expectedCode == 200 && tt.expectedAllow != "" {
+				assert.Equal(t, tt.expectedAllow, w.Body.String())
+			}
+		})
+	}
+
+	t.Run("disabled", func(t *testing.T) {
+		router := NewRouter()
+		router.Route("/users").GET(h).POST(h)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodOptions, "/users", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+		assert.Equal(t, "", w.Header().Get("Allow"))
+	})
+}
+
 func TestRouterProxy(t *testing.T) {
 	expectedBody, route := "OK", "/foo"
 	handle := func(c *Context) {
@@ -1052,3 +1993,47 @@ func stringOr(s1, s2 string) string {
 	}
 	return s2
 }
+
+func BenchmarkRouter_StaticRoutes(b *testing.B) {
+	router := NewRouter()
+	router.Use(func(c *Context) {
+		c.Next()
+	})
+	for i := 0; i < 20; i++ {
+		router.GET(fmt.Sprintf("/static/%d", i), func(c *Context) {})
+	}
+	router.GET("/static/foo/bar", func(c *Context) {})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/static/foo/bar", nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		router.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkRouter_ParamRoutes(b *testing.B) {
+	router := NewRouter()
+	router.Use(func(c *Context) {
+		c.Next()
+	})
+	router.GET("/users/:id/books/:book", func(c *Context) {})
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/users/1/books/2", nil)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		router.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkRouter_Mount(b *testing.B) {
+	sub := newLargeRouter(200)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		NewRouter().Use("/api", sub)
+	}
+}
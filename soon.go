@@ -5,17 +5,111 @@
 package soon
 
 import (
+	"fmt"
+	"io"
 	"net/http"
+	"sync"
+	"text/tabwriter"
 )
 
 // App represents an application with Soon framework.
 type App struct {
 	*Router
+
+	// This mutex protect settings map
+	mu sync.RWMutex
+
+	settings map[string]interface{}
 }
 
 // New creates a Soon application.
 func New(options ...*RouterOption) *App {
-	return &App{Router: NewRouter(options...)}
+	app := &App{Router: NewRouter(options...), settings: map[string]interface{}{
+		"x-powered-by": true,
+		"etag":         true,
+	}}
+	app.Router.app = app
+	return app
+}
+
+// Set assigns the setting name to value, like app.set of Express, and returns
+// the app for chaining. The settings take precedence over the package level
+// ones for the requests served by this app:
+//
+//	"trust proxy"          bool, see SetTrustProxy
+//	"x-powered-by"         bool, whether to send the X-Powered-By header, defaults to true
+//	"etag"                 bool, whether to send the weak ETag of files, defaults to true
+//	"json spaces"          int or string, the indentation of c.Json and c.Jsonp
+//	"templates"            *template.Template, the templates rendered by c.HTMLTemplate
+//	"layout"               string, the name of the layout template of c.HTMLTemplate
+//	"json codec"           JSONCodec, the JSON implementation, see SetJSONCodec
+//	"cookie defaults"      CookieDefaults, see SetCookieDefaults
+//	"max multipart memory" int64, see SetMaxMultipartMemory
+//
+// Any other name may be used to store a custom value. It panics if the value
+// of "trust proxy" isn't a bool, so that a value like "false" can't enable it.
+func (app *App) Set(name string, value interface{}) *App {
+	if _, ok := value.(bool); name == "trust proxy" && !ok {
+		panic(fmt.Sprintf("trust proxy setting should be bool, got %T", value))
+	}
+	app.mu.Lock()
+	defer app.mu.Unlock()
+	app.settings[name] = value
+	return app
+}
+
+// Get returns the value of the setting name, or nil if it's not set.
+func (app *App) Get(name string) interface{} {
+	v, _ := app.setting(name)
+	return v
+}
+
+// Enabled reports whether the setting name is enabled, that is true, non-zero
+// number or non-empty string, or any other non-nil value.
+func (app *App) Enabled(name string) bool {
+	return truthy(app.Get(name))
+}
+
+// Disabled reports whether the setting name is disabled, it's the opposite of
+// Enabled.
+func (app *App) Disabled(name string) bool {
+	return !app.Enabled(name)
+}
+
+// Enable sets the setting name to true.
+func (app *App) Enable(name string) *App {
+	return app.Set(name, true)
+}
+
+// Disable sets the setting name to false.
+func (app *App) Disable(name string) *App {
+	return app.Set(name, false)
+}
+
+// setting returns the value of the setting name, and whether it's set.
+func (app *App) setting(name string) (interface{}, bool) {
+	app.mu.RLock()
+	defer app.mu.RUnlock()
+	v, ok := app.settings[name]
+	return v, ok
+}
+
+// truthy reports whether the setting value is considered as enabled, that is
+// true, non-zero number or non-empty string, or any other non-nil value.
+func truthy(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return x
+	case int:
+		return x != 0
+	case float64:
+		return x != 0
+	case string:
+		return x != ""
+	}
+	return true
 }
 
 // Run attaches the router to a http.Server and starts listening and serving HTTP requests.
@@ -25,3 +119,37 @@ func (app *App) Run(addr ...string) error {
 	debugPrint("Listening and serving HTTP on %s\n", address)
 	return http.ListenAndServe(address, app)
 }
+
+// SetErrorHandler sets the handler for the errors which are not handled by
+// any error handler registered by Use, including the not found error when no
+// route matches. It replaces the default error handler, which responds with
+// the status and text of the error. The default error handler is used instead
+// if the given handler panics.
+func (app *App) SetErrorHandler(h ErrorHandle) {
+	app.Router.errorHandler = h
+}
+
+// MapError maps the errors matching target by errors.Is to the http status
+// code, when they reach the end of chain without status, such as
+// `app.MapError(sql.ErrNoRows, 404)`. The error handler set by SetErrorHandler
+// receives the mapped error as HttpError, which wraps the original one.
+// The mappings are consulted in the order of registration.
+func (app *App) MapError(target error, status int) {
+	app.Router.errorStatuses = append(app.Router.errorStatuses, errorStatus{target, status})
+}
+
+// PrintRoutes writes a table of all registered routes to w.
+func (app *App) PrintRoutes(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATH\tTYPE")
+	for _, route := range app.Routes() {
+		method, kind := route.Method, "route"
+		if route.IsMiddleware {
+			method, kind = "*", "middleware"
+		} else if route.IsErrorHandler {
+			method, kind = "*", "error handler"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", method, route.Path, kind)
+	}
+	tw.Flush()
+}
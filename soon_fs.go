@@ -0,0 +1,26 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build go1.16
+// +build go1.16
+
+package soon
+
+import (
+	"html/template"
+	"io/fs"
+)
+
+// LoadHTMLFS parses the templates matching patterns from fsys, such as an
+// embed.FS, and sets them as the "templates" setting rendered by
+// c.HTMLTemplate. The templates are named by the base name of files. It panics
+// if the parsing fails, for example:
+//
+//	//go:embed views
+//	var views embed.FS
+//
+//	app.LoadHTMLFS(views, "views/*.html")
+func (app *App) LoadHTMLFS(fsys fs.FS, patterns ...string) {
+	app.Set("templates", template.Must(template.ParseFS(fsys, patterns...)))
+}
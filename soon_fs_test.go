@@ -0,0 +1,46 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+//go:build go1.16
+// +build go1.16
+
+package soon
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApp_LoadHTMLFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"views/layout.html": {Data: []byte(`<main>{{.Content}}</main>`)},
+		"views/index.html":  {Data: []byte(`<h1>{{.}}</h1>`)},
+		"views/about.html":  {Data: []byte(`<p>{{.}}</p>`)},
+		"views/readme.md":   {Data: []byte(`ignored`)},
+	}
+
+	app := New()
+	app.LoadHTMLFS(fsys, "views/*.html")
+	app.GET("/:page", func(c *Context) {
+		c.HTMLTemplate(c.Param("page")+".html", "<soon>")
+	})
+
+	w := Test(app).Get("/about").Do()
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "<p>&lt;soon&gt;</p>", w.Body.String())
+
+	app.Set("layout", "layout.html")
+	w = Test(app).Get("/index").Do()
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "<main><h1>&lt;soon&gt;</h1></main>", w.Body.String())
+
+	w = Test(app).Get("/readme").Do()
+	assert.Equal(t, 500, w.Code)
+
+	assert.Panics(t, func() {
+		app.LoadHTMLFS(fsys, "not-exist/*.html")
+	})
+}
@@ -5,9 +5,24 @@
 package soon
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
 	"testing"
 
+	"github.com/soongo/soon/binding"
+	"github.com/soongo/soon/internal"
+	"github.com/soongo/soon/internal/json"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestApp_Run(t *testing.T) {
@@ -26,3 +41,402 @@ func TestApp_Run(t *testing.T) {
 	assert.Equal(200, statusCode)
 	assert.Equal("hello", body)
 }
+
+func TestApp_PrintRoutes(t *testing.T) {
+	app, handle := New(), func(c *Context) {}
+	app.Use(handle)
+	app.GET("/", handle)
+	app.POST("/users/:id", handle)
+	app.Use(func(v interface{}, c *Context) {})
+
+	expected := `METHOD  PATH        TYPE
+*       /           middleware
+GET     /           route
+POST    /users/:id  route
+*       /           error handler
+`
+	var buf bytes.Buffer
+	app.PrintRoutes(&buf)
+	assert.Equal(t, expected, buf.String())
+}
+
+func TestApp_SetErrorHandler(t *testing.T) {
+	errFoo := errors.New("foo")
+	tests := []struct {
+		path       string
+		handle     ErrorHandle
+		received   interface{}
+		statusCode int
+		body       string
+	}{
+		{"/panic", nil, errFoo, 500, "custom: foo"},
+		{"/next", nil, errFoo, 500, "custom: foo"},
+		{"/not-found", nil, internal.ErrNotFound, 404, "custom: Not Found"},
+		{"/panic", func(v interface{}, c *Context) { c.Status(400).Send("handled") }, nil, 400, "handled"},
+		{"/panic", func(v interface{}, c *Context) { c.Next(v) }, errFoo, 500, "custom: foo"},
+		{"/panic", func(v interface{}, c *Context) { panic(v) }, errFoo, 500, "custom: foo"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			var received interface{}
+			app := New()
+			app.SetErrorHandler(func(v interface{}, c *Context) {
+				received = v
+				status, text := resolveError(v)
+				c.Status(status).Send("custom: " + text)
+			})
+			app.GET("/panic", func(c *Context) { panic(errFoo) })
+			app.GET("/next", func(c *Context) { c.Next(errFoo) })
+			if tt.handle != nil {
+				app.Use(tt.handle)
+			}
+
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			assert.Equal(t, tt.received, received)
+			assert.Equal(t, tt.statusCode, w.Code)
+			assert.Equal(t, tt.body, w.Body.String())
+		})
+	}
+
+	t.Run("panic", func(t *testing.T) {
+		app := New()
+		app.SetErrorHandler(func(v interface{}, c *Context) {
+			panic(NewError(503, "unavailable"))
+		})
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+		assert.Equal(t, 503, w.Code)
+		assert.Equal(t, http.StatusText(503)+"\n", w.Body.String())
+	})
+}
+
+func TestApp_MapError(t *testing.T) {
+	errNoRows := errors.New("no rows")
+	app := New()
+	app.MapError(errNoRows, 404)
+	app.MapError(context.DeadlineExceeded, 504)
+	app.MapError(errNoRows, 410)
+	app.GET("/panic", func(c *Context) { panic(errNoRows) })
+	app.GET("/wrapped", func(c *Context) { c.Next(fmt.Errorf("find user: %w", errNoRows)) })
+	app.GET("/timeout", func(c *Context) { panic(context.DeadlineExceeded) })
+	app.GET("/status", func(c *Context) { panic(NewError(400, "no rows")) })
+	app.GET("/unmapped", func(c *Context) { panic(errors.New("oops")) })
+	app.GET("/string", func(c *Context) { panic("no rows") })
+
+	tests := []struct {
+		path       string
+		statusCode int
+		body       string
+	}{
+		{"/panic", 404, "no rows"},
+		{"/wrapped", 404, "find user: no rows"},
+		{"/timeout", 504, http.StatusText(504)},
+		{"/status", 400, "no rows"},
+		{"/unmapped", 500, http.StatusText(500)},
+		{"/string", 500, http.StatusText(500)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, httptest.NewRequest("GET", tt.path, nil))
+			assert.Equal(t, tt.statusCode, w.Code)
+			assert.Equal(t, tt.body+"\n", w.Body.String())
+		})
+	}
+
+	t.Run("error handler", func(t *testing.T) {
+		var received interface{}
+		app.SetErrorHandler(func(v interface{}, c *Context) {
+			received = v
+			status, text := resolveError(v)
+			c.Status(status).Send(text)
+		})
+		w := httptest.NewRecorder()
+		app.ServeHTTP(w, httptest.NewRequest("GET", "/panic", nil))
+		assert.Equal(t, 404, w.Code)
+		assert.True(t, errors.Is(received.(error), errNoRows))
+	})
+}
+
+func TestApp_Set(t *testing.T) {
+	app := New()
+	assert.Nil(t, app.Get("foo"))
+	assert.Equal(t, app, app.Set("foo", "bar"))
+	assert.Equal(t, "bar", app.Get("foo"))
+
+	app.GET("/", func(c *Context) {
+		c.Send(c.Request.IP() + " " + c.Request.Hostname())
+	})
+
+	tests := []struct {
+		trustProxy       interface{}
+		globalTrustProxy bool
+		expected         string
+	}{
+		{nil, false, "192.0.2.1 example.com"},
+		{nil, true, "203.0.113.1 proxy.example.com"},
+		{true, false, "203.0.113.1 proxy.example.com"},
+		{false, true, "192.0.2.1 example.com"},
+	}
+
+	defer SetTrustProxy(false)
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			SetTrustProxy(tt.globalTrustProxy)
+			if tt.trustProxy != nil {
+				app.Set("trust proxy", tt.trustProxy)
+			}
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Host, req.RemoteAddr = "example.com", "192.0.2.1:1234"
+			req.Header.Set("X-Forwarded-For", "203.0.113.1")
+			req.Header.Set("X-Forwarded-Host", "proxy.example.com")
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, req)
+			assert.Equal(t, tt.expected, w.Body.String())
+		})
+	}
+
+	// the settings of app don't affect other routers
+	router := NewRouter()
+	router.GET("/", func(c *Context) {
+		c.Send(c.Request.IP())
+	})
+	SetTrustProxy(false)
+	app.Set("trust proxy", true)
+	w := Test(router).Get("/").Set("X-Forwarded-For", "203.0.113.1").Do()
+	assert.Equal(t, "192.0.2.1", w.Body.String())
+
+	// only bool is accepted, so that a string like "false" can't enable it
+	for _, v := range []interface{}{"false", "true", 0, 1, nil} {
+		assert.Panics(t, func() { app.Set("trust proxy", v) })
+	}
+	assert.Equal(t, true, app.Get("trust proxy"))
+}
+
+func TestTruthy(t *testing.T) {
+	tests := []struct {
+		v        interface{}
+		expected bool
+	}{
+		{nil, false},
+		{false, false},
+		{true, true},
+		{0, false},
+		{2, true},
+		{0.0, false},
+		{"", false},
+		{"foo", true},
+		{struct{}{}, true},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, truthy(tt.v))
+	}
+}
+
+func TestApp_Enabled(t *testing.T) {
+	app := New()
+	assert.True(t, app.Enabled("x-powered-by"))
+	assert.False(t, app.Enabled("foo"))
+	assert.True(t, app.Disabled("foo"))
+
+	assert.Equal(t, app, app.Enable("foo"))
+	assert.True(t, app.Enabled("foo"))
+	assert.Equal(t, true, app.Get("foo"))
+
+	assert.Equal(t, app, app.Disable("foo"))
+	assert.True(t, app.Disabled("foo"))
+	assert.Equal(t, false, app.Get("foo"))
+}
+
+func TestApp_XPoweredBy(t *testing.T) {
+	app := New()
+	app.GET("/", func(c *Context) {
+		c.Send("foo")
+	})
+
+	w := Test(app).Get("/").Do()
+	assert.Equal(t, "Soon", w.Header().Get("X-Powered-By"))
+
+	app.Disable("x-powered-by")
+	w = Test(app).Get("/").Do()
+	assert.Equal(t, "foo", w.Body.String())
+	_, ok := w.Header()["X-Powered-By"]
+	assert.False(t, ok)
+}
+
+func TestApp_ETag(t *testing.T) {
+	pwd, err := os.Getwd()
+	assert.NoError(t, err)
+
+	app := New()
+	app.Use(Static(pwd))
+
+	w := Test(app).Get("/soon.go").Do()
+	assert.Equal(t, 200, w.Code)
+	assert.Regexp(t, `^W/"`, w.Header().Get("ETag"))
+
+	app.Disable("etag")
+	w = Test(app).Get("/soon.go").Do()
+	assert.Equal(t, 200, w.Code)
+	_, ok := w.Header()["Etag"]
+	assert.False(t, ok)
+}
+
+type countingJSONCodec struct {
+	JSONCodec
+	encodes, decodes *int
+}
+
+func (c countingJSONCodec) NewEncoder(w io.Writer) json.Encoder {
+	*c.encodes++
+	return c.JSONCodec.NewEncoder(w)
+}
+
+func (c countingJSONCodec) NewDecoder(r io.Reader) json.Decoder {
+	*c.decodes++
+	return c.JSONCodec.NewDecoder(r)
+}
+
+func TestApp_JSONCodec(t *testing.T) {
+	var encodes, decodes int
+	app1, app2 := New(), New()
+	app1.Set("json codec", JSONCodec(countingJSONCodec{json.Std, &encodes, &decodes}))
+	for _, app := range []*App{app1, app2} {
+		app.POST("/", func(c *Context) {
+			var v map[string]string
+			c.MustBindJSON(&v)
+			c.Json(v)
+		})
+	}
+
+	w := Test(app1).Post("/").Send(map[string]string{"foo": "bar"}).Do()
+	assert.Equal(t, `{"foo":"bar"}`+"\n", w.Body.String())
+	assert.Equal(t, 1, encodes)
+	assert.Equal(t, 1, decodes)
+
+	// the codec of app doesn't affect other apps
+	w = Test(app2).Post("/").Send(map[string]string{"foo": "bar"}).Do()
+	assert.Equal(t, `{"foo":"bar"}`+"\n", w.Body.String())
+	assert.Equal(t, 1, encodes)
+	assert.Equal(t, 1, decodes)
+}
+
+func TestApp_CookieDefaults(t *testing.T) {
+	SetCookieDefaults(CookieDefaults{Path: "/"})
+	defer SetCookieDefaults(CookieDefaults{})
+
+	app1, app2 := New(), New()
+	app1.Set("cookie defaults", CookieDefaults{Path: "/app", HttpOnly: true})
+	for _, app := range []*App{app1, app2} {
+		app.GET("/", func(c *Context) {
+			c.Cookie(&http.Cookie{Name: "foo", Value: "bar"})
+			c.End()
+		})
+	}
+
+	w := Test(app1).Get("/").Do()
+	assert.Equal(t, "foo=bar; Path=/app; HttpOnly", w.Header().Get("Set-Cookie"))
+	w = Test(app2).Get("/").Do()
+	assert.Equal(t, "foo=bar; Path=/", w.Header().Get("Set-Cookie"))
+}
+
+func TestApp_MaxMultipartMemory(t *testing.T) {
+	type form struct {
+		File *multipart.FileHeader `form:"file"`
+	}
+	onDisk := func(fh *multipart.FileHeader) bool {
+		f, err := fh.Open()
+		require.NoError(t, err)
+		defer f.Close()
+		_, ok := f.(*os.File)
+		return ok
+	}
+
+	app1, app2 := New(), New()
+	app1.Set("max multipart memory", 10)
+	for _, app := range []*App{app1, app2} {
+		app.POST("/file", func(c *Context) {
+			fh, err := c.FormFile("file")
+			require.NoError(t, err)
+			c.Send(strconv.FormatBool(onDisk(fh)))
+		})
+		app.POST("/bind", func(c *Context) {
+			var f form
+			c.MustBindWith(&f, binding.FormMultipart)
+			c.Send(strconv.FormatBool(onDisk(f.File)))
+		})
+	}
+
+	file := multipartFile{"file", "foo.txt", bytes.Repeat([]byte("a"), 100)}
+	for _, path := range []string{"/file", "/bind"} {
+		for app, expected := range map[*App]string{app1: "true", app2: "false"} {
+			req := createMultipartRequest(t, nil, file)
+			req.URL.Path = path
+			w := httptest.NewRecorder()
+			app.ServeHTTP(w, req)
+			assert.Equal(t, expected, w.Body.String(), path)
+		}
+	}
+}
+
+func TestApp_JSONSpaces(t *testing.T) {
+	app := New()
+	app.GET("/json", func(c *Context) {
+		c.Json(map[string]int{"foo": 1})
+	})
+	app.GET("/jsonp", func(c *Context) {
+		c.Jsonp(map[string]int{"foo": 1})
+	})
+
+	tests := []struct {
+		spaces   interface{}
+		json     string
+		jsonBody string
+	}{
+		{nil, "{\"foo\":1}\n", "{\"foo\":1}"},
+		{2, "{\n  \"foo\": 1\n}\n", "{\n  \"foo\": 1\n}"},
+		{"\t", "{\n\t\"foo\": 1\n}\n", "{\n\t\"foo\": 1\n}"},
+		{0, "{\"foo\":1}\n", "{\"foo\":1}"},
+	}
+
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			app.Set("json spaces", tt.spaces)
+			w := Test(app).Get("/json").Do()
+			assert.Equal(t, tt.json, w.Body.String())
+			assert.Equal(t, strconv.Itoa(len(tt.json)), w.Header().Get("Content-Length"))
+
+			w = Test(app).Get("/jsonp?callback=cb").Do()
+			assert.Equal(t, "/**/ typeof cb === 'function' && cb("+tt.jsonBody+");", w.Body.String())
+		})
+	}
+}
+
+func TestApp_HTMLTemplate(t *testing.T) {
+	tmpl := template.Must(template.New("layout").Parse(
+		`<html><title>{{.Data.Title}}</title><body>{{.Content}}</body></html>`))
+	template.Must(tmpl.New("page").Parse(`<h1>{{.Title}}</h1>`))
+
+	app := New()
+	app.GET("/", func(c *Context) {
+		c.HTMLTemplate("page", map[string]string{"Title": "Home"})
+	})
+
+	w := Test(app).Get("/").Do()
+	assert.Equal(t, 500, w.Code)
+
+	app.Set("templates", tmpl)
+	w = Test(app).Get("/").Do()
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, htmlType, w.Header().Get("Content-Type"))
+	assert.Equal(t, "<h1>Home</h1>", w.Body.String())
+
+	app.Set("layout", "layout")
+	w = Test(app).Get("/").Do()
+	assert.Equal(t, 200, w.Code)
+	assert.Equal(t, "<html><title>Home</title><body><h1>Home</h1></body></html>", w.Body.String())
+}
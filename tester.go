@@ -0,0 +1,127 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package soon
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/soongo/soon/internal/json"
+)
+
+// Tester exercises an http.Handler, such as an app or a router, without a
+// live server, the responses are recorded by httptest.ResponseRecorder.
+//
+//	w := soon.Test(app).Get("/users/1").Set("Accept", "application/json").Do()
+//	assert.Equal(t, 200, w.Code)
+type Tester struct {
+	handler http.Handler
+}
+
+// TestRequest is a request to be sent by Tester, see Tester.Request.
+type TestRequest struct {
+	handler http.Handler
+	method  string
+	path    string
+	header  http.Header
+	body    io.Reader
+	err     error
+}
+
+// Test returns a Tester for the given handler.
+func Test(handler http.Handler) *Tester {
+	return &Tester{handler: handler}
+}
+
+// Request returns a TestRequest with the given method and path.
+func (t *Tester) Request(method, path string) *TestRequest {
+	return &TestRequest{
+		handler: t.handler,
+		method:  method,
+		path:    path,
+		header:  make(http.Header),
+	}
+}
+
+// Get is a shortcut for t.Request("GET", path)
+func (t *Tester) Get(path string) *TestRequest {
+	return t.Request(http.MethodGet, path)
+}
+
+// Head is a shortcut for t.Request("HEAD", path)
+func (t *Tester) Head(path string) *TestRequest {
+	return t.Request(http.MethodHead, path)
+}
+
+// Post is a shortcut for t.Request("POST", path)
+func (t *Tester) Post(path string) *TestRequest {
+	return t.Request(http.MethodPost, path)
+}
+
+// Put is a shortcut for t.Request("PUT", path)
+func (t *Tester) Put(path string) *TestRequest {
+	return t.Request(http.MethodPut, path)
+}
+
+// Patch is a shortcut for t.Request("PATCH", path)
+func (t *Tester) Patch(path string) *TestRequest {
+	return t.Request(http.MethodPatch, path)
+}
+
+// Delete is a shortcut for t.Request("DELETE", path)
+func (t *Tester) Delete(path string) *TestRequest {
+	return t.Request(http.MethodDelete, path)
+}
+
+// Set sets the request header entries associated with key to the single
+// element value.
+func (r *TestRequest) Set(key, value string) *TestRequest {
+	r.header.Set(key, value)
+	return r
+}
+
+// Send sets the request body. A string, []byte or io.Reader is sent as it
+// is, and other values are encoded as JSON, in which case the Content-Type
+// defaults to "application/json".
+func (r *TestRequest) Send(body interface{}) *TestRequest {
+	switch v := body.(type) {
+	case string:
+		r.body = strings.NewReader(v)
+	case []byte:
+		r.body = bytes.NewReader(v)
+	case io.Reader:
+		r.body = v
+	default:
+		bs, err := json.Marshal(v)
+		if err != nil {
+			r.err = err
+			return r
+		}
+		r.body = bytes.NewReader(bs)
+		if r.header.Get("Content-Type") == "" {
+			r.header.Set("Content-Type", "application/json")
+		}
+	}
+	return r
+}
+
+// Do dispatches the request to the handler, and returns the recorded
+// response. It panics if the body passed to Send can't be encoded.
+func (r *TestRequest) Do() *httptest.ResponseRecorder {
+	if r.err != nil {
+		panic(r.err)
+	}
+
+	req := httptest.NewRequest(r.method, r.path, r.body)
+	for k, v := range r.header {
+		req.Header[k] = v
+	}
+	w := httptest.NewRecorder()
+	r.handler.ServeHTTP(w, req)
+	return w
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package soon
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTest(t *testing.T) {
+	type user struct {
+		Name string `json:"name"`
+	}
+
+	router := NewRouter()
+	router.GET("/users/:id", func(c *Context) {
+		c.Set("X-Id", c.Param("id"))
+		c.Json(map[string]string{"id": c.Param("id"), "accept": c.Request.Get("Accept")})
+	})
+	router.POST("/users", func(c *Context) {
+		var u user
+		c.MustBindJSON(&u)
+		c.Status(http.StatusCreated).Send("created " + u.Name)
+	})
+	router.PUT("/echo", func(c *Context) {
+		c.Type(c.Request.Get("Content-Type"))
+		body, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			panic(err)
+		}
+		c.Send(string(body))
+	})
+
+	w := Test(router).Get("/users/1").Set("Accept", "application/json").Do()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "1", w.Header().Get("X-Id"))
+	assert.Equal(t, `{"accept":"application/json","id":"1"}`, strings.TrimSpace(w.Body.String()))
+
+	w = Test(router).Post("/users").Send(user{Name: "foo"}).Do()
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "created foo", w.Body.String())
+
+	w = Test(router).Post("/users").Send(`{"name":"bar"}`).Do()
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "created bar", w.Body.String())
+
+	w = Test(router).Put("/echo").Set("Content-Type", "text/plain").Send([]byte("foo")).Do()
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	assert.Equal(t, "foo", w.Body.String())
+
+	w = Test(router).Delete("/users/1").Do()
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	assert.Panics(t, func() {
+		Test(router).Post("/users").Send(make(chan int)).Do()
+	})
+}
@@ -0,0 +1,41 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+)
+
+// SignCookie signs the cookie value with secret, the HMAC-SHA256 signature is
+// appended to value after a dot, such as `foo.<signature>`, and it consists of
+// the valid characters of cookie value only.
+func SignCookie(value string, secret []byte) string {
+	return value + "." + cookieSignature(value, secret)
+}
+
+// UnsignCookie verifies the cookie value signed by SignCookie with secret, and
+// returns the original value. It reports false if the signature is missing or
+// doesn't match, such as the value is tampered.
+func UnsignCookie(signed string, secret []byte) (string, bool) {
+	i := strings.LastIndex(signed, ".")
+	if i < 0 {
+		return "", false
+	}
+
+	value, signature := signed[:i], signed[i+1:]
+	if !hmac.Equal([]byte(signature), []byte(cookieSignature(value, secret))) {
+		return "", false
+	}
+	return value, true
+}
+
+func cookieSignature(value string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
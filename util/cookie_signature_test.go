@@ -0,0 +1,40 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignCookie(t *testing.T) {
+	secret := []byte("secret")
+	signed := SignCookie("foo.bar", secret)
+	assert.Equal(t, "foo.bar.", signed[:8])
+	assert.Equal(t, signed, SignCookie("foo.bar", secret))
+	assert.NotEqual(t, signed, SignCookie("foo.bar", []byte("other")))
+
+	tests := []struct {
+		signed   string
+		secret   []byte
+		expected string
+		ok       bool
+	}{
+		{signed, secret, "foo.bar", true},
+		{SignCookie("", secret), secret, "", true},
+		{signed, []byte("other"), "", false},
+		{"foo.baz" + signed[7:], secret, "", false},
+		{signed + "x", secret, "", false},
+		{"foo", secret, "", false},
+		{"", secret, "", false},
+	}
+
+	for _, tt := range tests {
+		value, ok := UnsignCookie(tt.signed, tt.secret)
+		assert.Equal(t, tt.expected, value)
+		assert.Equal(t, tt.ok, ok)
+	}
+}
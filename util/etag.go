@@ -0,0 +1,39 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"os"
+	"strconv"
+)
+
+// ETag generates a quoted entity tag of the given data, which is composed of
+// the length and the sha1 hash of data, such as `"5-qvTGHdzF6KLavt4PO0gs2a6pQ00"`.
+// If weak is true, the tag is prefixed with `W/`.
+func ETag(data []byte, weak bool) string {
+	hash := sha1.Sum(data)
+	tag := strconv.FormatInt(int64(len(data)), 16) + "-" +
+		base64.StdEncoding.EncodeToString(hash[:])[:27]
+	return formatETag(tag, weak)
+}
+
+// ETagFromFileInfo generates a quoted entity tag of the given file info, which
+// is composed of the size and the modification time of file. If weak is true,
+// the tag is prefixed with `W/`.
+func ETagFromFileInfo(fi os.FileInfo, weak bool) string {
+	mtime := fi.ModTime().UnixNano() / 1e6
+	tag := strconv.FormatInt(fi.Size(), 16) + "-" + strconv.FormatInt(mtime, 16)
+	return formatETag(tag, weak)
+}
+
+func formatETag(tag string, weak bool) string {
+	tag = "\"" + tag + "\""
+	if weak {
+		tag = "W/" + tag
+	}
+	return tag
+}
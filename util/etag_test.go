@@ -0,0 +1,74 @@
+// Copyright 2020 Guoyao Wu. All rights reserved.
+// Use of this source code is governed by a MIT style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeFileInfo struct {
+	os.FileInfo
+	size    int64
+	modTime time.Time
+}
+
+func (fi fakeFileInfo) Size() int64 {
+	return fi.size
+}
+
+func (fi fakeFileInfo) ModTime() time.Time {
+	return fi.modTime
+}
+
+func TestETag(t *testing.T) {
+	tests := []struct {
+		data     string
+		weak     bool
+		expected string
+	}{
+		{"", false, `"0-2jmj7l5rSw0yVb/vlWAYkK/YBwk"`},
+		{"", true, `W/"0-2jmj7l5rSw0yVb/vlWAYkK/YBwk"`},
+		{"beep boop", false, `"9-fINXV39R1PCo05OqGqr7KIY9lCE"`},
+		{"beep boop", true, `W/"9-fINXV39R1PCo05OqGqr7KIY9lCE"`},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, ETag([]byte(tt.data), tt.weak))
+		assert.Equal(t, ETag([]byte(tt.data), tt.weak), ETag([]byte(tt.data), tt.weak))
+	}
+	assert.NotEqual(t, ETag([]byte("foo"), false), ETag([]byte("bar"), false))
+}
+
+func TestETagFromFileInfo(t *testing.T) {
+	fi := fakeFileInfo{size: 1024, modTime: time.Unix(1577836800, 123e6)}
+	assert.Equal(t, `"400-16f5e66e87b"`, ETagFromFileInfo(fi, false))
+	assert.Equal(t, `W/"400-16f5e66e87b"`, ETagFromFileInfo(fi, true))
+
+	fi.modTime = fi.modTime.Add(time.Second)
+	assert.NotEqual(t, `"400-16f5e66e87b"`, ETagFromFileInfo(fi, false))
+}
+
+func TestETagFresh(t *testing.T) {
+	tags := []string{
+		ETag([]byte("foo"), false),
+		ETag([]byte("foo"), true),
+		ETagFromFileInfo(fakeFileInfo{size: 1, modTime: time.Now()}, false),
+	}
+
+	for _, tag := range tags {
+		reqHeader, resHeader := http.Header{}, http.Header{}
+		reqHeader.Set("If-None-Match", tag)
+		resHeader.Set("ETag", tag)
+		assert.True(t, Fresh(reqHeader, resHeader))
+
+		resHeader.Set("ETag", ETag([]byte("bar"), false))
+		assert.False(t, Fresh(reqHeader, resHeader))
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/dlclark/regexp2"
 )
@@ -142,7 +143,9 @@ func AppendToVaryHeader(vary string, fields []string) string {
 
 // Fresh checks freshness of the response using request and response headers.
 func Fresh(reqHeader, resHeader http.Header) bool {
-	modifiedSince, noneMatch := reqHeader.Get("if-modified-since"), reqHeader.Get("if-none-match")
+	// If-None-Match may be sent in multiple header lines
+	modifiedSince := reqHeader.Get("if-modified-since")
+	noneMatch := strings.Join(GetHeaderValues(reqHeader, "if-none-match"), ",")
 
 	// unconditional request
 	if modifiedSince == "" && noneMatch == "" {
@@ -169,8 +172,8 @@ func Fresh(reqHeader, resHeader http.Header) bool {
 
 		etagStale, matches := true, ParseHeader(noneMatch)
 		for i, length := 0, len(matches); i < length; i++ {
-			match := matches[i]
-			if match == etag || match == "W/"+etag || "W/"+match == etag {
+			match := strings.TrimSpace(matches[i])
+			if weakETagMatch(match, etag) {
 				etagStale = false
 				break
 			}
@@ -199,6 +202,14 @@ func Fresh(reqHeader, resHeader http.Header) bool {
 	return true
 }
 
+// weakETagMatch reports whether the given entity tags match by the weak
+// comparison defined in RFC 7232, section 2.3.2, which compares the opaque
+// tags regardless of the weak indicator `W/`.
+func weakETagMatch(a, b string) bool {
+	a, b = strings.TrimPrefix(a, "W/"), strings.TrimPrefix(b, "W/")
+	return a != "" && a == b
+}
+
 // ParseHeader parses header with type string into a slice.
 func ParseHeader(header string) []string {
 	start, end, length := 0, 0, len(header)
@@ -228,6 +239,133 @@ func ParseHeader(header string) []string {
 	return values
 }
 
+// ParseLinks parses the Link header, such as
+// `<http://api/?page=2>; rel="next", <http://api/?page=5>; rel="last"`, into a
+// map from the relation types to the urls. A `rel` param with multiple space
+// separated types, such as `rel="prev first"`, is mapped to each type, and the
+// first one wins if a type occurs more than once. The malformed entries are
+// skipped.
+func ParseLinks(header string) map[string]string {
+	links := make(map[string]string)
+	i, length := 0, len(header)
+	for i < length {
+		// skip to the url of entry
+		if header[i] != '<' {
+			i++
+			continue
+		}
+		end := strings.IndexByte(header[i:], '>')
+		if end == -1 {
+			break
+		}
+		url := header[i+1 : i+end]
+		i += end + 1
+
+		// params are separated by `;`, until `,` of next entry
+		for i < length && header[i] != ',' {
+			if header[i] != ';' {
+				i++
+				continue
+			}
+			var name, value string
+			name, value, i = parseLinkParam(header, i+1)
+			if strings.EqualFold(name, "rel") {
+				for _, rel := range strings.Fields(value) {
+					rel = strings.ToLower(rel)
+					if _, ok := links[rel]; !ok {
+						links[rel] = url
+					}
+				}
+			}
+		}
+	}
+	return links
+}
+
+// parseLinkParam parses the `name=value` param of Link header from `i`, the
+// value may be quoted. It returns the index after the param.
+func parseLinkParam(header string, i int) (name, value string, next int) {
+	length := len(header)
+	start := i
+	for i < length && strings.IndexByte("=;,", header[i]) == -1 {
+		i++
+	}
+	name = strings.TrimSpace(header[start:i])
+	if i >= length || header[i] != '=' {
+		return name, "", i
+	}
+
+	i++
+	for i < length && header[i] == ' ' {
+		i++
+	}
+	if i < length && header[i] == '"' {
+		var b strings.Builder
+		for i++; i < length && header[i] != '"'; i++ {
+			if header[i] == '\\' && i+1 < length {
+				i++
+			}
+			b.WriteByte(header[i])
+		}
+		return name, b.String(), i + 1
+	}
+
+	start = i
+	for i < length && header[i] != ';' && header[i] != ',' {
+		i++
+	}
+	return name, strings.TrimSpace(header[start:i]), i
+}
+
+// ContentDisposition returns the value of Content-Disposition header with
+// type “attachment” and the given filename. If filename contains non-ASCII
+// characters, an ASCII fallback is set to the “filename=” parameter, and the
+// full filename is encoded into the “filename*=” parameter (RFC 5987).
+func ContentDisposition(filename string) string {
+	return ContentDispositionType("attachment", filename)
+}
+
+// ContentDispositionType is similar with ContentDisposition, but with the
+// given disposition type, such as “inline” to display the file in browser.
+func ContentDispositionType(dispositionType, filename string) string {
+	fallback, isASCII := make([]byte, 0, len(filename)), true
+	for _, r := range filename {
+		switch {
+		case r >= utf8.RuneSelf:
+			fallback, isASCII = append(fallback, '?'), false
+		case r == '"' || r == '\\':
+			fallback = append(fallback, '\\', byte(r))
+		default:
+			fallback = append(fallback, byte(r))
+		}
+	}
+
+	value := dispositionType + "; filename=\"" + string(fallback) + "\""
+	if !isASCII {
+		value += "; filename*=UTF-8''" + encodeRFC5987(filename)
+	}
+	return value
+}
+
+// encodeRFC5987 percent-encodes the given string except for the attr-char
+// defined in RFC 5987.
+func encodeRFC5987(str string) string {
+	const hex = "0123456789ABCDEF"
+	var b strings.Builder
+	for i := 0; i < len(str); i++ {
+		c := str[i]
+		if 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' ||
+			strings.IndexByte("!#$&+-.^_`|~", c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			b.WriteByte('%')
+			b.WriteByte(hex[c>>4])
+			b.WriteByte(hex[c&15])
+		}
+	}
+	return b.String()
+}
+
 // GetHeaderValues returns the header values of specified key.
 // This is a patch function of http.Header.Values for go version lower than 1.4
 func GetHeaderValues(h http.Header, key string) []string {
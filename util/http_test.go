@@ -191,6 +191,54 @@ func TestFresh(t *testing.T) {
 			http.Header{H("etag"): []string{`"foo"`}},
 			true,
 		},
+		{
+			"when requested with If-None-Match *, it should be fresh",
+			http.Header{H("if-none-match"): []string{"*"}},
+			http.Header{H("etag"): []string{`W/"foo"`}},
+			true,
+		},
+		{
+			"when requested with If-None-Match, and weak ETags differ, it should be stale",
+			http.Header{H("if-none-match"): []string{`W/"1"`}},
+			http.Header{H("etag"): []string{`W/"2"`}},
+			false,
+		},
+		{
+			"when requested with If-None-Match, and strong tag matches weak ETag, it should be fresh",
+			http.Header{H("if-none-match"): []string{`"1"`}},
+			http.Header{H("etag"): []string{`W/"1"`}},
+			true,
+		},
+		{
+			"when requested with If-None-Match, and weak tag is a prefix of ETag, it should be stale",
+			http.Header{H("if-none-match"): []string{`W/"foo"`}},
+			http.Header{H("etag"): []string{`"fooX"`}},
+			false,
+		},
+		{
+			"when requested with If-None-Match, and tag differs only in quotes, it should be stale",
+			http.Header{H("if-none-match"): []string{`W/foo`}},
+			http.Header{H("etag"): []string{`"foo"`}},
+			false,
+		},
+		{
+			"when requested with multiple If-None-Match lines, and one matches, it should be fresh",
+			http.Header{H("if-none-match"): []string{`"bar"`, `"baz" , "foo"`}},
+			http.Header{H("etag"): []string{`"foo"`}},
+			true,
+		},
+		{
+			"when requested with multiple If-None-Match lines, and none matches, it should be stale",
+			http.Header{H("if-none-match"): []string{`"bar"`, `"baz"`}},
+			http.Header{H("etag"): []string{`"foo"`}},
+			false,
+		},
+		{
+			"when requested with If-None-Match, and tabs around commas, it should be fresh",
+			http.Header{H("if-none-match"): []string{"\"bar\"\t,\t\"foo\""}},
+			http.Header{H("etag"): []string{`"foo"`}},
+			true,
+		},
 		{
 			"when requested with If-None-Match, and ETag is missing, it should be stale",
 			http.Header{H("if-none-match"): []string{`"foo"`}},
@@ -405,6 +453,74 @@ func TestParseHeader(t *testing.T) {
 	}
 }
 
+func TestParseLinks(t *testing.T) {
+	tests := []struct {
+		header   string
+		expected map[string]string
+	}{
+		{"", map[string]string{}},
+		{
+			`<http://api.example.com/users?page=2>; rel="next", <http://api.example.com/users?page=5>; rel="last"`,
+			map[string]string{
+				"next": "http://api.example.com/users?page=2",
+				"last": "http://api.example.com/users?page=5",
+			},
+		},
+		{
+			`<http://a/?page=1>;rel=prev,<http://a/?page=3>; title="a; b, c"; REL="Next"`,
+			map[string]string{"prev": "http://a/?page=1", "next": "http://a/?page=3"},
+		},
+		{
+			`<http://a/?ids=1,2>; rel="first prev"; title="\"quoted\""`,
+			map[string]string{"first": "http://a/?ids=1,2", "prev": "http://a/?ids=1,2"},
+		},
+		{
+			`<http://a/1>; rel="next", <http://a/2>; rel="next"`,
+			map[string]string{"next": "http://a/1"},
+		},
+		{`<http://a/1>; title="foo", <http://a/2`, map[string]string{}},
+		{`http://a/1; rel="next"`, map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, ParseLinks(tt.header))
+	}
+}
+
+func TestContentDisposition(t *testing.T) {
+	tests := []struct {
+		filename string
+		expected string
+	}{
+		{"", `attachment; filename=""`},
+		{"foo.png", `attachment; filename="foo.png"`},
+		{"the \"plans\".pdf", `attachment; filename="the \"plans\".pdf"`},
+		{"a\\b.txt", `attachment; filename="a\\b.txt"`},
+		{"€ rates.csv", `attachment; filename="? rates.csv"; filename*=UTF-8''%E2%82%AC%20rates.csv`},
+		{"报表.pdf", `attachment; filename="??.pdf"; filename*=UTF-8''%E6%8A%A5%E8%A1%A8.pdf`},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, ContentDisposition(tt.filename))
+	}
+}
+
+func TestContentDispositionType(t *testing.T) {
+	tests := []struct {
+		dispositionType string
+		filename        string
+		expected        string
+	}{
+		{"inline", "foo.pdf", `inline; filename="foo.pdf"`},
+		{"inline", "报表.pdf", `inline; filename="??.pdf"; filename*=UTF-8''%E6%8A%A5%E8%A1%A8.pdf`},
+		{"attachment", "foo.pdf", `attachment; filename="foo.pdf"`},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, ContentDispositionType(tt.dispositionType, tt.filename))
+	}
+}
+
 func TestGetHeaderValues(t *testing.T) {
 	k := "Content-Type"
 	tests := []struct {
@@ -8,8 +8,12 @@ import (
 	"path"
 	"regexp"
 	"strings"
+	"sync"
 )
 
+// mimeMu guards mimeTypes and charsets, which can be extended at runtime.
+var mimeMu sync.RWMutex
+
 var mimeTypes = map[string]string{
 	"ez":                       "application/andrew-inset",
 	"aw":                       "application/applixware",
@@ -1127,10 +1131,36 @@ var mimeTypes = map[string]string{
 	"urlencoded":               "application/x-www-form-urlencoded",
 }
 
+// charsets contains the custom charsets of MIME Type added by AddCharset.
+var charsets = map[string]string{}
+
 var charsetUTF8Regexp = regexp.MustCompile("^text/|^application/(javascript|json)")
 
+// AddMimeType adds or replaces the MIME Type of the given extension, such as
+// AddMimeType(".wasm", "application/wasm"), the leading dot is optional.
+// It's safe to be called concurrently, typically in init function.
+func AddMimeType(ext, mimeType string) {
+	ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+	mimeMu.Lock()
+	defer mimeMu.Unlock()
+	mimeTypes[ext] = strings.TrimSpace(mimeType)
+}
+
+// AddCharset adds or replaces the charset of the given MIME Type, which is
+// used by LookupCharset. An empty charset means the MIME Type has no charset.
+// It's safe to be called concurrently, typically in init function.
+func AddCharset(mimeType, charset string) {
+	mimeType = strings.ToLower(strings.TrimSpace(mimeType))
+	mimeMu.Lock()
+	defer mimeMu.Unlock()
+	charsets[mimeType] = strings.TrimSpace(charset)
+}
+
 // LookupMimeType lookups the MIME Type of a suffix string.
 func LookupMimeType(s string) string {
+	mimeMu.RLock()
+	defer mimeMu.RUnlock()
+
 	if m, ok := mimeTypes[s]; ok {
 		return m
 	}
@@ -1148,6 +1178,13 @@ func LookupMimeType(s string) string {
 
 // LookupCharset lookups the charset of MIME Type.
 func LookupCharset(mimeType string) string {
+	mimeMu.RLock()
+	charset, ok := charsets[strings.ToLower(strings.TrimSpace(mimeType))]
+	mimeMu.RUnlock()
+	if ok {
+		return charset
+	}
+
 	if charsetUTF8Regexp.MatchString(mimeType) {
 		return "utf-8"
 	}
@@ -5,6 +5,7 @@
 package util
 
 import (
+	"net/http/httptest"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -40,3 +41,53 @@ func TestLookupCharset(t *testing.T) {
 		assert.Equal(t, v, LookupCharset(k))
 	}
 }
+
+func TestAddMimeType(t *testing.T) {
+	assert.Equal(t, "application/octet-stream", LookupMimeType("app.soonx"))
+	AddMimeType(".SoonX", "application/x-soon")
+	defer func() {
+		mimeMu.Lock()
+		delete(mimeTypes, "soonx")
+		mimeMu.Unlock()
+	}()
+
+	tests := map[string]string{
+		"app.soonx":  "application/x-soon",
+		"app.SOONX":  "application/x-soon",
+		".soonx":     "application/x-soon",
+		"soonx":      "application/x-soon",
+		"index.html": "text/html",
+	}
+	for k, v := range tests {
+		assert.Equal(t, v, LookupMimeType(k))
+	}
+
+	w := httptest.NewRecorder()
+	SetContentType(w, ".soonx")
+	assert.Equal(t, "application/x-soon", w.Header().Get("Content-Type"))
+}
+
+func TestAddCharset(t *testing.T) {
+	AddCharset("application/x-soon", "utf-8")
+	AddCharset("text/x-soon", "")
+	defer func() {
+		mimeMu.Lock()
+		delete(charsets, "application/x-soon")
+		delete(charsets, "text/x-soon")
+		mimeMu.Unlock()
+	}()
+
+	tests := map[string]string{
+		"application/x-soon": "utf-8",
+		"Application/X-Soon": "utf-8",
+		"text/x-soon":        "",
+		"text/html":          "utf-8",
+	}
+	for k, v := range tests {
+		assert.Equal(t, v, LookupCharset(k))
+	}
+
+	w := httptest.NewRecorder()
+	SetContentType(w, "application/x-soon")
+	assert.Equal(t, "application/x-soon; charset=utf-8", w.Header().Get("Content-Type"))
+}
@@ -11,6 +11,23 @@ import (
 	"strings"
 )
 
+var (
+	// ErrMalformedRange indicates the range header string is malformed.
+	ErrMalformedRange = errors.New("malformed header string")
+
+	// ErrUnsatisfiableRange indicates none of the ranges overlaps the size,
+	// which is always the case for the zero size.
+	ErrUnsatisfiableRange = errors.New("unsatisifiable range")
+
+	// ErrTooManyRanges indicates the range header string contains more ranges
+	// than MaxRanges.
+	ErrTooManyRanges = errors.New("too many ranges")
+)
+
+// MaxRanges is the maximum count of ranges in the range header string, which
+// avoids the excessive work of a malicious header. Zero means unlimited.
+var MaxRanges = 100
+
 type Range struct {
 	Start int64
 	End   int64
@@ -22,6 +39,21 @@ type Ranges struct {
 	Ranges []*Range
 }
 
+// IsBytes reports whether the unit of ranges is bytes.
+func (r Ranges) IsBytes() bool {
+	return strings.EqualFold(r.Type, "bytes")
+}
+
+// ParseRangeType returns the unit of "Range" header `str`, such as "bytes".
+func ParseRangeType(str string) (string, error) {
+	str = strings.TrimSpace(str)
+	index := strings.Index(str, "=")
+	if index <= 0 {
+		return "", ErrMalformedRange
+	}
+	return strings.TrimSpace(str[:index]), nil
+}
+
 type rangeSortBy func(r1, r2 *Range) bool
 
 func (by rangeSortBy) sort(ranges []*Range) {
@@ -49,17 +81,27 @@ func (r *rangeSorter) Less(i, j int) bool {
 // RangeParser parses "Range" header `str` relative to the given file `size`.
 //
 // The "combine" argument can be set to `true` and overlapping & adjacent ranges
-// * will be combined into a single range.
+// * will be combined into a single range. Otherwise only the identical ranges
+// are deduplicated, and the order is preserved.
+//
+// ErrMalformedRange is returned if the header isn't syntactically valid.
+// ErrTooManyRanges is returned if there are more ranges than MaxRanges.
+// ErrUnsatisfiableRange is returned if none of the ranges is satisfiable,
+// including any range of the zero size, in which case the server should
+// respond 416 with the `Content-Range: bytes */0` header.
 func RangeParser(size int64, str string, combine bool) (Ranges, error) {
 	str = strings.TrimSpace(str)
 	index := strings.Index(str, "=")
 	ranges := Ranges{}
 
 	if index == -1 || index == len(str)-1 {
-		return ranges, errors.New("malformed header string")
+		return ranges, ErrMalformedRange
 	}
 
 	arr := strings.Split(str[index+1:], ",")
+	if MaxRanges > 0 && len(arr) > MaxRanges {
+		return Ranges{}, ErrTooManyRanges
+	}
 	ranges.Ranges = make([]*Range, 0, len(arr))
 
 	// add ranges type
@@ -68,14 +110,28 @@ func RangeParser(size int64, str string, combine bool) (Ranges, error) {
 	// parse all ranges
 	for i, v := range arr {
 		v = strings.TrimSpace(v)
-		values := strings.Split(v, "-")
-		start, err1 := strconv.ParseInt(values[0], 10, 64)
-		end, err2 := strconv.ParseInt(values[1], 10, 64)
+		values := strings.SplitN(v, "-", 2)
+		if len(values) != 2 || values[0] == "" && values[1] == "" {
+			return Ranges{}, ErrMalformedRange
+		}
+
+		var start, end int64
+		var err error
+		if values[0] != "" {
+			if start, err = strconv.ParseInt(values[0], 10, 64); err != nil {
+				return Ranges{}, ErrMalformedRange
+			}
+		}
+		if values[1] != "" {
+			if end, err = strconv.ParseInt(values[1], 10, 64); err != nil {
+				return Ranges{}, ErrMalformedRange
+			}
+		}
 
-		if err1 != nil {
+		if values[0] == "" {
 			start = size - end
 			end = size - 1
-		} else if err2 != nil {
+		} else if values[1] == "" {
 			end = size - 1
 		}
 
@@ -94,7 +150,7 @@ func RangeParser(size int64, str string, combine bool) (Ranges, error) {
 
 	// unsatisifiable
 	if len(ranges.Ranges) == 0 {
-		return Ranges{}, errors.New("unsatisifiable range")
+		return Ranges{}, ErrUnsatisfiableRange
 	}
 
 	if combine {
@@ -102,9 +158,29 @@ func RangeParser(size int64, str string, combine bool) (Ranges, error) {
 		return ranges, nil
 	}
 
+	dedupRanges(&ranges)
 	return ranges, nil
 }
 
+// Remove the duplicate ranges, the first one of them is retained in order.
+func dedupRanges(r *Ranges) {
+	j := 0
+	for i, ra := range r.Ranges {
+		duplicate := false
+		for _, kept := range r.Ranges[:j] {
+			if ra.Start == kept.Start && ra.End == kept.End {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			r.Ranges[j] = r.Ranges[i]
+			j++
+		}
+	}
+	r.Ranges = r.Ranges[:j]
+}
+
 // Combine overlapping & adjacent ranges.
 func combineRanges(r *Ranges) {
 	rangeSortBy(sortByRangeStart).sort(r.Ranges)
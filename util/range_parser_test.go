@@ -6,6 +6,7 @@ package util
 
 import (
 	"errors"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -45,6 +46,36 @@ func TestRangeParser(t *testing.T) {
 			str:  "bytes=500-999,1000-1499",
 			err:  errors.New("unsatisifiable range"),
 		},
+		{
+			desc: "should return error for zero size",
+			size: 0,
+			str:  "bytes=0-",
+			err:  ErrUnsatisfiableRange,
+		},
+		{
+			desc: "should return error for suffix range of zero size",
+			size: 0,
+			str:  "bytes=-1",
+			err:  ErrUnsatisfiableRange,
+		},
+		{
+			desc: "should return error for malformed range of zero size",
+			size: 0,
+			str:  "bytes=abc",
+			err:  ErrMalformedRange,
+		},
+		{
+			desc: "should return error for range without start and end",
+			size: 200,
+			str:  "bytes=0-5,-",
+			err:  ErrMalformedRange,
+		},
+		{
+			desc: "should return error for non-numeric end",
+			size: 200,
+			str:  "bytes=0-x",
+			err:  ErrMalformedRange,
+		},
 		{
 			desc: "should parse str",
 			size: 1000,
@@ -148,6 +179,40 @@ func TestRangeParser(t *testing.T) {
 				Ranges: []*Range{{Start: 0, End: 5}},
 			},
 		},
+		{
+			desc: "should dedup identical ranges when combine is false",
+			size: 1000,
+			str:  "bytes=0-0,0-0",
+			expectedRanges: Ranges{
+				Type:   "bytes",
+				Ranges: []*Range{{Start: 0, End: 0, index: 0}},
+			},
+		},
+		{
+			desc: "should retain original order when dedup",
+			size: 1000,
+			str:  "bytes=10-20,0-5,10-20,-1,999-",
+			expectedRanges: Ranges{
+				Type: "bytes",
+				Ranges: []*Range{
+					{Start: 10, End: 20, index: 0},
+					{Start: 0, End: 5, index: 1},
+					{Start: 999, End: 999, index: 3},
+				},
+			},
+		},
+		{
+			desc: "should not combine overlapping ranges when combine is false",
+			size: 1000,
+			str:  "bytes=0-10,5-15,0-10",
+			expectedRanges: Ranges{
+				Type: "bytes",
+				Ranges: []*Range{
+					{Start: 0, End: 10, index: 0},
+					{Start: 5, End: 15, index: 1},
+				},
+			},
+		},
 		{
 			desc:    "should combine overlapping ranges when combine is true",
 			size:    150,
@@ -201,9 +266,67 @@ func TestRangeParser(t *testing.T) {
 			}
 
 			assert.Equal(t, tt.expectedRanges.Type, ranges.Type)
+			require.Len(t, ranges.Ranges, len(tt.expectedRanges.Ranges))
 			for i, r := range tt.expectedRanges.Ranges {
 				assert.Equal(t, *r, *ranges.Ranges[i])
 			}
 		})
 	}
 }
+
+func TestRangeParser_MaxRanges(t *testing.T) {
+	defer func(n int) { MaxRanges = n }(MaxRanges)
+
+	str := "bytes=" + strings.TrimSuffix(strings.Repeat("0-1,", 101), ",")
+	_, err := RangeParser(200, str, false)
+	assert.Equal(t, ErrTooManyRanges, err)
+
+	ranges, err := RangeParser(200, "bytes="+strings.TrimSuffix(strings.Repeat("0-1,", 100), ","), false)
+	require.NoError(t, err)
+	assert.Len(t, ranges.Ranges, 1)
+
+	MaxRanges = 2
+	_, err = RangeParser(200, "bytes=0-1,2-3,4-5", false)
+	assert.Equal(t, ErrTooManyRanges, err)
+
+	MaxRanges = 0
+	_, err = RangeParser(200, str, false)
+	assert.NoError(t, err)
+}
+
+func TestRanges_IsBytes(t *testing.T) {
+	tests := []struct {
+		ranges   Ranges
+		expected bool
+	}{
+		{Ranges{Type: "bytes"}, true},
+		{Ranges{Type: "Bytes"}, true},
+		{Ranges{Type: "items"}, false},
+		{Ranges{}, false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tt.ranges.IsBytes())
+	}
+}
+
+func TestParseRangeType(t *testing.T) {
+	tests := []struct {
+		str      string
+		expected string
+		err      error
+	}{
+		{"bytes=0-5", "bytes", nil},
+		{"  items = 0-5", "items", nil},
+		{"bytes=", "bytes", nil},
+		{"0-5", "", ErrMalformedRange},
+		{"=0-5", "", ErrMalformedRange},
+		{"", "", ErrMalformedRange},
+	}
+
+	for _, tt := range tests {
+		unit, err := ParseRangeType(tt.str)
+		assert.Equal(t, tt.err, err)
+		assert.Equal(t, tt.expected, unit)
+	}
+}
@@ -5,6 +5,10 @@
 package util
 
 import (
+	"context"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
 	"net/url"
 	"os"
 	"path/filepath"
@@ -86,9 +90,13 @@ func FileExists(absPath string) bool {
 	return true
 }
 
+// uriReserved are the reserved characters of URI, which are kept by EncodeURI
+// and DecodeURI.
+const uriReserved = ";/?:@&=+$,#"
+
 // EncodeURI encodes a text string as a valid Uniform Resource Identifier (URI)
 func EncodeURI(str string) string {
-	excludes := ";/?:@&=+$,#"
+	excludes := uriReserved
 	arr := strings.Split(str, "")
 	result := ""
 	for _, v := range arr {
@@ -109,6 +117,50 @@ func EncodeURIComponent(str string) string {
 	return r
 }
 
+// DecodeURI decodes a Uniform Resource Identifier (URI) encoded by EncodeURI,
+// the escape sequences of the reserved characters, such as "%2F", are kept.
+func DecodeURI(str string) (string, error) {
+	return decodeURI(str, uriReserved)
+}
+
+// DecodeURIComponent decodes a component of Uniform Resource Identifier (URI)
+// encoded by EncodeURIComponent. Unlike url.QueryUnescape, "+" is kept as it
+// is.
+func DecodeURIComponent(str string) (string, error) {
+	return url.PathUnescape(str)
+}
+
+// decodeURI decodes the escape sequences of str, except the ones of the
+// characters in excludes.
+func decodeURI(str, excludes string) (string, error) {
+	if !strings.Contains(str, "%") {
+		return str, nil
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(str))
+	for i := 0; i < len(str); i++ {
+		if str[i] != '%' {
+			sb.WriteByte(str[i])
+			continue
+		}
+		if i+2 >= len(str) {
+			return "", url.EscapeError(str[i:])
+		}
+		b, err := hex.DecodeString(str[i+1 : i+3])
+		if err != nil {
+			return "", url.EscapeError(str[i : i+3])
+		}
+		if strings.IndexByte(excludes, b[0]) >= 0 {
+			sb.WriteString(str[i : i+3])
+		} else {
+			sb.WriteByte(b[0])
+		}
+		i += 2
+	}
+	return sb.String(), nil
+}
+
 // StringToBytes converts string to byte slice without a memory allocation.
 func StringToBytes(s string) (b []byte) {
 	sh := *(*reflect.StringHeader)(unsafe.Pointer(&s))
@@ -168,3 +220,56 @@ func IsFileExist(path string) bool {
 
 	return true
 }
+
+// ReadAllContext reads from r until an error or EOF like ioutil.ReadAll, but
+// returns the error of ctx as soon as ctx is done, such as the deadline
+// exceeded, even if a read of a slow client is still in flight. The reading
+// runs on another goroutine then, and r is closed if it's an io.Closer, so
+// that the pending read is interrupted. A panic of r is re-panicked on the
+// caller's goroutine.
+func ReadAllContext(ctx context.Context, r io.Reader) ([]byte, error) {
+	if ctx.Done() == nil {
+		return ioutil.ReadAll(r)
+	}
+	if err := ctx.Err(); err != nil {
+		closeReader(r)
+		return nil, err
+	}
+
+	result := make(chan readResult, 1)
+	go func() {
+		var res readResult
+		defer func() {
+			res.recovered = recover()
+			result <- res
+		}()
+		res.data, res.err = ioutil.ReadAll(r)
+	}()
+
+	select {
+	case res := <-result:
+		if res.recovered != nil {
+			panic(res.recovered)
+		}
+		return res.data, res.err
+	case <-ctx.Done():
+		// the body of http request can't be closed until the pending read
+		// returns, so it's closed in background.
+		go closeReader(r)
+		return nil, ctx.Err()
+	}
+}
+
+// readResult is the result of ReadAllContext read on another goroutine.
+type readResult struct {
+	data      []byte
+	err       error
+	recovered interface{}
+}
+
+// closeReader closes r if it's an io.Closer.
+func closeReader(r io.Reader) {
+	if c, ok := r.(io.Closer); ok {
+		c.Close()
+	}
+}
@@ -5,9 +5,12 @@
 package util
 
 import (
+	"context"
+	"io"
 	"math/rand"
 	"os"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -186,6 +189,62 @@ func TestEncodeURIComponent(t *testing.T) {
 	}
 }
 
+func TestDecodeURI(t *testing.T) {
+	tests := map[string]string{
+		"foo":                             "foo",
+		"foo%25bar":                       "foo%bar",
+		"http://a.com/%E4%BD%A0%E5%A5%BD": "http://a.com/你好",
+		"/a%2Fb%3Fc%20d":                  "/a%2Fb%3Fc d",
+		"a+b":                             "a+b",
+	}
+	for k, v := range tests {
+		s, err := DecodeURI(k)
+		assert.NoError(t, err)
+		assert.Equal(t, v, s)
+	}
+
+	for _, s := range []string{"%", "foo%2", "foo%zz"} {
+		_, err := DecodeURI(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestDecodeURIComponent(t *testing.T) {
+	tests := map[string]string{
+		"foo":       "foo",
+		"foo%25bar": "foo%bar",
+		"http%3A%2F%2Fa.com%2F%E4%BD%A0%E5%A5%BD": "http://a.com/你好",
+		"a+b%20c": "a+b c",
+	}
+	for k, v := range tests {
+		s, err := DecodeURIComponent(k)
+		assert.NoError(t, err)
+		assert.Equal(t, v, s)
+	}
+
+	_, err := DecodeURIComponent("foo%zz")
+	assert.Error(t, err)
+}
+
+func TestURIRoundTrip(t *testing.T) {
+	tests := []string{
+		"foo bar",
+		"你好, Go_шеллы!",
+		";/?:@&=+$,#",
+		"100% 😀 a+b=c",
+		"http://a.com/path?q=你好&x=1#frag",
+	}
+	for _, s := range tests {
+		decoded, err := DecodeURIComponent(EncodeURIComponent(s))
+		assert.NoError(t, err)
+		assert.Equal(t, s, decoded)
+
+		decoded, err = DecodeURI(EncodeURI(s))
+		assert.NoError(t, err)
+		assert.Equal(t, s, decoded)
+	}
+}
+
 func TestStringToBytes(t *testing.T) {
 	for i := 0; i < 100; i++ {
 		str := generateRandomString(20)
@@ -314,3 +373,85 @@ func TestIsFileExist(t *testing.T) {
 		assert.Equal(t, tt.expected, IsFileExist(tt.path))
 	}
 }
+
+type slowReader struct {
+	delay time.Duration
+	n     int
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if r.n <= 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(r.delay)
+	r.n--
+	p[0] = 'a'
+	return 1, nil
+}
+
+func TestReadAllContext(t *testing.T) {
+	data, err := ReadAllContext(context.Background(), strings.NewReader("foo"))
+	assert.NoError(t, err)
+	assert.Equal(t, "foo", string(data))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	data, err = ReadAllContext(ctx, strings.NewReader("bar"))
+	cancel()
+	assert.NoError(t, err)
+	assert.Equal(t, "bar", string(data))
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	data, err = ReadAllContext(ctx, &slowReader{10 * time.Millisecond, 100})
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Nil(t, data)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second))
+
+	data, err = ReadAllContext(ctx, strings.NewReader("baz"))
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Nil(t, data)
+
+	// the pending read of a stalled client is interrupted by closing the body
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	r := &blockingReader{closed: make(chan struct{})}
+	start = time.Now()
+	data, err = ReadAllContext(ctx, r)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.Nil(t, data)
+	assert.Less(t, int64(time.Since(start)), int64(500*time.Millisecond))
+	select {
+	case <-r.closed:
+	case <-time.After(time.Second):
+		t.Error("the reader isn't closed")
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	assert.PanicsWithValue(t, "boom", func() {
+		ReadAllContext(ctx, panicReader{})
+	})
+}
+
+// blockingReader blocks the read until it's closed.
+type blockingReader struct {
+	closed chan struct{}
+	once   sync.Once
+}
+
+func (r *blockingReader) Read(p []byte) (int, error) {
+	<-r.closed
+	return 0, io.ErrClosedPipe
+}
+
+func (r *blockingReader) Close() error {
+	r.once.Do(func() { close(r.closed) })
+	return nil
+}
+
+type panicReader struct{}
+
+func (panicReader) Read(p []byte) (int, error) {
+	panic("boom")
+}